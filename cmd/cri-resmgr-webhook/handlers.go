@@ -23,6 +23,7 @@ import (
 	"io"
 	"log"
 	"net/http"
+	"strconv"
 
 	admissionv1 "k8s.io/api/admission/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -36,9 +37,12 @@ import (
 type jsonPatch struct {
 	Op    string      `json:"op"`
 	Path  string      `json:"path"`
-	Value interface{} `json:"value"`
+	Value interface{} `json:"value,omitempty"`
 }
 
+// priorityAnnotation is the annotation the pod priority is duplicated as.
+const priorityAnnotation = "cri-resource-manager.intel.com/priority"
+
 type podResourceRequirements struct {
 	InitContainers map[string]corev1.ResourceRequirements `json:"initContainers"`
 	Containers     map[string]corev1.ResourceRequirements `json:"containers"`
@@ -151,6 +155,10 @@ func mutatePodObject(rawObj *runtime.RawExtension) *admissionv1.AdmissionRespons
 	}
 	patches = append(patches, patch)
 
+	if patch, ok := patchPriorityAnnotation(&pod); ok {
+		patches = append(patches, patch)
+	}
+
 	reviewResponse.Patch, err = json.Marshal(patches)
 	if err != nil {
 		log.Printf("ERROR: failed to marshal Pod patch: %v", err)
@@ -186,3 +194,22 @@ func patchResourceAnnotation(pod *corev1.Pod) (jsonPatch, error) {
 
 	return patch, nil
 }
+
+// Create a Pod (JSON) patch setting the pod priority annotation from the pod spec.
+// Any priority annotation set by the user is overwritten, or removed if the pod
+// has no priority, so the annotation cannot be used to claim a higher priority.
+func patchPriorityAnnotation(pod *corev1.Pod) (jsonPatch, bool) {
+	patch := jsonPatch{Op: "add", Path: "/metadata/annotations/cri-resource-manager.intel.com~1priority"}
+
+	if pod.Spec.Priority == nil {
+		if _, ok := pod.ObjectMeta.Annotations[priorityAnnotation]; !ok {
+			return patch, false
+		}
+		patch.Op = "remove"
+		return patch, true
+	}
+
+	patch.Value = strconv.FormatInt(int64(*pod.Spec.Priority), 10)
+
+	return patch, true
+}
@@ -181,6 +181,15 @@ func (g Group) Write(entry, format string, args ...interface{}) error {
 	return nil
 }
 
+// Read reads the content of the groups entry, with trailing whitespace trimmed.
+func (g Group) Read(entry string) (string, error) {
+	data, err := os.ReadFile(path.Join(string(g), entry))
+	if err != nil {
+		return "", g.errorf("%q: failed to read: %v", entry, err)
+	}
+	return strings.TrimRight(string(data), " \t\n"), nil
+}
+
 // readPids reads pids from a cgroup's tasks or procs entry.
 func (g Group) readPids(entry string) ([]string, error) {
 	var pids []string
@@ -37,6 +37,12 @@ const (
 	CpusetCpus = "cpuset.cpus"
 	// CpusetMems is the cpuset controller's cpuset.mems entry.
 	CpusetMems = "cpuset.mems"
+	// CpusetPartition is the cgroup v2 cpuset controller's cpuset.cpus.partition entry.
+	CpusetPartition = "cpuset.cpus.partition"
+	// CpusetExclusive is the cgroup v2 cpuset controller's cpuset.cpus.exclusive entry.
+	CpusetExclusive = "cpuset.cpus.exclusive"
+	// NetClsClassID is the net_cls controller's net_cls.classid entry.
+	NetClsClassID = "net_cls.classid"
 	// Controllers is the cgroup v2 controllers file
 	Controllers = "cgroup.controllers"
 )
@@ -15,14 +15,18 @@
 package cache
 
 import (
+	"crypto/cipher"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	v1 "k8s.io/api/core/v1"
 	criv1 "k8s.io/cri-api/pkg/apis/runtime/v1"
@@ -49,6 +53,8 @@ const (
 	Memory = "memory"
 	// PageMigration marks changes that can be applied by the PageMigration controller.
 	PageMigration = "page-migration"
+	// NetClass marks changes that can be applied by the network class controller.
+	NetClass = "netclass"
 
 	// TagAVX512 tags containers that use AVX512 instructions.
 	TagAVX512 = "AVX512"
@@ -68,6 +74,11 @@ const (
 
 	// TopologyHintsKey can be used to opt out from automatic topology hint generation.
 	TopologyHintsKey = "topologyhints" + "." + kubernetes.ResmgrKeyNamespace
+
+	// UnmanagedKey can be used to exclude containers from resource management.
+	UnmanagedKey = "unmanaged" + "." + kubernetes.ResmgrKeyNamespace
+	// UnmanagedPrivileged is the UnmanagedKey value for excluding only privileged containers.
+	UnmanagedPrivileged = "privileged"
 )
 
 // allControllers is a slice of all controller domains.
@@ -118,6 +129,8 @@ type Pod interface {
 	GetNamespace() string
 	// GetState returns the PodState of the pod.
 	GetState() PodState
+	// GetCreatedAt returns the time the pod was created, or first seen.
+	GetCreatedAt() time.Time
 	// GetQOSClass returns the PodQOSClass of the pod.
 	GetQOSClass() v1.PodQOSClass
 	// GetLabelKeys returns the keys of all pod labels as a string slice.
@@ -182,6 +195,7 @@ type pod struct {
 	Name         string            // pod sandbox name
 	Namespace    string            // pod namespace
 	State        PodState          // ready/not ready
+	CreatedAt    time.Time         // creation time, or time first seen
 	QOSClass     v1.PodQOSClass    // pod QoS class
 	Labels       map[string]string // pod labels
 	Annotations  map[string]string // pod annotations
@@ -232,6 +246,12 @@ type Container interface {
 	UpdateState(ContainerState)
 	// GetState returns the ContainerState of the container.
 	GetState() ContainerState
+	// GetCreatedAt returns the time the container was created, or first seen.
+	GetCreatedAt() time.Time
+	// GetRestartCount returns the number of times the container has been restarted.
+	GetRestartCount() int
+	// IsManaged returns false if the container is excluded from resource management.
+	IsManaged() bool
 	// GetQOSClass returns the QoS class the pod would have if this was its only container.
 	GetQOSClass() v1.PodQOSClass
 	// GetImage returns the image of the container.
@@ -283,6 +303,15 @@ type Container interface {
 	GetDeviceByHost(string) *Device
 	// GetDeviceByContainer returns the device for a container path.
 	GetDeviceByContainer(string) *Device
+	// GetSecurityContext returns the security context of the container.
+	GetSecurityContext() SecurityContext
+	// IsPrivileged returns true if the container is privileged.
+	IsPrivileged() bool
+	// IsHostNetwork returns true if the container uses the network namespace of the host.
+	IsHostNetwork() bool
+	// HasCapability returns true if the container has the given capability added,
+	// or if it is privileged. Capabilities can be given with or without "CAP_".
+	HasCapability(string) bool
 	// GetResourceRequirements returns the webhook-annotated requirements for ths container.
 	GetResourceRequirements() v1.ResourceRequirements
 	// GetLinuxResources returns the CRI linux resource request of the container.
@@ -330,6 +359,8 @@ type Container interface {
 	GetCpusetCpus() string
 	// GetCpusetMems gets the cgroup cpuset.mems of the container.
 	GetCpusetMems() string
+	// GetUnifiedResource gets a cgroup v2 unified resource of the container (for instance "memory.low").
+	GetUnifiedResource(string) (string, bool)
 
 	// SetLinuxResources sets the Linux-specific resource request of the container.
 	SetLinuxResources(*criv1.LinuxContainerResources)
@@ -347,6 +378,10 @@ type Container interface {
 	SetCpusetCpus(string)
 	// SetCpusetMems sets the cgroup cpuset.mems of the container.
 	SetCpusetMems(string)
+	// SetHugepageLimit sets the hugetlb limit in bytes for the given page size (for instance "1GB").
+	SetHugepageLimit(string, uint64)
+	// SetUnifiedResource sets a cgroup v2 unified resource of the container (for instance "memory.low").
+	SetUnifiedResource(string, string)
 
 	// GetAffinity returns the annotated affinity expressions for this container.
 	GetAffinity() ([]*Affinity, error)
@@ -364,6 +399,11 @@ type Container interface {
 	// GetBlockIOClass returns the BlockIO class for this container.
 	GetBlockIOClass() string
 
+	// SetNetClass sets the network class (net_cls classid) of this container.
+	SetNetClass(string)
+	// GetNetClass returns the network class of this container.
+	GetNetClass() string
+
 	// SetToptierLimit sets the tier memory limit for the container.
 	SetToptierLimit(int64)
 	// GetToptierLimit returns the top tier memory limit for the container.
@@ -417,7 +457,10 @@ type container struct {
 	Name          string             // container name
 	Namespace     string             // container namespace
 	State         ContainerState     // created/running/exited/unknown
+	CreatedAt     time.Time          // creation time, or time first seen
+	RestartCount  int                // number of restarts, from the CRI attempt counter
 	Image         string             // containers image
+	UserImage     string             // image as specified by the user, if known
 	Command       []string           // command to run in container
 	Args          []string           // arguments for command
 	Labels        map[string]string  // container labels
@@ -425,17 +468,20 @@ type container struct {
 	Env           map[string]string  // environment variables
 	Mounts        map[string]*Mount  // mounts
 	Devices       map[string]*Device // devices
+	Security      *SecurityContext   // security context
 	TopologyHints topology.Hints     // Set of topology hints for all containers within Pod
 	Tags          map[string]string  // container tags (local dynamic labels)
 	Adjustment    string             // name of applicable external adjustment, if any
 
 	Resources v1.ResourceRequirements        // container resources (from webhook annotation)
 	LinuxReq  *criv1.LinuxContainerResources // used to estimate Resources if we lack annotations
+	Estimated bool                           // Resources estimated from LinuxReq
 	req       *interface{}                   // pending CRI request
 
 	CgroupDir    string       // cgroup directory relative to a(ny) controller.
 	RDTClass     string       // RDT class this container is assigned to.
 	BlockIOClass string       // Block I/O class this container is assigned to.
+	NetClass     string       // Network class (net_cls classid) of this container.
 	ToptierLimit int64        // Top tier memory limit.
 	PageMigrate  *PageMigrate // Page migration policy/options for this container.
 
@@ -480,10 +526,40 @@ type Device struct {
 	Permissions string
 }
 
+// SecurityContext is the security context of a container.
+type SecurityContext struct {
+	// Privileged is true for privileged containers.
+	Privileged bool
+	// HostNetwork is true if the container uses the network namespace of the host.
+	HostNetwork bool
+	// AddCapabilities are the capabilities added to the container.
+	AddCapabilities []string `json:",omitempty"`
+	// DropCapabilities are the capabilities dropped from the container.
+	DropCapabilities []string `json:",omitempty"`
+}
+
 // PageMigrate contains the policy/preferences for container page migration.
 type PageMigrate struct {
-	SourceNodes idset.IDSet // idle memory pages on these NUMA nodes
-	TargetNodes idset.IDSet // should be migrated to these NUMA nodes
+	SourceNodes idset.IDSet   // idle memory pages on these NUMA nodes
+	TargetNodes idset.IDSet   // should be migrated to these NUMA nodes
+	Tiers       []idset.IDSet // optional memory tiers, fastest first, to demote along
+}
+
+// Steps returns the ordered demotion steps for the page migration policy.
+// If multiple memory tiers are set, idle pages are demoted from each tier
+// to the next one. Otherwise pages are demoted from SourceNodes to TargetNodes.
+func (pm *PageMigrate) Steps() []*PageMigrate {
+	if pm == nil {
+		return nil
+	}
+	if len(pm.Tiers) < 2 {
+		return []*PageMigrate{{SourceNodes: pm.SourceNodes, TargetNodes: pm.TargetNodes}}
+	}
+	steps := make([]*PageMigrate, 0, len(pm.Tiers)-1)
+	for i := 0; i < len(pm.Tiers)-1; i++ {
+		steps = append(steps, &PageMigrate{SourceNodes: pm.Tiers[i], TargetNodes: pm.Tiers[i+1]})
+	}
+	return steps
 }
 
 // Clone creates a copy of the page migration policy/preferences.
@@ -498,6 +574,9 @@ func (pm *PageMigrate) Clone() *PageMigrate {
 	if pm.TargetNodes != nil {
 		c.TargetNodes = pm.TargetNodes.Clone()
 	}
+	for _, tier := range pm.Tiers {
+		c.Tiers = append(c.Tiers, tier.Clone())
+	}
 	return c
 }
 
@@ -532,14 +611,25 @@ type Cache interface {
 	LookupContainer(id string) (Container, bool)
 	// LookupContainerByCgroup looks up a container for the given cgroup path.
 	LookupContainerByCgroup(path string) (Container, bool)
+	// SelfCheck verifies the internal consistency of the cache, returning any violations found.
+	SelfCheck() []error
 
 	// GetPendingContainers returs all containers with pending changes.
 	GetPendingContainers() []Container
 
-	// GetPods returns all the pods known to the cache.
+	// GetPods returns all the pods known to the cache, sorted by creation time and ID.
 	GetPods() []Pod
-	// GetContainers returns all the containers known to the cache.
+	// GetPodsByNamespace returns all the pods in the given namespace, sorted like GetPods.
+	GetPodsByNamespace(namespace string) []Pod
+	// GetContainers returns all the containers known to the cache, sorted by
+	// creation time and cache ID.
 	GetContainers() []Container
+	// GetContainersSnapshot returns a detached copy of all containers and their
+	// pods. The cache does not lock itself, so like other cache accessors it must
+	// be called with the resource manager lock held. Changes to the copies are
+	// not reflected in the cache, and the copies are not updated by later changes
+	// to the cache.
+	GetContainersSnapshot() []Container
 
 	// GetContainerCacheIds returns the cache ids of all containers.
 	GetContainerCacheIds() []string
@@ -559,6 +649,7 @@ type Cache interface {
 	SetActivePolicy(string) error
 
 	// ResetActivePolicy clears the active policy any any policy-specific data from the cache.
+	// Controller entries are not cleared.
 	ResetActivePolicy() error
 
 	// SetPolicyEntry sets the policy entry for a key.
@@ -566,12 +657,22 @@ type Cache interface {
 	// GetPolicyEntry gets the policy entry for a key.
 	GetPolicyEntry(string, interface{}) bool
 
+	// SetControllerEntry sets the entry of a controller for a key. Like policy
+	// entries, controller entries are stored when the cache is saved.
+	SetControllerEntry(string, string, interface{})
+	// GetControllerEntry gets the entry of a controller for a key.
+	GetControllerEntry(string, string, interface{}) bool
+
 	// SetConfig caches the given configuration.
 	SetConfig(*config.RawConfig) error
 	// GetConfig returns the current/cached configuration.
 	GetConfig() *config.RawConfig
 	// ResetConfig clears any stored configuration from the cache.
 	ResetConfig() error
+	// AddConfigNotify registers a named callback for configuration changes.
+	AddConfigNotify(string, ConfigNotifyFn) error
+	// DeleteConfigNotify removes a previously registered configuration change callback.
+	DeleteConfigNotify(string)
 
 	// SetAdjustment updates external adjustments and containers based this.
 	SetAdjustment(*config.Adjustment) (bool, map[string]error)
@@ -613,14 +714,16 @@ var (
 
 // Our cache of objects.
 type cache struct {
-	sync.Mutex    `json:"-"` // we're lockable
-	logger.Logger `json:"-"` // cache logger instance
-	filePath      string     // where to store to/load from
-	dataDir       string     // container data directory
+	sync.Mutex    `json:"-"`  // we're lockable
+	logger.Logger `json:"-"`  // cache logger instance
+	filePath      string      // where to store to/load from
+	dataDir       string      // container data directory
+	aead          cipher.AEAD // snapshot encryption, if enabled
 
-	Pods       map[string]*pod       // known/cached pods
-	Containers map[string]*container // known/cache containers
-	NextID     uint64                // next container cache id to use
+	Pods       map[string]*pod            // known/cached pods
+	namespaces map[string]map[string]*pod // known/cached pods by namespace
+	Containers map[string]*container      // known/cache containers
+	NextID     uint64                     // next container cache id to use
 
 	Cfg        *config.RawConfig      // cached/current configuration
 	External   *config.Adjustment     // cached/current external adjustments
@@ -628,11 +731,18 @@ type cache struct {
 	policyData map[string]interface{} // opaque policy data
 	PolicyJSON map[string]string      // ditto in raw, unmarshaled form
 
+	ControllerJSON map[string]map[string]string // opaque controller data, per controller
+
 	pending map[string]struct{} // cache IDs of containers with pending changes
 
 	implicit map[string]ImplicitAffinity // implicit affinities
+	notify   map[string]ConfigNotifyFn   // configuration change callbacks
 }
 
+// ConfigNotifyFn is called with the old and new configuration after a change.
+// Either of them is nil if there was no configuration before or after.
+type ConfigNotifyFn func(oldCfg, newCfg *config.RawConfig)
+
 // Make sure cache implements Cache.
 var _ Cache = &cache{}
 
@@ -640,6 +750,9 @@ var _ Cache = &cache{}
 type Options struct {
 	// CacheDir is the directory the cache should save its state in.
 	CacheDir string
+	// EncryptionKeyFile is the file to read the snapshot encryption key from.
+	// If not given, the key is taken from EncryptionKeyEnv, if set.
+	EncryptionKeyFile string
 }
 
 // NewCache instantiates a new cache. Load it from the given path if it exists.
@@ -649,11 +762,23 @@ func NewCache(options Options) (Cache, error) {
 		dataDir:    filepath.Join(options.CacheDir, "containers"),
 		Logger:     logger.NewLogger("cache"),
 		Pods:       make(map[string]*pod),
+		namespaces: make(map[string]map[string]*pod),
 		Containers: make(map[string]*container),
 		NextID:     1,
 		policyData: make(map[string]interface{}),
 		PolicyJSON: make(map[string]string),
 		implicit:   make(map[string]ImplicitAffinity),
+		notify:     make(map[string]ConfigNotifyFn),
+
+		ControllerJSON: make(map[string]map[string]string),
+	}
+
+	key, err := loadEncryptionKey(options.EncryptionKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	if err := cch.setupEncryption(key); err != nil {
+		return nil, err
 	}
 
 	if _, err := cch.checkPerm("cache", cch.filePath, false, cacheFilePerm); err != nil {
@@ -705,6 +830,8 @@ func (cch *cache) SetConfig(cfg *config.RawConfig) error {
 		return err
 	}
 
+	cch.notifyConfig(old, cfg)
+
 	return nil
 }
 
@@ -723,9 +850,38 @@ func (cch *cache) ResetConfig() error {
 		return err
 	}
 
+	cch.notifyConfig(old, nil)
+
 	return nil
 }
 
+// AddConfigNotify registers a named callback for configuration changes.
+func (cch *cache) AddConfigNotify(name string, fn ConfigNotifyFn) error {
+	if _, ok := cch.notify[name]; ok {
+		return cacheError("configuration notifier %s already registered", name)
+	}
+	cch.notify[name] = fn
+	return nil
+}
+
+// DeleteConfigNotify removes a previously registered configuration change callback.
+func (cch *cache) DeleteConfigNotify(name string) {
+	delete(cch.notify, name)
+}
+
+// notifyConfig calls all registered callbacks, in the order of their names.
+func (cch *cache) notifyConfig(oldCfg, newCfg *config.RawConfig) {
+	names := make([]string, 0, len(cch.notify))
+	for name := range cch.notify {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		cch.Debug("notifying %s about configuration change", name)
+		cch.notify[name](oldCfg, newCfg)
+	}
+}
+
 // SetAdjustment updates external adjustments and containers based on this.
 func (cch *cache) SetAdjustment(external *config.Adjustment) (bool, map[string]error) {
 	effective := map[*container]string{}
@@ -871,7 +1027,11 @@ func (cch *cache) InsertPod(id string, msg interface{}, status *PodStatus) (Pod,
 		return nil, err
 	}
 
+	if old, ok := cch.Pods[p.ID]; ok {
+		cch.unindexPod(old)
+	}
 	cch.Pods[p.ID] = p
+	cch.indexPod(p)
 
 	cch.Save()
 
@@ -887,12 +1047,35 @@ func (cch *cache) DeletePod(id string) Pod {
 
 	cch.Debug("removing pod %s (%s)", p.Name, p.ID)
 	delete(cch.Pods, id)
+	cch.unindexPod(p)
 
 	cch.Save()
 
 	return p
 }
 
+// indexPod adds a pod to the namespace index.
+func (cch *cache) indexPod(p *pod) {
+	pods, ok := cch.namespaces[p.Namespace]
+	if !ok {
+		pods = make(map[string]*pod)
+		cch.namespaces[p.Namespace] = pods
+	}
+	pods[p.ID] = p
+}
+
+// unindexPod removes a pod from the namespace index.
+func (cch *cache) unindexPod(p *pod) {
+	pods, ok := cch.namespaces[p.Namespace]
+	if !ok {
+		return
+	}
+	delete(pods, p.ID)
+	if len(pods) == 0 {
+		delete(cch.namespaces, p.Namespace)
+	}
+}
+
 // Look up a pod in the cache.
 func (cch *cache) LookupPod(id string) (Pod, bool) {
 	p, ok := cch.Pods[id]
@@ -926,9 +1109,17 @@ func (cch *cache) InsertContainer(msg interface{}) (Container, error) {
 	if c.ID != "" {
 		cch.Containers[c.ID] = c
 	}
+	if len(c.pending) > 0 {
+		cch.markPending(c)
+	}
 
 	cch.createContainerDirectory(c.CacheID)
 
+	for key, value := range opt.imageTags(c.taggedImage()) {
+		cch.Debug("%s: tagging with %s=%s by image %s", c.PrettyName(), key, value, c.taggedImage())
+		c.SetTag(key, value)
+	}
+
 	adjustments := cch.getApplicableAdjustments(cch.External, c)
 	switch {
 	case len(adjustments) > 1:
@@ -1102,6 +1293,10 @@ func (cch *cache) RefreshContainers(msg *criv1.ListContainersResponse) ([]Contai
 
 // Mark a container as having pending changes.
 func (cch *cache) markPending(c *container) {
+	if c.CacheID == "" {
+		// not inserted yet, marked once the cache ID is assigned
+		return
+	}
 	if cch.pending == nil {
 		cch.pending = make(map[string]struct{})
 	}
@@ -1163,9 +1358,114 @@ func (cch *cache) GetPods() []Pod {
 	for _, pod := range cch.Pods {
 		pods = append(pods, pod)
 	}
+	slices.SortFunc(pods, ComparePodsByCreation)
+	return pods
+}
+
+// GetPodsByNamespace returns all pods in the given namespace.
+func (cch *cache) GetPodsByNamespace(namespace string) []Pod {
+	pods := make([]Pod, 0, len(cch.namespaces[namespace]))
+	for _, pod := range cch.namespaces[namespace] {
+		pods = append(pods, pod)
+	}
+	slices.SortFunc(pods, ComparePodsByCreation)
 	return pods
 }
 
+// SelfCheck verifies the internal consistency of the cache, returning any violations found.
+func (cch *cache) SelfCheck() []error {
+	errs := []error{}
+
+	for id, c := range cch.Containers {
+		if id != c.CacheID && id != c.ID {
+			errs = append(errs, cacheError("container %s (cache ID %s, ID %s) is indexed by unrelated ID %s",
+				c.PrettyName(), c.CacheID, c.ID, id))
+			continue
+		}
+		if other, ok := cch.Containers[c.CacheID]; !ok || other != c {
+			errs = append(errs, cacheError("container %s: cache ID %s does not map back to the container",
+				c.PrettyName(), c.CacheID))
+		}
+		if c.ID != "" {
+			if other, ok := cch.Containers[c.ID]; !ok || other != c {
+				errs = append(errs, cacheError("container %s: ID %s does not map back to the container",
+					c.PrettyName(), c.ID))
+			}
+		}
+		if id != c.CacheID {
+			continue
+		}
+		if _, ok := cch.Pods[c.PodID]; !ok {
+			errs = append(errs, cacheError("container %s: pod %s does not exist",
+				c.PrettyName(), c.PodID))
+		}
+	}
+
+	for id, p := range cch.Pods {
+		if id != p.ID {
+			errs = append(errs, cacheError("pod %s is indexed by unrelated ID %s", p.ID, id))
+		}
+		if other, ok := cch.namespaces[p.Namespace][p.ID]; !ok || other != p {
+			errs = append(errs, cacheError("pod %s is missing from the index of namespace %q",
+				p.ID, p.Namespace))
+		}
+	}
+	for namespace, pods := range cch.namespaces {
+		for id, p := range pods {
+			if other, ok := cch.Pods[id]; !ok || other != p {
+				errs = append(errs, cacheError("namespace %q index has stale pod %s", namespace, id))
+			} else if p.Namespace != namespace {
+				errs = append(errs, cacheError("pod %s of namespace %q is indexed under namespace %q",
+					id, p.Namespace, namespace))
+			}
+		}
+	}
+
+	for id := range cch.pending {
+		if c, ok := cch.Containers[id]; !ok || c.CacheID != id {
+			errs = append(errs, cacheError("pending changes for unknown container %s", id))
+		}
+	}
+
+	sort.Slice(errs, func(i, j int) bool { return errs[i].Error() < errs[j].Error() })
+
+	return errs
+}
+
+// GetContainersSnapshot returns a detached copy of all containers.
+func (cch *cache) GetContainersSnapshot() []Container {
+	// Container copies are bound to a detached view of the cache, so
+	// any changes made to them are not reflected in the real cache.
+	view := &cache{
+		Logger:     cch.Logger,
+		Pods:       make(map[string]*pod, len(cch.Pods)),
+		Containers: make(map[string]*container, len(cch.Containers)),
+		External:   cch.External,
+		implicit:   make(map[string]ImplicitAffinity, len(cch.implicit)),
+	}
+	for id, p := range cch.Pods {
+		view.Pods[id] = p.snapshot(view)
+	}
+	for name, a := range cch.implicit {
+		view.implicit[name] = a
+	}
+
+	containers := make([]Container, 0, len(cch.Containers)/2)
+	for id, c := range cch.Containers {
+		if id != c.CacheID {
+			continue
+		}
+		cp := c.snapshot(view)
+		view.Containers[cp.CacheID] = cp
+		if cp.ID != "" {
+			view.Containers[cp.ID] = cp
+		}
+		containers = append(containers, cp)
+	}
+	SortContainers(containers, CompareByCreation)
+	return containers
+}
+
 // GetContainers returns all the containers present in the cache.
 func (cch *cache) GetContainers() []Container {
 	containers := make([]Container, 0, len(cch.Containers)/2)
@@ -1175,6 +1475,7 @@ func (cch *cache) GetContainers() []Container {
 		}
 		containers = append(containers, container)
 	}
+	SortContainers(containers, CompareByCreation)
 	return containers
 }
 
@@ -1233,6 +1534,40 @@ func (cch *cache) GetPolicyEntry(key string, ptr interface{}) bool {
 	return true
 }
 
+// Set the entry of a controller for a key.
+func (cch *cache) SetControllerEntry(controller, key string, obj interface{}) {
+	data, err := marshalEntry(obj)
+	if err != nil {
+		cch.Error("marshalling of controller %s entry '%s' failed: %v", controller, key, err)
+		return
+	}
+
+	entries, ok := cch.ControllerJSON[controller]
+	if !ok {
+		entries = make(map[string]string)
+		cch.ControllerJSON[controller] = entries
+	}
+	entries[key] = string(data)
+
+	cch.Debug("controller %s entry '%s' set to '%s'", controller, key, string(data))
+}
+
+// Get the entry of a controller for a key.
+func (cch *cache) GetControllerEntry(controller, key string, ptr interface{}) bool {
+	entry, ok := cch.ControllerJSON[controller][key]
+	if !ok {
+		return false
+	}
+
+	if err := unmarshalEntry([]byte(entry), ptr); err != nil {
+		cch.Error("failed to unmarshal controller %s entry for key '%s' (%T): %v",
+			controller, key, ptr, err)
+		return false
+	}
+
+	return true
+}
+
 // Marshal an opaque policy entry, special-casing cpusets and maps of cpusets.
 func marshalEntry(obj interface{}) ([]byte, error) {
 	switch obj.(type) {
@@ -1440,6 +1775,8 @@ type snapshot struct {
 	Cfg        *config.RawConfig
 	PolicyName string
 	PolicyJSON map[string]string
+
+	ControllerJSON map[string]map[string]string `json:",omitempty"`
 }
 
 // Snapshot takes a restorable snapshot of the current state of the cache.
@@ -1452,6 +1789,8 @@ func (cch *cache) Snapshot() ([]byte, error) {
 		NextID:     cch.NextID,
 		PolicyName: cch.PolicyName,
 		PolicyJSON: cch.PolicyJSON,
+
+		ControllerJSON: cch.ControllerJSON,
 	}
 
 	for id, p := range cch.Pods {
@@ -1505,10 +1844,16 @@ func (cch *cache) Restore(data []byte) error {
 	cch.PolicyJSON = s.PolicyJSON
 	cch.PolicyName = s.PolicyName
 	cch.policyData = make(map[string]interface{})
+	cch.ControllerJSON = s.ControllerJSON
+	if cch.ControllerJSON == nil {
+		cch.ControllerJSON = make(map[string]map[string]string)
+	}
 
+	cch.namespaces = make(map[string]map[string]*pod)
 	for _, p := range cch.Pods {
 		p.cache = cch
 		p.containers = make(map[string]string)
+		cch.indexPod(p)
 	}
 	for _, c := range cch.Containers {
 		c.cache = cch
@@ -1529,6 +1874,9 @@ func (cch *cache) Save() error {
 	if err != nil {
 		return cacheError("failed to save cache: %v", err)
 	}
+	if data, err = cch.encrypt(data); err != nil {
+		return cacheError("failed to save cache: %v", err)
+	}
 
 	tmpPath := cch.filePath + ".saving"
 	if err = os.WriteFile(tmpPath, data, cacheFilePerm.prefer); err != nil {
@@ -1559,6 +1907,10 @@ func (cch *cache) Load() error {
 		return cacheError("failed to load cache from file '%s': %v", cch.filePath, err)
 	}
 
+	if data, err = cch.decrypt(data); err != nil {
+		return cacheError("failed to load cache from file '%s': %v", cch.filePath, err)
+	}
+
 	return cch.Restore(data)
 }
 
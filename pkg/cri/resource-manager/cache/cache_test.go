@@ -17,13 +17,19 @@ package cache
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	v1 "k8s.io/api/core/v1"
+	resapi "k8s.io/apimachinery/pkg/api/resource"
 	criv1 "k8s.io/cri-api/pkg/apis/runtime/v1"
 
+	pkgcfg "github.com/intel/cri-resource-manager/pkg/config"
+	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/config"
 	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/kubernetes"
+	idset "github.com/intel/goresctrl/pkg/utils"
 )
 
 var nextFakePodID = 1
@@ -31,6 +37,7 @@ var nextFakeContainerID = 1
 
 type fakePod struct {
 	name        string
+	namespace   string
 	uid         string
 	id          string
 	qos         v1.PodQOSClass
@@ -43,9 +50,13 @@ type fakeContainer struct {
 	fakePod     *fakePod
 	name        string
 	id          string
+	image       string
+	userImage   string
 	labels      map[string]string
 	annotations map[string]string
 	resources   criv1.LinuxContainerResources
+	security    *criv1.LinuxContainerSecurityContext
+	attempt     uint32
 }
 
 func createTmpCache() (Cache, string, error) {
@@ -78,6 +89,9 @@ func createFakePod(cch Cache, fp *fakePod) (Pod, error) {
 	if string(fp.qos) == "" {
 		fp.qos = v1.PodQOSBurstable
 	}
+	if fp.namespace == "" {
+		fp.namespace = "default"
+	}
 
 	cgroupPath := ""
 	if fp.qos != v1.PodQOSGuaranteed {
@@ -92,7 +106,7 @@ func createFakePod(cch Cache, fp *fakePod) (Pod, error) {
 			Metadata: &criv1.PodSandboxMetadata{
 				Name:      fp.name,
 				Uid:       fp.uid,
-				Namespace: "default",
+				Namespace: fp.namespace,
 			},
 			Labels:      fp.labels,
 			Annotations: fp.annotations,
@@ -124,12 +138,15 @@ func createFakeContainer(cch Cache, fc *fakeContainer) (Container, error) {
 		PodSandboxId: fc.fakePod.id,
 		Config: &criv1.ContainerConfig{
 			Metadata: &criv1.ContainerMetadata{
-				Name: fc.name,
+				Name:    fc.name,
+				Attempt: fc.attempt,
 			},
+			Image:       &criv1.ImageSpec{Image: fc.image, UserSpecifiedImage: fc.userImage},
 			Labels:      fc.labels,
 			Annotations: fc.annotations,
 			Linux: &criv1.LinuxContainerConfig{
-				Resources: &fc.resources,
+				Resources:       &fc.resources,
+				SecurityContext: fc.security,
 			},
 		},
 		SandboxConfig: fc.fakePod.podCfg,
@@ -415,3 +432,905 @@ func TestCPULimitCalculationAccuracy(t *testing.T) {
 		}
 	}
 }
+
+func TestEstimateComputeResources(t *testing.T) {
+	tcases := []struct {
+		name         string
+		lnx          *criv1.LinuxContainerResources
+		cgroupParent string
+		requests     map[v1.ResourceName]string
+		limits       map[v1.ResourceName]string
+	}{
+		{
+			name: "no Linux resources",
+		},
+		{
+			name: "besteffort",
+			lnx: &criv1.LinuxContainerResources{
+				CpuShares: 2,
+			},
+			cgroupParent: "/kubepods/besteffort/pod1234",
+		},
+		{
+			name: "burstable",
+			lnx: &criv1.LinuxContainerResources{
+				CpuShares:          512,
+				CpuQuota:           150000,
+				CpuPeriod:          100000,
+				MemoryLimitInBytes: 1024 * 1024 * 1024,
+			},
+			cgroupParent: "/kubepods/burstable/pod1234",
+			requests: map[v1.ResourceName]string{
+				v1.ResourceCPU: "500m",
+			},
+			limits: map[v1.ResourceName]string{
+				v1.ResourceCPU:    "1500m",
+				v1.ResourceMemory: "1Gi",
+			},
+		},
+		{
+			name: "guaranteed",
+			lnx: &criv1.LinuxContainerResources{
+				CpuShares:          2048,
+				CpuQuota:           200000,
+				CpuPeriod:          100000,
+				MemoryLimitInBytes: 1024 * 1024 * 1024,
+				HugepageLimits: []*criv1.HugepageLimit{
+					{PageSize: "2MB", Limit: 64 * 1024 * 1024},
+					{PageSize: "1GB", Limit: 0},
+				},
+			},
+			cgroupParent: "/kubepods/pod1234",
+			requests: map[v1.ResourceName]string{
+				v1.ResourceCPU:                     "2",
+				v1.ResourceMemory:                  "1Gi",
+				v1.ResourceHugePagesPrefix + "2Mi": "64Mi",
+			},
+			limits: map[v1.ResourceName]string{
+				v1.ResourceCPU:                     "2",
+				v1.ResourceMemory:                  "1Gi",
+				v1.ResourceHugePagesPrefix + "2Mi": "64Mi",
+			},
+		},
+	}
+
+	check := func(t *testing.T, kind string, expected map[v1.ResourceName]string, got v1.ResourceList) {
+		if len(expected) != len(got) {
+			t.Errorf("expected %d %s, got %v", len(expected), kind, got)
+			return
+		}
+		for name, value := range expected {
+			qty, ok := got[name]
+			if !ok {
+				t.Errorf("expected %s %s missing", kind, name)
+				continue
+			}
+			if qty.Cmp(resapi.MustParse(value)) != 0 {
+				t.Errorf("expected %s %s %s, got %s", kind, name, value, qty.String())
+			}
+		}
+	}
+
+	for _, tc := range tcases {
+		t.Run(tc.name, func(t *testing.T) {
+			resources := estimateComputeResources(tc.lnx, tc.cgroupParent)
+			check(t, "request", tc.requests, resources.Requests)
+			check(t, "limit", tc.limits, resources.Limits)
+		})
+	}
+}
+
+func TestControllerEntries(t *testing.T) {
+	type resctrlGroups map[string]string
+
+	cch, dir, err := createTmpCache()
+	if err != nil {
+		t.Fatalf("failed to create cache: %v", err)
+	}
+	defer removeTmpCache(dir)
+
+	groups := resctrlGroups{"container-1": "gold", "container-2": "silver"}
+	cch.SetControllerEntry("rdt", "groups", groups)
+
+	if cch.GetControllerEntry("blockio", "groups", &resctrlGroups{}) {
+		t.Errorf("unexpected entry for unset controller")
+	}
+	if cch.GetControllerEntry("rdt", "classes", &resctrlGroups{}) {
+		t.Errorf("unexpected entry for unset key")
+	}
+
+	if err := cch.Save(); err != nil {
+		t.Fatalf("failed to save cache: %v", err)
+	}
+	restored, err := NewCache(Options{CacheDir: dir})
+	if err != nil {
+		t.Fatalf("failed to load saved cache: %v", err)
+	}
+
+	for name, c := range map[string]Cache{"original": cch, "restored": restored} {
+		got := resctrlGroups{}
+		if !c.GetControllerEntry("rdt", "groups", &got) {
+			t.Errorf("%s cache: controller entry not found", name)
+			continue
+		}
+		if len(got) != len(groups) {
+			t.Errorf("%s cache: expected %v, got %v", name, groups, got)
+		}
+		for key, value := range groups {
+			if got[key] != value {
+				t.Errorf("%s cache: expected %v, got %v", name, groups, got)
+			}
+		}
+	}
+}
+
+func TestGetPodsByNamespace(t *testing.T) {
+	cch, dir, err := createTmpCache()
+	if err != nil {
+		t.Fatalf("failed to create cache: %v", err)
+	}
+	defer removeTmpCache(dir)
+
+	pods := map[string]Pod{}
+	for _, fp := range []*fakePod{
+		{name: "pod-a0", namespace: "ns-a"},
+		{name: "pod-a1", namespace: "ns-a"},
+		{name: "pod-b0", namespace: "ns-b"},
+	} {
+		p, err := createFakePod(cch, fp)
+		if err != nil {
+			t.Fatalf("failed to create pod %s: %v", fp.name, err)
+		}
+		pods[fp.name] = p
+	}
+
+	check := func(name string, c Cache, namespace string, expected ...string) {
+		got := map[string]struct{}{}
+		for _, p := range c.GetPodsByNamespace(namespace) {
+			got[p.GetName()] = struct{}{}
+		}
+		if len(got) != len(expected) {
+			t.Errorf("%s cache: expected pods %v in namespace %q, got %v",
+				name, expected, namespace, got)
+			return
+		}
+		for _, podName := range expected {
+			if _, ok := got[podName]; !ok {
+				t.Errorf("%s cache: expected pods %v in namespace %q, got %v",
+					name, expected, namespace, got)
+			}
+		}
+	}
+
+	check("original", cch, "ns-a", "pod-a0", "pod-a1")
+	check("original", cch, "ns-b", "pod-b0")
+	check("original", cch, "ns-c")
+
+	cch.DeletePod(pods["pod-a0"].GetID())
+	check("original", cch, "ns-a", "pod-a1")
+
+	cch.DeletePod(pods["pod-b0"].GetID())
+	check("original", cch, "ns-b")
+
+	restored, err := NewCache(Options{CacheDir: dir})
+	if err != nil {
+		t.Fatalf("failed to load saved cache: %v", err)
+	}
+	check("restored", restored, "ns-a", "pod-a1")
+	check("restored", restored, "ns-b")
+}
+
+func TestPageMigrateSteps(t *testing.T) {
+	hbm, dram, pmem := idset.NewIDSet(4), idset.NewIDSet(0), idset.NewIDSet(2)
+	tcases := []struct {
+		name     string
+		pm       *PageMigrate
+		expected [][2]string
+	}{
+		{
+			name: "nil page migration",
+		},
+		{
+			name:     "single step",
+			pm:       &PageMigrate{SourceNodes: dram, TargetNodes: pmem},
+			expected: [][2]string{{"0", "2"}},
+		},
+		{
+			name: "tier chain",
+			pm: &PageMigrate{
+				SourceNodes: hbm,
+				TargetNodes: dram,
+				Tiers:       []idset.IDSet{hbm, dram, pmem},
+			},
+			expected: [][2]string{{"4", "0"}, {"0", "2"}},
+		},
+	}
+	for _, tc := range tcases {
+		t.Run(tc.name, func(t *testing.T) {
+			steps := tc.pm.Steps()
+			if len(steps) != len(tc.expected) {
+				t.Fatalf("expected %d steps, got %d", len(tc.expected), len(steps))
+			}
+			for i, s := range steps {
+				if s.SourceNodes.String() != tc.expected[i][0] || s.TargetNodes.String() != tc.expected[i][1] {
+					t.Errorf("step #%d: expected %v, got %s -> %s",
+						i, tc.expected[i], s.SourceNodes, s.TargetNodes)
+				}
+			}
+			if c := tc.pm.Clone(); len(c.Steps()) != len(steps) {
+				t.Errorf("clone has %d steps, expected %d", len(c.Steps()), len(steps))
+			}
+		})
+	}
+}
+
+func TestCreatedAt(t *testing.T) {
+	cch, dir, err := createTmpCache()
+	if err != nil {
+		t.Fatalf("failed to create cache: %v", err)
+	}
+	defer removeTmpCache(dir)
+
+	before := time.Now()
+	fp := &fakePod{name: "pod"}
+	p, err := createFakePod(cch, fp)
+	if err != nil {
+		t.Fatalf("failed to create fake pod: %v", err)
+	}
+	c, err := createFakeContainer(cch, &fakeContainer{fakePod: fp, name: "container"})
+	if err != nil {
+		t.Fatalf("failed to create fake container: %v", err)
+	}
+	after := time.Now()
+
+	for name, created := range map[string]time.Time{"pod": p.GetCreatedAt(), "container": c.GetCreatedAt()} {
+		if created.Before(before) || created.After(after) {
+			t.Errorf("%s creation time %v not within [%v, %v]", name, created, before, after)
+		}
+	}
+
+	restored, err := NewCache(Options{CacheDir: dir})
+	if err != nil {
+		t.Fatalf("failed to load saved cache: %v", err)
+	}
+	rc, ok := restored.LookupContainer(c.GetCacheID())
+	if !ok {
+		t.Fatalf("failed to look up restored container")
+	}
+	if !rc.GetCreatedAt().Equal(c.GetCreatedAt()) {
+		t.Errorf("restored creation time %v != %v", rc.GetCreatedAt(), c.GetCreatedAt())
+	}
+
+	if ts := int64(1600000000123456789); !createdAt(ts).Equal(time.Unix(0, ts)) {
+		t.Errorf("unexpected time %v for CRI timestamp %d", createdAt(ts), ts)
+	}
+}
+
+func TestContainerOrder(t *testing.T) {
+	cch, dir, err := createTmpCache()
+	if err != nil {
+		t.Fatalf("failed to create cache: %v", err)
+	}
+	defer removeTmpCache(dir)
+
+	base := time.Unix(1600000000, 0)
+	fp := &fakePod{name: "pod"}
+	if _, err := createFakePod(cch, fp); err != nil {
+		t.Fatalf("failed to create fake pod: %v", err)
+	}
+	created := map[string]time.Time{
+		"c0": base.Add(2 * time.Second),
+		"c1": base,
+		"c2": base.Add(time.Second),
+		"c3": base.Add(time.Second),
+	}
+	for _, name := range []string{"c0", "c1", "c2", "c3"} {
+		c, err := createFakeContainer(cch, &fakeContainer{fakePod: fp, name: name})
+		if err != nil {
+			t.Fatalf("failed to create fake container %s: %v", name, err)
+		}
+		c.(*container).CreatedAt = created[name]
+	}
+
+	check := func(containers []Container) {
+		for i := 1; i < len(containers); i++ {
+			prev, next := containers[i-1], containers[i]
+			if next.GetCreatedAt().Before(prev.GetCreatedAt()) ||
+				(next.GetCreatedAt().Equal(prev.GetCreatedAt()) && next.GetCacheID() < prev.GetCacheID()) {
+				t.Errorf("containers %s and %s out of order", prev.PrettyName(), next.PrettyName())
+			}
+		}
+	}
+
+	containers := cch.GetContainers()
+	if len(containers) != 4 || containers[0].GetName() != "c1" || containers[3].GetName() != "c0" {
+		t.Errorf("unexpected container order %v", containers)
+	}
+	check(containers)
+	check(cch.GetContainersSnapshot())
+	pods := cch.GetPods()
+	if len(pods) != 1 {
+		t.Fatalf("expected 1 pod, got %d", len(pods))
+	}
+	check(pods[0].GetContainers())
+
+	for i := 0; i < 10; i++ {
+		for j, c := range cch.GetContainers() {
+			if c.GetCacheID() != containers[j].GetCacheID() {
+				t.Fatalf("container order changed between calls")
+			}
+		}
+	}
+}
+
+func TestRestartCount(t *testing.T) {
+	cch, dir, err := createTmpCache()
+	if err != nil {
+		t.Fatalf("failed to create cache: %v", err)
+	}
+	defer removeTmpCache(dir)
+
+	fp := &fakePod{name: "pod"}
+	if _, err := createFakePod(cch, fp); err != nil {
+		t.Fatalf("failed to create fake pod: %v", err)
+	}
+
+	counts := map[string]int{}
+	for name, attempt := range map[string]uint32{"stable": 0, "crashing": 5} {
+		c, err := createFakeContainer(cch, &fakeContainer{fakePod: fp, name: name, attempt: attempt})
+		if err != nil {
+			t.Fatalf("failed to create fake container %s: %v", name, err)
+		}
+		if c.GetRestartCount() != int(attempt) {
+			t.Errorf("%s: expected restart count %d, got %d", name, attempt, c.GetRestartCount())
+		}
+		counts[c.GetCacheID()] = int(attempt)
+	}
+
+	restored, err := NewCache(Options{CacheDir: dir})
+	if err != nil {
+		t.Fatalf("failed to load saved cache: %v", err)
+	}
+	for id, count := range counts {
+		c, ok := restored.LookupContainer(id)
+		if !ok {
+			t.Fatalf("failed to look up restored container %s", id)
+		}
+		if c.GetRestartCount() != count {
+			t.Errorf("%s: expected restored restart count %d, got %d",
+				c.PrettyName(), count, c.GetRestartCount())
+		}
+	}
+}
+
+func TestSelfCheck(t *testing.T) {
+	cch, dir, err := createTmpCache()
+	if err != nil {
+		t.Fatalf("failed to create cache: %v", err)
+	}
+	defer removeTmpCache(dir)
+
+	fps := []*fakePod{
+		{name: "pod-a", namespace: "ns-a"},
+		{name: "pod-b", namespace: "ns-b"},
+	}
+	for _, fp := range fps {
+		if _, err := createFakePod(cch, fp); err != nil {
+			t.Fatalf("failed to create fake pod %s: %v", fp.name, err)
+		}
+		for _, name := range []string{"ctr0", "ctr1"} {
+			if _, err := createFakeContainer(cch, &fakeContainer{fakePod: fp, name: name}); err != nil {
+				t.Fatalf("failed to create fake container %s: %v", name, err)
+			}
+		}
+	}
+
+	if errs := cch.SelfCheck(); len(errs) != 0 {
+		t.Errorf("unexpected self-check errors: %v", errs)
+	}
+
+	restored, err := NewCache(Options{CacheDir: dir})
+	if err != nil {
+		t.Fatalf("failed to load saved cache: %v", err)
+	}
+	if errs := restored.SelfCheck(); len(errs) != 0 {
+		t.Errorf("unexpected self-check errors in restored cache: %v", errs)
+	}
+
+	// Corrupt the cache: drop a pod bypassing the namespace index and
+	// leave a pending change for a container which does not exist.
+	c := cch.(*cache)
+	delete(c.Pods, fps[0].id)
+	if c.pending == nil {
+		c.pending = map[string]struct{}{}
+	}
+	c.pending["bogus"] = struct{}{}
+
+	// 2 orphaned containers, 1 stale namespace index entry, 1 bogus pending
+	if errs := cch.SelfCheck(); len(errs) != 4 {
+		t.Errorf("expected 4 self-check errors, got %d: %v", len(errs), errs)
+	}
+}
+
+func TestGetContainersSnapshot(t *testing.T) {
+	cch, dir, err := createTmpCache()
+	if err != nil {
+		t.Fatalf("failed to create cache: %v", err)
+	}
+	defer removeTmpCache(dir)
+
+	fp := &fakePod{name: "pod"}
+	if _, err := createFakePod(cch, fp); err != nil {
+		t.Fatalf("failed to create fake pod: %v", err)
+	}
+	live, err := createFakeContainer(cch, &fakeContainer{fakePod: fp, name: "ctr"})
+	if err != nil {
+		t.Fatalf("failed to create fake container: %v", err)
+	}
+	live.SetCpusetCpus("0-1")
+	live.SetLabel("key", "value")
+	pending := len(cch.GetPendingContainers())
+
+	snapshot := cch.GetContainersSnapshot()
+	if len(snapshot) != 1 {
+		t.Fatalf("expected 1 container in snapshot, got %d", len(snapshot))
+	}
+	c := snapshot[0]
+	if c.GetCacheID() != live.GetCacheID() || c.GetCpusetCpus() != "0-1" {
+		t.Errorf("snapshot %s (cpus %q) does not match container %s (cpus %q)",
+			c.GetCacheID(), c.GetCpusetCpus(), live.GetCacheID(), live.GetCpusetCpus())
+	}
+	if pod, ok := c.GetPod(); !ok || pod.GetName() != fp.name {
+		t.Errorf("failed to look up pod of snapshot container")
+	} else if containers := pod.GetContainers(); len(containers) != 1 || containers[0] != c {
+		t.Errorf("expected snapshot pod to have the snapshot container, got %v", containers)
+	}
+
+	// changes to the live container must not show up in the snapshot
+	live.SetCpusetCpus("2-3")
+	live.SetLabel("key", "changed")
+	if c.GetCpusetCpus() != "0-1" {
+		t.Errorf("expected snapshot cpus 0-1, got %q", c.GetCpusetCpus())
+	}
+	if value, _ := c.GetLabel("key"); value != "value" {
+		t.Errorf("expected snapshot label value %q, got %q", "value", value)
+	}
+
+	// changes to the snapshot must not show up in the cache
+	c.SetCpusetMems("1")
+	c.SetLabel("other", "value")
+	if live.GetCpusetMems() == "1" {
+		t.Errorf("snapshot change leaked to the cached container")
+	}
+	if _, ok := live.GetLabel("other"); ok {
+		t.Errorf("snapshot label leaked to the cached container")
+	}
+	if len(cch.GetPendingContainers()) != pending {
+		t.Errorf("snapshot change marked containers pending in the cache")
+	}
+
+	// pods and pointer fields must not be shared with the cache
+	lc := live.(*container)
+	lc.Mounts = map[string]*Mount{"/data": {Container: "/data", Host: "/host/data"}}
+	lc.Security = &SecurityContext{AddCapabilities: []string{"SYS_NICE"}}
+	snapshot = cch.GetContainersSnapshot()
+	sc := snapshot[0].(*container)
+	lc.Mounts["/data"].Host = "/changed"
+	lc.Security.AddCapabilities[0] = "SYS_ADMIN"
+	if sc.Mounts["/data"].Host != "/host/data" {
+		t.Errorf("expected snapshot mount host %q, got %q", "/host/data", sc.Mounts["/data"].Host)
+	}
+	if sc.Security.AddCapabilities[0] != "SYS_NICE" {
+		t.Errorf("expected snapshot capability %q, got %q", "SYS_NICE", sc.Security.AddCapabilities[0])
+	}
+	livePod, _ := live.GetPod()
+	snapshotPod, _ := sc.GetPod()
+	if livePod == snapshotPod {
+		t.Fatalf("snapshot shares the pod with the cache")
+	}
+	livePod.(*pod).Labels["changed"] = "true"
+	if _, ok := snapshotPod.GetLabel("changed"); ok {
+		t.Errorf("cached pod label leaked to the snapshot pod")
+	}
+}
+
+func TestEncryptedCache(t *testing.T) {
+	t.Setenv(EncryptionKeyEnv, "")
+
+	cch, dir, err := createTmpCache()
+	if err != nil {
+		t.Fatalf("failed to create cache: %v", err)
+	}
+	defer removeTmpCache(dir)
+
+	fp := &fakePod{name: "secret-pod", namespace: "secret-namespace"}
+	p, err := createFakePod(cch, fp)
+	if err != nil {
+		t.Fatalf("failed to create fake pod: %v", err)
+	}
+
+	cacheFile := filepath.Join(dir, "cache")
+	keyFile := filepath.Join(dir, "key")
+	key := "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f"
+	if err := os.WriteFile(keyFile, []byte(key+"\n"), 0600); err != nil {
+		t.Fatalf("failed to write key file: %v", err)
+	}
+
+	// plaintext cache gets migrated to an encrypted one
+	encrypted, err := NewCache(Options{CacheDir: dir, EncryptionKeyFile: keyFile})
+	if err != nil {
+		t.Fatalf("failed to load plaintext cache with encryption enabled: %v", err)
+	}
+	if _, ok := encrypted.LookupPod(p.GetID()); !ok {
+		t.Errorf("pod %s not found in migrated cache", p.GetID())
+	}
+	if err := encrypted.Save(); err != nil {
+		t.Fatalf("failed to save encrypted cache: %v", err)
+	}
+
+	data, err := os.ReadFile(cacheFile)
+	if err != nil {
+		t.Fatalf("failed to read cache file: %v", err)
+	}
+	if !strings.HasPrefix(string(data), encryptedHeader) {
+		t.Errorf("cache file has no encrypted header")
+	}
+	for _, s := range []string{"secret-pod", "secret-namespace", CacheVersion + "\""} {
+		if strings.Contains(string(data), s) {
+			t.Errorf("encrypted cache file contains plaintext %q", s)
+		}
+	}
+
+	// encrypted cache can be loaded with the key from the environment
+	t.Setenv(EncryptionKeyEnv, key)
+	restored, err := NewCache(Options{CacheDir: dir})
+	if err != nil {
+		t.Fatalf("failed to load encrypted cache: %v", err)
+	}
+	if _, ok := restored.LookupPod(p.GetID()); !ok {
+		t.Errorf("pod %s not found in restored cache", p.GetID())
+	}
+
+	// encrypted cache can't be loaded without or with the wrong key
+	t.Setenv(EncryptionKeyEnv, "")
+	if _, err := NewCache(Options{CacheDir: dir}); err == nil {
+		t.Errorf("loading encrypted cache without a key should have failed")
+	}
+	t.Setenv(EncryptionKeyEnv, strings.Repeat("ff", 32))
+	if _, err := NewCache(Options{CacheDir: dir}); err == nil {
+		t.Errorf("loading encrypted cache with a wrong key should have failed")
+	}
+	t.Setenv(EncryptionKeyEnv, "0011")
+	if _, err := NewCache(Options{CacheDir: dir}); err == nil {
+		t.Errorf("creating cache with an invalid key should have failed")
+	}
+}
+
+func TestIsManaged(t *testing.T) {
+	cch, dir, err := createTmpCache()
+	if err != nil {
+		t.Fatalf("failed to create cache: %v", err)
+	}
+	defer removeTmpCache(dir)
+
+	tcases := []struct {
+		name        string
+		annotations map[string]string
+		privileged  bool
+		managed     bool
+	}{
+		{
+			name:    "no annotation",
+			managed: true,
+		},
+		{
+			name:        "pod unmanaged",
+			annotations: map[string]string{UnmanagedKey + "/pod": "true"},
+			managed:     false,
+		},
+		{
+			name:        "container unmanaged",
+			annotations: map[string]string{UnmanagedKey + "/container.ctr": "true"},
+			managed:     false,
+		},
+		{
+			name:        "explicitly managed",
+			annotations: map[string]string{UnmanagedKey: "false"},
+			managed:     true,
+		},
+		{
+			name:        "unprivileged container",
+			annotations: map[string]string{UnmanagedKey: UnmanagedPrivileged},
+			managed:     true,
+		},
+		{
+			name:        "privileged container",
+			annotations: map[string]string{UnmanagedKey: UnmanagedPrivileged},
+			privileged:  true,
+			managed:     false,
+		},
+		{
+			name:        "invalid annotation",
+			annotations: map[string]string{UnmanagedKey: "maybe"},
+			managed:     true,
+		},
+	}
+
+	containers := []Container{}
+	for _, tc := range tcases {
+		fp := &fakePod{name: tc.name, annotations: tc.annotations}
+		if _, err := createFakePod(cch, fp); err != nil {
+			t.Fatalf("%s: failed to create fake pod: %v", tc.name, err)
+		}
+		fc := &fakeContainer{fakePod: fp, name: "ctr"}
+		if tc.privileged {
+			fc.security = &criv1.LinuxContainerSecurityContext{Privileged: true}
+		}
+		c, err := createFakeContainer(cch, fc)
+		if err != nil {
+			t.Fatalf("%s: failed to create fake container: %v", tc.name, err)
+		}
+		if c.IsManaged() != tc.managed {
+			t.Errorf("%s: expected managed %v, got %v", tc.name, tc.managed, c.IsManaged())
+		}
+		containers = append(containers, c)
+	}
+
+	if managed := ManagedContainers(containers); len(managed) != 4 {
+		t.Errorf("expected 4 managed containers, got %d", len(managed))
+	}
+}
+
+func TestSecurityContext(t *testing.T) {
+	cch, dir, err := createTmpCache()
+	if err != nil {
+		t.Fatalf("failed to create cache: %v", err)
+	}
+	defer removeTmpCache(dir)
+
+	tcases := []struct {
+		name        string
+		security    *criv1.LinuxContainerSecurityContext
+		privileged  bool
+		hostNetwork bool
+		present     []string
+		absent      []string
+	}{
+		{
+			name:   "no security context",
+			absent: []string{"SYS_ADMIN", "NET_ADMIN"},
+		},
+		{
+			name: "privileged",
+			security: &criv1.LinuxContainerSecurityContext{
+				Privileged: true,
+			},
+			privileged: true,
+			present:    []string{"SYS_ADMIN", "NET_ADMIN"},
+		},
+		{
+			name: "added capabilities",
+			security: &criv1.LinuxContainerSecurityContext{
+				Capabilities: &criv1.Capability{
+					AddCapabilities:  []string{"CAP_NET_ADMIN", "sys_nice"},
+					DropCapabilities: []string{"ALL"},
+				},
+			},
+			present: []string{"NET_ADMIN", "CAP_SYS_NICE", "net_admin"},
+			absent:  []string{"SYS_ADMIN"},
+		},
+		{
+			name: "all capabilities",
+			security: &criv1.LinuxContainerSecurityContext{
+				Capabilities: &criv1.Capability{
+					AddCapabilities: []string{"ALL"},
+				},
+			},
+			present: []string{"SYS_ADMIN", "IPC_LOCK"},
+		},
+		{
+			name: "host network",
+			security: &criv1.LinuxContainerSecurityContext{
+				NamespaceOptions: &criv1.NamespaceOption{
+					Network: criv1.NamespaceMode_NODE,
+				},
+			},
+			hostNetwork: true,
+			absent:      []string{"NET_ADMIN"},
+		},
+	}
+
+	for _, tc := range tcases {
+		t.Run(tc.name, func(t *testing.T) {
+			fp := &fakePod{name: tc.name}
+			if _, err := createFakePod(cch, fp); err != nil {
+				t.Fatalf("failed to create fake pod: %v", err)
+			}
+			c, err := createFakeContainer(cch, &fakeContainer{fakePod: fp, name: "ctr", security: tc.security})
+			if err != nil {
+				t.Fatalf("failed to create fake container: %v", err)
+			}
+			if c.IsPrivileged() != tc.privileged {
+				t.Errorf("expected privileged %v, got %v", tc.privileged, c.IsPrivileged())
+			}
+			if c.IsHostNetwork() != tc.hostNetwork {
+				t.Errorf("expected host network %v, got %v", tc.hostNetwork, c.IsHostNetwork())
+			}
+			for _, capability := range tc.present {
+				if !c.HasCapability(capability) {
+					t.Errorf("expected capability %s to be present", capability)
+				}
+			}
+			for _, capability := range tc.absent {
+				if c.HasCapability(capability) {
+					t.Errorf("expected capability %s to be absent", capability)
+				}
+			}
+		})
+	}
+}
+
+func TestConfigNotify(t *testing.T) {
+	cch, dir, err := createTmpCache()
+	if err != nil {
+		t.Fatalf("failed to create cache: %v", err)
+	}
+	defer removeTmpCache(dir)
+
+	type change struct {
+		old, new *config.RawConfig
+	}
+	changes := []change{}
+	notify := func(oldCfg, newCfg *config.RawConfig) {
+		changes = append(changes, change{old: oldCfg, new: newCfg})
+	}
+
+	if err := cch.AddConfigNotify("test", notify); err != nil {
+		t.Fatalf("failed to add config notifier: %v", err)
+	}
+	if err := cch.AddConfigNotify("test", notify); err == nil {
+		t.Errorf("expected duplicate config notifier to fail")
+	}
+
+	cfg1 := &config.RawConfig{NodeName: "node", Data: map[string]string{"policy": "1"}}
+	cfg2 := &config.RawConfig{NodeName: "node", Data: map[string]string{"policy": "2"}}
+	if err := cch.SetConfig(cfg1); err != nil {
+		t.Fatalf("failed to set config: %v", err)
+	}
+	if err := cch.SetConfig(cfg2); err != nil {
+		t.Fatalf("failed to set config: %v", err)
+	}
+	if err := cch.ResetConfig(); err != nil {
+		t.Fatalf("failed to reset config: %v", err)
+	}
+
+	expected := []change{{nil, cfg1}, {cfg1, cfg2}, {cfg2, nil}}
+	if len(changes) != len(expected) {
+		t.Fatalf("expected %d notifications, got %d", len(expected), len(changes))
+	}
+	for i, c := range changes {
+		if c.old != expected[i].old || c.new != expected[i].new {
+			t.Errorf("notification #%d: expected %v -> %v, got %v -> %v",
+				i, expected[i].old, expected[i].new, c.old, c.new)
+		}
+	}
+
+	cch.DeleteConfigNotify("test")
+	if err := cch.SetConfig(cfg1); err != nil {
+		t.Fatalf("failed to set config: %v", err)
+	}
+	if len(changes) != len(expected) {
+		t.Errorf("expected no notification after deleting notifier")
+	}
+}
+
+func TestImageTags(t *testing.T) {
+	opt.ImageTags = []*ImageTagRule{
+		{Image: "redis:*", Tags: map[string]string{"cache-sensitive": "true"}},
+		{Image: "quay.io/*/memcached:*", Tags: map[string]string{"cache-sensitive": "true", "kv": "memcached"}},
+	}
+	defer func() { opt.ImageTags = nil }()
+
+	cch, dir, err := createTmpCache()
+	if err != nil {
+		t.Fatalf("failed to create cache: %v", err)
+	}
+	defer removeTmpCache(dir)
+
+	fp := &fakePod{name: "pod"}
+	if _, err := createFakePod(cch, fp); err != nil {
+		t.Fatalf("failed to create fake pod: %v", err)
+	}
+
+	tcases := []struct {
+		image     string
+		userImage string
+		expected  map[string]string
+	}{
+		{
+			image:    "docker.io/library/redis:7",
+			expected: map[string]string{"cache-sensitive": "true"},
+		},
+		{
+			image:     "sha256:7614ae9453d1d87e740a2056257a6de7135c84037c367e1fffa92ae922784631",
+			userImage: "redis:7",
+			expected:  map[string]string{"cache-sensitive": "true"},
+		},
+		{
+			image:    "sha256:7614ae9453d1d87e740a2056257a6de7135c84037c367e1fffa92ae922784631",
+			expected: map[string]string{},
+		},
+		{
+			image:    "quay.io/example/memcached:1.6",
+			expected: map[string]string{"cache-sensitive": "true", "kv": "memcached"},
+		},
+		{
+			image:    "docker.io/library/memcached:1.6",
+			expected: map[string]string{},
+		},
+		{
+			image:    "docker.io/library/nginx:latest",
+			expected: map[string]string{},
+		},
+	}
+	for idx, tc := range tcases {
+		t.Run(tc.image, func(t *testing.T) {
+			fc := &fakeContainer{fakePod: fp, name: fmt.Sprintf("ctr%d", idx), image: tc.image, userImage: tc.userImage}
+			c, err := createFakeContainer(cch, fc)
+			if err != nil {
+				t.Fatalf("failed to create fake container: %v", err)
+			}
+			for _, key := range []string{"cache-sensitive", "kv"} {
+				value, ok := c.GetTag(key)
+				expected, tagged := tc.expected[key]
+				if ok != tagged || value != expected {
+					t.Errorf("expected tag %s=%q (%v), got %q (%v)", key, expected, tagged, value, ok)
+				}
+			}
+		})
+	}
+
+	opt.ImageTags = append(opt.ImageTags, &ImageTagRule{Image: "[", Tags: map[string]string{"x": "y"}})
+	if err := opt.configNotify(pkgcfg.UpdateEvent, pkgcfg.ConfigFile); err == nil {
+		t.Errorf("expected invalid image pattern to be rejected")
+	}
+}
+
+func TestUnifiedResources(t *testing.T) {
+	cch, dir, err := createTmpCache()
+	if err != nil {
+		t.Fatalf("failed to create cache: %v", err)
+	}
+	defer removeTmpCache(dir)
+
+	fp := &fakePod{name: "pod"}
+	if _, err := createFakePod(cch, fp); err != nil {
+		t.Fatalf("failed to create fake pod: %v", err)
+	}
+	c, err := createFakeContainer(cch, &fakeContainer{fakePod: fp, name: "ctr"})
+	if err != nil {
+		t.Fatalf("failed to create fake container: %v", err)
+	}
+
+	if _, ok := c.GetUnifiedResource("memory.low"); ok {
+		t.Errorf("unexpected memory.low in new container")
+	}
+
+	c.ClearPending(CRI)
+	c.SetUnifiedResource("memory.low", "1073741824")
+	if value, ok := c.GetUnifiedResource("memory.low"); !ok || value != "1073741824" {
+		t.Errorf("expected memory.low 1073741824, got %q", value)
+	}
+	if !c.HasPending(CRI) {
+		t.Errorf("expected pending CRI update after setting memory.low")
+	}
+
+	c.ClearPending(CRI)
+	c.SetUnifiedResource("memory.low", "1073741824")
+	if c.HasPending(CRI) {
+		t.Errorf("unexpected pending CRI update after setting unchanged memory.low")
+	}
+	if value := c.GetLinuxResources().Unified["memory.low"]; value != "1073741824" {
+		t.Errorf("expected memory.low in CRI resources, got %q", value)
+	}
+}
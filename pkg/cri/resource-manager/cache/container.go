@@ -16,10 +16,13 @@ package cache
 
 import (
 	"encoding/json"
+	"maps"
 	"regexp"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/intel/cri-resource-manager/pkg/apis/resmgr"
 	"github.com/intel/cri-resource-manager/pkg/cgroups"
@@ -62,7 +65,10 @@ func (c *container) fromCreateRequest(req *criv1.CreateContainerRequest) error {
 	c.Name = meta.Name
 	c.Namespace = podMeta.Namespace
 	c.State = ContainerStateCreating
+	c.CreatedAt = time.Now()
+	c.RestartCount = int(meta.Attempt)
 	c.Image = cfg.GetImage().GetImage()
+	c.UserImage = cfg.GetImage().GetUserSpecifiedImage()
 	c.Command = cfg.Command
 	c.Args = cfg.Args
 	c.Labels = cfg.Labels
@@ -120,17 +126,16 @@ func (c *container) fromCreateRequest(req *criv1.CreateContainerRequest) error {
 
 	c.LinuxReq = cfg.GetLinux().GetResources()
 
-	if pod.Resources != nil {
-		if r, ok := pod.Resources.InitContainers[c.Name]; ok {
-			c.Resources = r
-		} else if r, ok := pod.Resources.Containers[c.Name]; ok {
-			c.Resources = r
+	if sc := cfg.GetLinux().GetSecurityContext(); sc != nil {
+		c.Security = &SecurityContext{
+			Privileged:       sc.Privileged,
+			HostNetwork:      sc.GetNamespaceOptions().GetNetwork() == criv1.NamespaceMode_NODE,
+			AddCapabilities:  sc.GetCapabilities().GetAddCapabilities(),
+			DropCapabilities: sc.GetCapabilities().GetDropCapabilities(),
 		}
 	}
 
-	if len(c.Resources.Requests) == 0 && len(c.Resources.Limits) == 0 {
-		c.Resources = estimateComputeResources(c.LinuxReq, pod.CgroupParent)
-	}
+	c.setResources(pod)
 
 	c.TopologyHints = topology.MergeTopologyHints(c.TopologyHints, getKubeletHint(c.GetCpusetCpus(), c.GetCpusetMems()))
 
@@ -159,11 +164,27 @@ func (c *container) fromListResponse(lrc *criv1.Container) error {
 	c.Name = meta.Name
 	c.Namespace = pod.Namespace
 	c.State = ContainerState(int32(lrc.State))
+	c.CreatedAt = createdAt(lrc.CreatedAt)
+	c.RestartCount = int(meta.Attempt)
 	c.Image = lrc.GetImage().GetImage()
+	c.UserImage = lrc.GetImage().GetUserSpecifiedImage()
 	c.Labels = lrc.Labels
 	c.Annotations = lrc.Annotations
 	c.Tags = make(map[string]string)
 
+	c.setResources(pod)
+
+	if err := c.setDefaults(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// setResources sets the resource requirements of the container, either from
+// the pod resource annotation or, if that is missing, by estimating them from
+// the Linux resources of the CRI request.
+func (c *container) setResources(pod *pod) {
 	if pod.Resources != nil {
 		if r, ok := pod.Resources.InitContainers[c.Name]; ok {
 			c.Resources = r
@@ -174,13 +195,9 @@ func (c *container) fromListResponse(lrc *criv1.Container) error {
 
 	if len(c.Resources.Requests) == 0 && len(c.Resources.Limits) == 0 {
 		c.Resources = estimateComputeResources(c.LinuxReq, pod.CgroupParent)
+		c.Estimated = true
+		c.cache.Debug("%s: estimated resources %v from CRI request", c.PrettyName(), c.Resources)
 	}
-
-	if err := c.setDefaults(); err != nil {
-		return err
-	}
-
-	return nil
 }
 
 func (c *container) setDefaults() error {
@@ -256,6 +273,44 @@ func (c *container) GetState() ContainerState {
 	return c.State
 }
 
+func (c *container) GetCreatedAt() time.Time {
+	return c.CreatedAt
+}
+
+// GetRestartCount returns the number of times the container has been
+// restarted. Every restart creates a new CRI container, with its attempt
+// counter in the container metadata incremented by the kubelet.
+func (c *container) GetRestartCount() int {
+	return c.RestartCount
+}
+
+func (c *container) IsManaged() bool {
+	value, ok := c.GetEffectiveAnnotation(UnmanagedKey)
+	if !ok {
+		return true
+	}
+	if value == UnmanagedPrivileged {
+		return !c.IsPrivileged()
+	}
+	unmanaged, err := strconv.ParseBool(value)
+	if err != nil {
+		c.cache.Error("invalid annotation %q=%q: %v", UnmanagedKey, value, err)
+		return true
+	}
+	return !unmanaged
+}
+
+// ManagedContainers filters out containers excluded from resource management.
+func ManagedContainers(containers []Container) []Container {
+	managed := make([]Container, 0, len(containers))
+	for _, c := range containers {
+		if c.IsManaged() {
+			managed = append(managed, c)
+		}
+	}
+	return managed
+}
+
 func (c *container) GetQOSClass() v1.PodQOSClass {
 	var qos v1.PodQOSClass
 
@@ -270,6 +325,16 @@ func (c *container) GetImage() string {
 	return c.Image
 }
 
+// taggedImage returns the image to match image tagging rules against. The
+// image as specified by the user is preferred, as the runtime may have
+// resolved it to an image ID or digest which no rule would match.
+func (c *container) taggedImage() string {
+	if c.UserImage != "" {
+		return c.UserImage
+	}
+	return c.Image
+}
+
 func (c *container) GetCommand() []string {
 	command := make([]string, len(c.Command))
 	copy(command, c.Command)
@@ -450,6 +515,41 @@ func (c *container) GetDevices() []Device {
 	return devices
 }
 
+func (c *container) GetSecurityContext() SecurityContext {
+	if c.Security == nil {
+		return SecurityContext{}
+	}
+	sc := *c.Security
+	sc.AddCapabilities = append([]string(nil), c.Security.AddCapabilities...)
+	sc.DropCapabilities = append([]string(nil), c.Security.DropCapabilities...)
+	return sc
+}
+
+func (c *container) IsPrivileged() bool {
+	return c.Security != nil && c.Security.Privileged
+}
+
+func (c *container) IsHostNetwork() bool {
+	return c.Security != nil && c.Security.HostNetwork
+}
+
+func (c *container) HasCapability(capability string) bool {
+	if c.Security == nil {
+		return false
+	}
+	if c.Security.Privileged {
+		return true
+	}
+	name := strings.TrimPrefix(strings.ToUpper(capability), "CAP_")
+	for _, added := range c.Security.AddCapabilities {
+		added = strings.TrimPrefix(strings.ToUpper(added), "CAP_")
+		if added == name || added == "ALL" {
+			return true
+		}
+	}
+	return false
+}
+
 func (c *container) GetDeviceByHost(path string) *Device {
 	for _, d := range c.Devices {
 		if d.Host == path {
@@ -640,11 +740,31 @@ func (c *container) GetCpusetMems() string {
 	return c.LinuxReq.CpusetMems
 }
 
+func (c *container) GetUnifiedResource(key string) (string, bool) {
+	if c.LinuxReq == nil {
+		return "", false
+	}
+	value, ok := c.LinuxReq.Unified[key]
+	return value, ok
+}
+
 func (c *container) SetLinuxResources(req *criv1.LinuxContainerResources) {
 	c.LinuxReq = req
+	c.reestimateResources()
 	c.markPending(CRI)
 }
 
+// reestimateResources updates estimated resource requirements after a change
+// in the Linux resources of the container.
+func (c *container) reestimateResources() {
+	if !c.Estimated {
+		return
+	}
+	if pod, ok := c.cache.Pods[c.PodID]; ok {
+		c.Resources = estimateComputeResources(c.LinuxReq, pod.CgroupParent)
+	}
+}
+
 func (c *container) SetCPUPeriod(value int64) {
 	if c.LinuxReq == nil {
 		c.LinuxReq = &criv1.LinuxContainerResources{}
@@ -701,6 +821,36 @@ func (c *container) SetCpusetMems(value string) {
 	c.markPending(CRI)
 }
 
+func (c *container) SetHugepageLimit(pageSize string, limit uint64) {
+	if c.LinuxReq == nil {
+		c.LinuxReq = &criv1.LinuxContainerResources{}
+	}
+	for _, hp := range c.LinuxReq.HugepageLimits {
+		if hp != nil && hp.PageSize == pageSize {
+			hp.Limit = limit
+			c.markPending(CRI)
+			return
+		}
+	}
+	c.LinuxReq.HugepageLimits = append(c.LinuxReq.HugepageLimits,
+		&criv1.HugepageLimit{PageSize: pageSize, Limit: limit})
+	c.markPending(CRI)
+}
+
+func (c *container) SetUnifiedResource(key, value string) {
+	if c.LinuxReq == nil {
+		c.LinuxReq = &criv1.LinuxContainerResources{}
+	}
+	if c.LinuxReq.Unified == nil {
+		c.LinuxReq.Unified = make(map[string]string)
+	}
+	if old, ok := c.LinuxReq.Unified[key]; ok && old == value {
+		return
+	}
+	c.LinuxReq.Unified[key] = value
+	c.markPending(CRI)
+}
+
 func getTopologyHints(hostPath, containerPath string, readOnly bool) topology.Hints {
 
 	if readOnly {
@@ -809,6 +959,15 @@ func (c *container) GetBlockIOClass() string {
 	return c.BlockIOClass
 }
 
+func (c *container) SetNetClass(class string) {
+	c.NetClass = class
+	c.markPending(NetClass)
+}
+
+func (c *container) GetNetClass() string {
+	return c.NetClass
+}
+
 func (c *container) SetToptierLimit(limit int64) {
 	c.ToptierLimit = limit
 	c.markPending(Memory)
@@ -1058,6 +1217,14 @@ func SortContainers(containers []Container, compareFns ...CompareContainersFn) {
 // CompareByQOSMemoryCPU is a slice for comparing container by QOS, memory, and CPU.
 var CompareByQOSMemoryCPU = []CompareContainersFn{CompareQOS, CompareMemory, CompareCPU}
 
+// CompareByCreation compares containers by creation time, then by cache ID.
+func CompareByCreation(ci, cj Container) int {
+	if c := ci.GetCreatedAt().Compare(cj.GetCreatedAt()); c != 0 {
+		return c
+	}
+	return strings.Compare(ci.GetCacheID(), cj.GetCacheID())
+}
+
 // CompareQOS compares containers by QOS class.
 func CompareQOS(ci, cj Container) int {
 	qosi, qosj := ci.GetQOSClass(), cj.GetQOSClass()
@@ -1141,3 +1308,49 @@ func CompareCPU(ci, cj Container) int {
 	}
 	return 0
 }
+
+// snapshot returns a copy of the container, bound to the given cache view.
+func (c *container) snapshot(view *cache) *container {
+	cp := *c
+	cp.cache = view
+	cp.req = nil
+	cp.Command = slices.Clone(c.Command)
+	cp.Args = slices.Clone(c.Args)
+	cp.Labels = maps.Clone(c.Labels)
+	cp.Annotations = maps.Clone(c.Annotations)
+	cp.Env = maps.Clone(c.Env)
+	if c.Mounts != nil {
+		cp.Mounts = make(map[string]*Mount, len(c.Mounts))
+		for path, m := range c.Mounts {
+			mount := *m
+			cp.Mounts[path] = &mount
+		}
+	}
+	if c.Devices != nil {
+		cp.Devices = make(map[string]*Device, len(c.Devices))
+		for path, d := range c.Devices {
+			device := *d
+			cp.Devices[path] = &device
+		}
+	}
+	if c.Security != nil {
+		security := *c.Security
+		security.AddCapabilities = slices.Clone(c.Security.AddCapabilities)
+		security.DropCapabilities = slices.Clone(c.Security.DropCapabilities)
+		cp.Security = &security
+	}
+	cp.TopologyHints = maps.Clone(c.TopologyHints)
+	cp.Tags = maps.Clone(c.Tags)
+	cp.Resources = *c.Resources.DeepCopy()
+	if c.LinuxReq != nil {
+		req := *c.LinuxReq
+		req.HugepageLimits = slices.Clone(c.LinuxReq.HugepageLimits)
+		req.Unified = maps.Clone(c.LinuxReq.Unified)
+		cp.LinuxReq = &req
+	}
+	if c.PageMigrate != nil {
+		cp.PageMigrate = c.PageMigrate.Clone()
+	}
+	cp.pending = maps.Clone(c.pending)
+	return &cp
+}
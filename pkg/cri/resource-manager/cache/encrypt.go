@@ -0,0 +1,143 @@
+// Copyright 2026 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"os"
+	"strings"
+)
+
+const (
+	// EncryptionKeyEnv is the environment variable to take the cache encryption
+	// key from, if no key file is given. The key is hex-encoded and 16, 24, or
+	// 32 bytes long, selecting AES-128, AES-192, or AES-256.
+	EncryptionKeyEnv = "CRI_RESMGR_CACHE_KEY"
+
+	// encryptedMagic is the common prefix of all encrypted snapshot headers.
+	encryptedMagic = "cri-resmgr-cache:"
+	// encryptedHeader is the header of AES-GCM encrypted snapshots.
+	encryptedHeader = encryptedMagic + "aes-gcm:v" + CacheVersion + "\n"
+)
+
+// loadEncryptionKey reads the cache encryption key from a file or the environment.
+func loadEncryptionKey(path string) ([]byte, error) {
+	var encoded, source string
+
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, cacheError("failed to read cache encryption key: %v", err)
+		}
+		encoded, source = string(data), "file "+path
+	} else {
+		encoded, source = os.Getenv(EncryptionKeyEnv), "environment variable "+EncryptionKeyEnv
+	}
+
+	encoded = strings.TrimSpace(encoded)
+	if encoded == "" {
+		return nil, nil
+	}
+
+	key, err := hex.DecodeString(encoded)
+	if err != nil {
+		return nil, cacheError("invalid cache encryption key in %s: %v", source, err)
+	}
+	switch len(key) {
+	case 16, 24, 32:
+	default:
+		return nil, cacheError("invalid cache encryption key in %s: %d bytes, expecting 16, 24 or 32",
+			source, len(key))
+	}
+
+	return key, nil
+}
+
+// setupEncryption sets up snapshot encryption with the given key.
+func (cch *cache) setupEncryption(key []byte) error {
+	if key == nil {
+		return nil
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return cacheError("failed to set up cache encryption: %v", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return cacheError("failed to set up cache encryption: %v", err)
+	}
+	cch.aead = aead
+
+	return nil
+}
+
+// isEncrypted checks if the given data is an encrypted snapshot.
+func isEncrypted(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(encryptedMagic))
+}
+
+// encrypt encrypts snapshot data, if encryption is enabled.
+func (cch *cache) encrypt(data []byte) ([]byte, error) {
+	if cch.aead == nil {
+		return data, nil
+	}
+
+	nonce := make([]byte, cch.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, cacheError("failed to generate nonce for cache encryption: %v", err)
+	}
+
+	sealed := append([]byte(encryptedHeader), nonce...)
+	return cch.aead.Seal(sealed, nonce, data, []byte(encryptedHeader)), nil
+}
+
+// decrypt decrypts snapshot data. Plaintext snapshots are returned as such.
+func (cch *cache) decrypt(data []byte) ([]byte, error) {
+	if !isEncrypted(data) {
+		if cch.aead != nil {
+			cch.Info("cache is not encrypted, it will be encrypted once saved")
+		}
+		return data, nil
+	}
+
+	if !bytes.HasPrefix(data, []byte(encryptedHeader)) {
+		header := data
+		if idx := bytes.IndexByte(data, '\n'); idx >= 0 {
+			header = data[:idx]
+		}
+		return nil, cacheError("unsupported encrypted cache format %q", string(header))
+	}
+	if cch.aead == nil {
+		return nil, cacheError("cache is encrypted but no encryption key was given")
+	}
+
+	data = data[len(encryptedHeader):]
+	if len(data) < cch.aead.NonceSize() {
+		return nil, cacheError("failed to decrypt cache: truncated data")
+	}
+	nonce, sealed := data[:cch.aead.NonceSize()], data[cch.aead.NonceSize():]
+	plain, err := cch.aead.Open(nil, nonce, sealed, []byte(encryptedHeader))
+	if err != nil {
+		return nil, cacheError("failed to decrypt cache: %v", err)
+	}
+
+	return plain, nil
+}
@@ -0,0 +1,91 @@
+// Copyright 2026 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"path"
+	"strings"
+
+	pkgcfg "github.com/intel/cri-resource-manager/pkg/config"
+)
+
+// options captures our runtime configuration.
+type options struct {
+	// ImageTags are rules for tagging containers by their image.
+	ImageTags []*ImageTagRule `json:"ImageTags,omitempty"`
+}
+
+// ImageTagRule tags the containers with a matching image.
+type ImageTagRule struct {
+	// Image is a shell pattern for container images, for instance "redis:*".
+	// Patterns without a '/' are matched against the last element of the
+	// image name, so "redis:*" also matches "docker.io/library/redis:7".
+	// Images are matched as specified by the user, if the runtime tells.
+	Image string `json:"Image"`
+	// Tags are the tags to set on matching containers.
+	Tags map[string]string `json:"Tags"`
+}
+
+// Our runtime configuration.
+var opt = defaultOptions().(*options)
+
+// Matches checks if the rule matches the given container image.
+func (r *ImageTagRule) Matches(image string) bool {
+	if ok, _ := path.Match(r.Image, image); ok {
+		return true
+	}
+	if !strings.Contains(r.Image, "/") {
+		ok, _ := path.Match(r.Image, path.Base(image))
+		return ok
+	}
+	return false
+}
+
+// imageTags returns the tags of all rules matching the given container image.
+func (o *options) imageTags(image string) map[string]string {
+	tags := map[string]string{}
+	for _, r := range o.ImageTags {
+		if r.Matches(image) {
+			for key, value := range r.Tags {
+				tags[key] = value
+			}
+		}
+	}
+	return tags
+}
+
+// configNotify is our configuration update notification callback.
+func (o *options) configNotify(_ pkgcfg.Event, _ pkgcfg.Source) error {
+	for _, r := range o.ImageTags {
+		if _, err := path.Match(r.Image, ""); err != nil || r.Image == "" {
+			return cacheError("invalid image pattern %q in image tagging rule", r.Image)
+		}
+		if len(r.Tags) == 0 {
+			return cacheError("image tagging rule for %q has no tags", r.Image)
+		}
+	}
+	return nil
+}
+
+// defaultOptions returns a new options instance, all initialized to defaults.
+func defaultOptions() interface{} {
+	return &options{}
+}
+
+// Register us for configuration handling.
+func init() {
+	pkgcfg.Register("resource-manager.cache", "Pod and container cache.", opt, defaultOptions,
+		pkgcfg.WithNotify(opt.configNotify))
+}
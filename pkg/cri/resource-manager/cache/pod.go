@@ -16,8 +16,10 @@ package cache
 
 import (
 	"encoding/json"
+	"maps"
 	"strconv"
 	"strings"
+	"time"
 
 	v1 "k8s.io/api/core/v1"
 	criv1 "k8s.io/cri-api/pkg/apis/runtime/v1"
@@ -48,6 +50,7 @@ func (p *pod) fromRunRequest(req *criv1.RunPodSandboxRequest) error {
 	p.Name = meta.Name
 	p.Namespace = meta.Namespace
 	p.State = PodState(int32(PodStateReady))
+	p.CreatedAt = time.Now()
 	p.Labels = cfg.Labels
 	p.Annotations = cfg.Annotations
 	p.CgroupParent = cfg.GetLinux().GetCgroupParent()
@@ -73,6 +76,7 @@ func (p *pod) fromListResponse(pod *criv1.PodSandbox, status *PodStatus) error {
 	p.Name = meta.Name
 	p.Namespace = meta.Namespace
 	p.State = PodState(int32(pod.State))
+	p.CreatedAt = createdAt(pod.CreatedAt)
 	p.Labels = pod.Labels
 	p.Annotations = pod.Annotations
 
@@ -100,10 +104,10 @@ func (p *pod) GetInitContainers() []Container {
 	containers := []Container{}
 
 	for id, c := range p.cache.Containers {
-		if id != c.CacheID {
+		if c.PodID != p.ID || id != c.CacheID {
 			continue
 		}
-		if _, ok := p.Resources.InitContainers[c.ID]; ok {
+		if _, ok := p.Resources.InitContainers[c.Name]; ok {
 			containers = append(containers, c)
 		}
 	}
@@ -120,12 +124,13 @@ func (p *pod) GetContainers() []Container {
 			continue
 		}
 		if p.Resources != nil {
-			if _, ok := p.Resources.InitContainers[c.ID]; ok {
+			if _, ok := p.Resources.InitContainers[c.Name]; ok {
 				continue
 			}
 		}
 		containers = append(containers, c)
 	}
+	SortContainers(containers, CompareByCreation)
 
 	return containers
 }
@@ -181,6 +186,11 @@ func (p *pod) GetState() PodState {
 	return p.State
 }
 
+// Get the creation time of a pod.
+func (p *pod) GetCreatedAt() time.Time {
+	return p.CreatedAt
+}
+
 // Get the keys of all labels of a pod.
 func (p *pod) GetLabelKeys() []string {
 	keys := make([]string, len(p.Labels))
@@ -563,3 +573,53 @@ func ParsePodStatus(response *criv1.PodSandboxStatusResponse) (*PodStatus, error
 
 	return ps, nil
 }
+
+// ComparePodsByCreation compares pods by creation time, then by ID.
+func ComparePodsByCreation(pi, pj Pod) int {
+	if c := pi.GetCreatedAt().Compare(pj.GetCreatedAt()); c != 0 {
+		return c
+	}
+	return strings.Compare(pi.GetID(), pj.GetID())
+}
+
+// snapshot returns a copy of the pod, bound to the given cache view.
+func (p *pod) snapshot(view *cache) *pod {
+	cp := *p
+	cp.cache = view
+	cp.Labels = maps.Clone(p.Labels)
+	cp.Annotations = maps.Clone(p.Annotations)
+	cp.containers = maps.Clone(p.containers)
+	if p.Resources != nil {
+		resources := &PodResourceRequirements{}
+		if p.Resources.InitContainers != nil {
+			resources.InitContainers = make(map[string]v1.ResourceRequirements, len(p.Resources.InitContainers))
+			for name, r := range p.Resources.InitContainers {
+				resources.InitContainers[name] = *r.DeepCopy()
+			}
+		}
+		if p.Resources.Containers != nil {
+			resources.Containers = make(map[string]v1.ResourceRequirements, len(p.Resources.Containers))
+			for name, r := range p.Resources.Containers {
+				resources.Containers[name] = *r.DeepCopy()
+			}
+		}
+		cp.Resources = resources
+	}
+	if p.Affinity != nil {
+		affinity := make(podContainerAffinity, len(*p.Affinity))
+		for name, list := range *p.Affinity {
+			for _, a := range list {
+				cpa := *a
+				if a.Scope != nil {
+					cpa.Scope = a.Scope.DeepCopy()
+				}
+				if a.Match != nil {
+					cpa.Match = a.Match.DeepCopy()
+				}
+				affinity[name] = append(affinity[name], &cpa)
+			}
+		}
+		cp.Affinity = &affinity
+	}
+	return &cp
+}
@@ -19,6 +19,7 @@ import (
 	"path"
 	"strconv"
 	"strings"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	resapi "k8s.io/apimachinery/pkg/api/resource"
@@ -47,6 +48,11 @@ func IsPodQOSClassName(class string) bool {
 }
 
 // estimateComputeResources calculates resource requests/limits from a CRI request.
+// This is used as a fallback when the resource requirements of a container are
+// not available from the pod annotation set by our webhook. CPU shares are
+// mapped to CPU request, CPU quota and period to CPU limit, and the memory and
+// hugepage limits are taken as such. For Guaranteed QoS class containers limits
+// equal requests, so missing ones are filled in accordingly.
 func estimateComputeResources(lnx *criv1.LinuxContainerResources, cgroupParent string) corev1.ResourceRequirements {
 	var qos corev1.PodQOSClass
 
@@ -69,20 +75,43 @@ func estimateComputeResources(lnx *criv1.LinuxContainerResources, cgroupParent s
 		resources.Requests[corev1.ResourceCPU] = *qty
 	}
 
+	// calculate CPU limit
+	if value := QuotaToMilliCPU(lnx.CpuQuota, lnx.CpuPeriod); value > 0 {
+		qty := resapi.NewMilliQuantity(value, resapi.DecimalSI)
+		resources.Limits[corev1.ResourceCPU] = *qty
+	}
+
 	// get memory limit
 	if value := lnx.MemoryLimitInBytes; value > 0 {
 		qty := resapi.NewQuantity(value, resapi.DecimalSI)
 		resources.Limits[corev1.ResourceMemory] = *qty
 	}
 
-	// set or calculate CPU limit, set memory request if known
+	// get hugepage limits
+	for _, hp := range lnx.HugepageLimits {
+		if hp == nil || hp.Limit == 0 {
+			continue
+		}
+		// CRI page sizes are like "2MB" or "1GB", resource names use "2Mi" or "1Gi"
+		qty, err := resapi.ParseQuantity(strings.TrimSuffix(hp.PageSize, "B") + "i")
+		if err != nil {
+			continue
+		}
+		name := corev1.ResourceName(corev1.ResourceHugePagesPrefix + qty.String())
+		resources.Limits[name] = *resapi.NewQuantity(int64(hp.Limit), resapi.BinarySI)
+	}
+
+	// for Guaranteed QoS class, requests and limits are equal
 	if qos == corev1.PodQOSGuaranteed {
-		resources.Limits[corev1.ResourceCPU] = resources.Requests[corev1.ResourceCPU]
-		resources.Requests[corev1.ResourceMemory] = resources.Limits[corev1.ResourceMemory]
-	} else {
-		if value := QuotaToMilliCPU(lnx.CpuQuota, lnx.CpuPeriod); value > 0 {
-			qty := resapi.NewMilliQuantity(value, resapi.DecimalSI)
-			resources.Limits[corev1.ResourceCPU] = *qty
+		if qty, ok := resources.Requests[corev1.ResourceCPU]; ok {
+			resources.Limits[corev1.ResourceCPU] = qty
+		} else if qty, ok := resources.Limits[corev1.ResourceCPU]; ok {
+			resources.Requests[corev1.ResourceCPU] = qty
+		}
+		for name, qty := range resources.Limits {
+			if name != corev1.ResourceCPU {
+				resources.Requests[name] = qty
+			}
 		}
 	}
 
@@ -248,6 +277,15 @@ func findContainerDir(podCgroupDir, ID string) string {
 	return ""
 }
 
+// createdAt converts a CRI creation timestamp (in nanoseconds) to time,
+// falling back to the current time if the timestamp is not set.
+func createdAt(timestamp int64) time.Time {
+	if timestamp <= 0 {
+		return time.Now()
+	}
+	return time.Unix(0, timestamp)
+}
+
 func isSupportedQoSComputeResource(name corev1.ResourceName) bool {
 	return name == corev1.ResourceCPU || name == corev1.ResourceMemory
 }
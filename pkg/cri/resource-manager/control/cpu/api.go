@@ -25,9 +25,6 @@ func GetClasses() map[string]Class {
 }
 
 // Assign assigns a set of cpus to a class.
-//
-// TODO: Drop this function. Don't store cpu class in policy data but implement
-// controller-specific data store in cache.
 func Assign(c cache.Cache, class string, cpus ...int) error {
 	// NOTE: no locking implemented anywhere around -> we don't expect multiple parallel callers
 
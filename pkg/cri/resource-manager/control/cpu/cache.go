@@ -27,10 +27,31 @@ const (
 // classes
 type cpuClassAssignments map[string]utils.IDSet
 
+// cachedClassAssignments is the cached state of CPU class assignments. The
+// assignments are made by the active policy, and they are dropped when the
+// cached active policy changes, like the policy entries of the cache.
+type cachedClassAssignments struct {
+	Policy      string
+	Assignments cpuClassAssignments
+}
+
 // Get the state of CPU class assignments from cache
 func getClassAssignments(c cache.Cache) *cpuClassAssignments {
 	a := &cpuClassAssignments{}
 
+	cached := &cachedClassAssignments{}
+	if c.GetControllerEntry(CPUController, cacheKeyCPUAssignments, cached) {
+		if cached.Policy != c.GetActivePolicy() {
+			log.Info("dropping CPU class assignments of policy %q", cached.Policy)
+			return a
+		}
+		if cached.Assignments != nil {
+			*a = cached.Assignments
+		}
+		return a
+	}
+
+	// Caches saved by earlier versions have the assignments in a policy entry.
 	if !c.GetPolicyEntry(cacheKeyCPUAssignments, a) {
 		log.Error("no cached state of CPU class assignments found")
 	}
@@ -40,7 +61,8 @@ func getClassAssignments(c cache.Cache) *cpuClassAssignments {
 
 // Save the state of CPU class assignments in cache
 func setClassAssignments(c cache.Cache, a *cpuClassAssignments) {
-	c.SetPolicyEntry(cacheKeyCPUAssignments, cache.Cachable(a))
+	c.SetControllerEntry(CPUController, cacheKeyCPUAssignments,
+		&cachedClassAssignments{Policy: c.GetActivePolicy(), Assignments: *a})
 }
 
 // Set the value of cached cpuClassAssignments
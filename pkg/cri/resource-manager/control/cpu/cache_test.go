@@ -0,0 +1,69 @@
+// Copyright 2026 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cpu
+
+import (
+	"testing"
+
+	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/cache"
+	"github.com/intel/goresctrl/pkg/utils"
+)
+
+func TestClassAssignments(t *testing.T) {
+	dir := t.TempDir()
+	c, err := cache.NewCache(cache.Options{CacheDir: dir})
+	if err != nil {
+		t.Fatalf("failed to create cache: %v", err)
+	}
+	if err := c.SetActivePolicy("balloons"); err != nil {
+		t.Fatalf("failed to set active policy: %v", err)
+	}
+
+	// caches saved by earlier versions have the assignments in a policy entry
+	legacy := cpuClassAssignments{"turbo": utils.NewIDSet(1, 2)}
+	c.SetPolicyEntry(cacheKeyCPUAssignments, cache.Cachable(&legacy))
+	if err := c.Save(); err != nil {
+		t.Fatalf("failed to save cache: %v", err)
+	}
+	if c, err = cache.NewCache(cache.Options{CacheDir: dir}); err != nil {
+		t.Fatalf("failed to load cache: %v", err)
+	}
+	if a := getClassAssignments(c); (*a)["turbo"].String() != "1,2" {
+		t.Errorf("expected assignments from the policy entry, got %v", *a)
+	}
+
+	setClassAssignments(c, &cpuClassAssignments{"powersave": utils.NewIDSet(3)})
+	if err := c.Save(); err != nil {
+		t.Fatalf("failed to save cache: %v", err)
+	}
+	if c, err = cache.NewCache(cache.Options{CacheDir: dir}); err != nil {
+		t.Fatalf("failed to load cache: %v", err)
+	}
+	a := getClassAssignments(c)
+	if len(*a) != 1 || (*a)["powersave"].String() != "3" {
+		t.Errorf("expected restored assignments, got %v", *a)
+	}
+
+	// assignments of another policy are dropped
+	if err := c.ResetActivePolicy(); err != nil {
+		t.Fatalf("failed to reset active policy: %v", err)
+	}
+	if err := c.SetActivePolicy("topology-aware"); err != nil {
+		t.Fatalf("failed to set active policy: %v", err)
+	}
+	if a := getClassAssignments(c); len(*a) != 0 {
+		t.Errorf("expected no assignments after a policy switch, got %v", *a)
+	}
+}
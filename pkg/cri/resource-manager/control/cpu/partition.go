@@ -0,0 +1,169 @@
+// Copyright 2026 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cpu
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/intel/cri-resource-manager/pkg/cgroups"
+	"github.com/intel/cri-resource-manager/pkg/utils/cpuset"
+)
+
+const (
+	// PartitionRoot is the cpuset partition type of isolated CPUs.
+	PartitionRoot = "root"
+	// PartitionIsolated is the cpuset partition type of isolated CPUs
+	// without scheduler load balancing.
+	PartitionIsolated = "isolated"
+	// PartitionMember is the cpuset partition type of regular cgroups.
+	PartitionMember = "member"
+)
+
+// WritePartition turns a cgroup into a cgroup v2 cpuset partition of the
+// given type and CPUs. Cgroups whose parents do not allow the partition are
+// not touched, and invalid partitions are reverted to regular cgroups. It
+// returns true if the partition was created or changed.
+func WritePartition(group cgroups.Group, cpus cpuset.CPUSet, partition string) (bool, error) {
+	if _, err := os.Stat(filepath.Join(string(group), cgroups.CpusetExclusive)); err != nil {
+		return false, fmt.Errorf("kernel does not support %s", cgroups.CpusetExclusive)
+	}
+	if current, err := group.Read(cgroups.CpusetExclusive); err == nil {
+		if cset, err := cpuset.Parse(current); err == nil && cset.Equals(cpus) &&
+			checkPartition(group, partition) == nil {
+			return false, nil
+		}
+	}
+	if err := checkPartitionParent(group, cpus); err != nil {
+		return false, err
+	}
+	err := group.Write(cgroups.CpusetExclusive, cpus.String())
+	if err == nil {
+		err = group.Write(cgroups.CpusetPartition, partition)
+	}
+	if err == nil {
+		err = checkPartition(group, partition)
+	}
+	if err != nil {
+		if _, revertErr := ClearPartition(group); revertErr != nil {
+			log.Warn("failed to revert cgroup %s to a regular cgroup: %v", group, revertErr)
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// ClearPartition turns a cgroup back into a regular member of its parent
+// partition. It returns true if the cgroup was a partition.
+func ClearPartition(group cgroups.Group) (bool, error) {
+	partition, err := group.Read(cgroups.CpusetPartition)
+	if err != nil {
+		// no kernel support or the cgroup is already gone
+		return false, nil
+	}
+	cleared := false
+	if fields := strings.Fields(partition); len(fields) > 0 && fields[0] != PartitionMember {
+		if err := group.Write(cgroups.CpusetPartition, PartitionMember); err != nil {
+			return false, err
+		}
+		cleared = true
+	}
+	if exclusive, err := group.Read(cgroups.CpusetExclusive); err == nil && exclusive != "" {
+		if err := group.Write(cgroups.CpusetExclusive, "\n"); err != nil {
+			return cleared, err
+		}
+		cleared = true
+	}
+	return cleared, nil
+}
+
+// WriteExclusiveCpus sets the exclusive CPUs of a cgroup, if they are also
+// exclusive in its parent. It returns true if the exclusive CPUs changed.
+// If the CPUs can't be set, any earlier exclusive CPUs are cleared.
+func WriteExclusiveCpus(group cgroups.Group, cpus cpuset.CPUSet) (bool, error) {
+	current, err := group.Read(cgroups.CpusetExclusive)
+	if err != nil {
+		return false, fmt.Errorf("kernel does not support %s", cgroups.CpusetExclusive)
+	}
+	if cset, err := cpuset.Parse(current); err == nil && cset.Equals(cpus) {
+		return false, nil
+	}
+
+	var reason error
+	if !cpus.IsEmpty() {
+		reason = checkExclusiveParent(group, cpus)
+		if reason == nil {
+			return true, group.Write(cgroups.CpusetExclusive, cpus.String())
+		}
+	}
+	if current == "" {
+		return false, reason
+	}
+	if err := group.Write(cgroups.CpusetExclusive, "\n"); err != nil {
+		return false, err
+	}
+	return true, reason
+}
+
+// checkPartitionParent checks if a cgroup can become a partition of the
+// given CPUs. Its parent must either be a partition root, or have the CPUs
+// in its cpuset.cpus.exclusive for a remote partition. The parents of pod
+// cgroups in the default kubelet cgroup hierarchy are neither.
+func checkPartitionParent(group cgroups.Group, cpus cpuset.CPUSet) error {
+	parent := cgroups.AsGroup(filepath.Dir(string(group)))
+	if checkPartition(parent, PartitionRoot) == nil {
+		return nil
+	}
+	if err := checkExclusiveParent(group, cpus); err != nil {
+		return fmt.Errorf("parent cgroup %s is not a partition root: %w", parent, err)
+	}
+	return nil
+}
+
+// checkExclusiveParent checks if the given CPUs are exclusive in the parent
+// of a cgroup, which the kernel requires for exclusive CPUs of the cgroup.
+func checkExclusiveParent(group cgroups.Group, cpus cpuset.CPUSet) error {
+	parent := cgroups.AsGroup(filepath.Dir(string(group)))
+	if filepath.Clean(string(parent)) == filepath.Clean(cgroups.Cpuset.Path()) {
+		return nil
+	}
+	exclusive, err := parent.Read(cgroups.CpusetExclusive)
+	if err != nil {
+		return err
+	}
+	cset, err := cpuset.Parse(exclusive)
+	if err != nil {
+		return fmt.Errorf("cgroup %s: invalid %s %q: %v", parent, cgroups.CpusetExclusive, exclusive, err)
+	}
+	if !cset.Intersection(cpus).Equals(cpus) {
+		return fmt.Errorf("CPUs %s are not exclusive in parent cgroup %s", cpus, parent)
+	}
+	return nil
+}
+
+// checkPartition checks that a cgroup is a valid partition of the given type.
+func checkPartition(group cgroups.Group, partition string) error {
+	current, err := group.Read(cgroups.CpusetPartition)
+	if err != nil {
+		return err
+	}
+	// an invalid partition reads as "<type> invalid (<reason>)"
+	if fields := strings.Fields(current); len(fields) != 1 || fields[0] != partition {
+		return fmt.Errorf("cgroup %s is not a valid %s partition (%s)", group, partition, current)
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+// Copyright 2026 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cpu
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/intel/cri-resource-manager/pkg/cgroups"
+	"github.com/intel/cri-resource-manager/pkg/utils/cpuset"
+)
+
+// fakeCgroups creates fake cgroup entries. Like real cgroup entries,
+// they are not truncated when written through cgroups.Group.
+func fakeCgroups(t *testing.T, entries map[string]string) {
+	for path, value := range entries {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create %s: %v", filepath.Dir(path), err)
+		}
+		if err := os.WriteFile(path, []byte(value), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", path, err)
+		}
+	}
+}
+
+func readCgroup(t *testing.T, g cgroups.Group, entry string) string {
+	value, err := g.Read(entry)
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", entry, err)
+	}
+	return value
+}
+
+func TestWritePartition(t *testing.T) {
+	dir := t.TempDir()
+	parent := cgroups.AsGroup(dir)
+	group := cgroups.AsGroup(filepath.Join(dir, "pod"))
+	entry := func(g cgroups.Group, name string) string {
+		return filepath.Join(string(g), name)
+	}
+	cpus := cpuset.New(2, 3)
+
+	fakeCgroups(t, map[string]string{
+		entry(parent, cgroups.CpusetPartition): PartitionMember,
+		entry(parent, cgroups.CpusetExclusive): "",
+	})
+	if _, err := WritePartition(group, cpus, PartitionRoot); err == nil {
+		t.Errorf("expected an error without kernel support for exclusive CPUs")
+	}
+
+	fakeCgroups(t, map[string]string{
+		entry(group, cgroups.CpusetPartition): PartitionMember,
+		entry(group, cgroups.CpusetExclusive): "",
+	})
+	if _, err := WritePartition(group, cpus, PartitionRoot); err == nil {
+		t.Errorf("expected an error with a regular parent cgroup")
+	}
+	if exclusive := readCgroup(t, group, cgroups.CpusetExclusive); exclusive != "" {
+		t.Errorf("expected no exclusive CPUs with a regular parent cgroup, got %q", exclusive)
+	}
+	if partition := readCgroup(t, group, cgroups.CpusetPartition); partition != PartitionMember {
+		t.Errorf("expected partition %q with a regular parent cgroup, got %q", PartitionMember, partition)
+	}
+
+	// remote partition with the CPUs exclusive in the parent
+	fakeCgroups(t, map[string]string{
+		entry(parent, cgroups.CpusetExclusive): "2-5",
+		entry(group, cgroups.CpusetPartition):  "",
+	})
+	if _, err := WritePartition(group, cpuset.New(4, 5, 6, 7), PartitionIsolated); err == nil {
+		t.Errorf("expected an error for CPUs not exclusive in the parent cgroup")
+	}
+	if partition := readCgroup(t, group, cgroups.CpusetPartition); partition != "" {
+		t.Errorf("expected an untouched cgroup, got partition %q", partition)
+	}
+	changed, err := WritePartition(group, cpus, PartitionIsolated)
+	if err != nil || !changed {
+		t.Fatalf("expected partition to be created, got changed %v, error %v", changed, err)
+	}
+	if exclusive := readCgroup(t, group, cgroups.CpusetExclusive); exclusive != "2-3" {
+		t.Errorf("expected exclusive CPUs 2-3, got %q", exclusive)
+	}
+	if partition := readCgroup(t, group, cgroups.CpusetPartition); partition != PartitionIsolated {
+		t.Errorf("expected partition %q, got %q", PartitionIsolated, partition)
+	}
+	if changed, err := WritePartition(group, cpus, PartitionIsolated); err != nil || changed {
+		t.Errorf("expected an unchanged partition, got changed %v, error %v", changed, err)
+	}
+
+	cleared, err := ClearPartition(group)
+	if err != nil || !cleared {
+		t.Fatalf("expected partition to be cleared, got cleared %v, error %v", cleared, err)
+	}
+	if partition := readCgroup(t, group, cgroups.CpusetPartition); !strings.HasPrefix(partition, PartitionMember) {
+		t.Errorf("expected partition %q, got %q", PartitionMember, partition)
+	}
+	if cleared, err := ClearPartition(cgroups.AsGroup(filepath.Join(dir, "gone"))); err != nil || cleared {
+		t.Errorf("expected nothing to clear for a missing cgroup, got cleared %v, error %v", cleared, err)
+	}
+
+	// partition root parent
+	fakeCgroups(t, map[string]string{
+		entry(parent, cgroups.CpusetPartition): PartitionRoot,
+		entry(parent, cgroups.CpusetExclusive): "",
+		entry(group, cgroups.CpusetPartition):  "",
+		entry(group, cgroups.CpusetExclusive):  "",
+	})
+	if _, err := WritePartition(group, cpus, PartitionRoot); err != nil {
+		t.Fatalf("unexpected error with a partition root parent: %v", err)
+	}
+	if partition := readCgroup(t, group, cgroups.CpusetPartition); partition != PartitionRoot {
+		t.Errorf("expected partition %q, got %q", PartitionRoot, partition)
+	}
+}
+
+func TestWriteExclusiveCpus(t *testing.T) {
+	dir := t.TempDir()
+	pod := cgroups.AsGroup(dir)
+	group := cgroups.AsGroup(filepath.Join(dir, "container"))
+	if err := os.MkdirAll(string(group), 0755); err != nil {
+		t.Fatalf("failed to create %s: %v", group, err)
+	}
+	write := func(g cgroups.Group, value string) {
+		fakeCgroups(t, map[string]string{filepath.Join(string(g), cgroups.CpusetExclusive): value})
+	}
+	cpus := cpuset.New(2, 3)
+
+	if _, err := WriteExclusiveCpus(group, cpus); err == nil {
+		t.Errorf("expected an error without kernel support for exclusive CPUs")
+	}
+
+	// CPUs not exclusive in the pod are quietly skipped
+	write(pod, "")
+	write(group, "")
+	if changed, err := WriteExclusiveCpus(group, cpus); err == nil || changed {
+		t.Errorf("expected CPUs to be skipped, got changed %v, error %v", changed, err)
+	}
+	if exclusive := readCgroup(t, group, cgroups.CpusetExclusive); exclusive != "" {
+		t.Errorf("expected no exclusive CPUs, got %q", exclusive)
+	}
+
+	write(pod, "2-5")
+	if changed, err := WriteExclusiveCpus(group, cpus); err != nil || !changed {
+		t.Errorf("expected CPUs to be set, got changed %v, error %v", changed, err)
+	}
+	if exclusive := readCgroup(t, group, cgroups.CpusetExclusive); exclusive != "2-3" {
+		t.Errorf("expected exclusive CPUs 2-3, got %q", exclusive)
+	}
+	if changed, err := WriteExclusiveCpus(group, cpus); err != nil || changed {
+		t.Errorf("expected unchanged CPUs, got changed %v, error %v", changed, err)
+	}
+
+	// reallocated CPUs no longer exclusive in the pod clear earlier ones
+	if changed, err := WriteExclusiveCpus(group, cpuset.New(6)); err == nil || !changed {
+		t.Errorf("expected CPUs to be cleared, got changed %v, error %v", changed, err)
+	}
+	data, err := os.ReadFile(filepath.Join(string(group), cgroups.CpusetExclusive))
+	if err != nil || !strings.HasPrefix(string(data), "\n") {
+		t.Errorf("expected exclusive CPUs to be cleared, got %q (%v)", data, err)
+	}
+}
@@ -0,0 +1,168 @@
+// Copyright 2026 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package netcls
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/intel/cri-resource-manager/pkg/cgroups"
+	"github.com/intel/cri-resource-manager/pkg/cri/client"
+	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/cache"
+	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/control"
+	logger "github.com/intel/cri-resource-manager/pkg/log"
+)
+
+const (
+	// NetClassController is the name of the network class controller.
+	NetClassController = cache.NetClass
+)
+
+// netclsctl encapsulates the runtime state of our network class controller.
+type netclsctl struct {
+	cache cache.Cache // resource manager cache
+}
+
+// Our logger instance.
+var log logger.Logger = logger.NewLogger(NetClassController)
+
+// Our singleton network class controller instance.
+var singleton *netclsctl
+
+// getNetClassController returns our singleton network class controller instance.
+func getNetClassController() *netclsctl {
+	if singleton == nil {
+		singleton = &netclsctl{}
+	}
+	return singleton
+}
+
+// Start initializes the controller for enforcing decisions.
+func (ctl *netclsctl) Start(cache cache.Cache, _ client.Client) error {
+	entry := filepath.Join(cgroups.NetCls.Path(), cgroups.NetClsClassID)
+	if _, err := os.Stat(entry); err != nil {
+		return netclsError("cgroup net_cls controller not available: %v", err)
+	}
+	ctl.cache = cache
+	return nil
+}
+
+// Stop shuts down the controller.
+func (ctl *netclsctl) Stop() {
+}
+
+// PreCreateHook is the network class controller pre-create hook.
+func (ctl *netclsctl) PreCreateHook(_ cache.Container) error {
+	return nil
+}
+
+// PreStartHook is the network class controller pre-start hook.
+func (ctl *netclsctl) PreStartHook(_ cache.Container) error {
+	return nil
+}
+
+// PostStartHook is the network class controller post-start hook.
+func (ctl *netclsctl) PostStartHook(c cache.Container) error {
+	if !c.HasPending(NetClassController) {
+		return nil
+	}
+
+	if err := ctl.setClassID(c); err != nil {
+		return err
+	}
+
+	c.ClearPending(NetClassController)
+
+	return nil
+}
+
+// PostUpdateHook is the network class controller post-update hook.
+func (ctl *netclsctl) PostUpdateHook(c cache.Container) error {
+	if !c.HasPending(NetClassController) {
+		return nil
+	}
+
+	if err := ctl.setClassID(c); err != nil {
+		return err
+	}
+
+	c.ClearPending(NetClassController)
+
+	return nil
+}
+
+// PostStopHook is the network class controller post-stop hook.
+func (ctl *netclsctl) PostStopHook(_ cache.Container) error {
+	return nil
+}
+
+// setClassID sets the net_cls classid of the container, resetting it if
+// the container has no network class.
+func (ctl *netclsctl) setClassID(c cache.Container) error {
+	class := c.GetNetClass()
+	classID, err := ParseClassID(class)
+	if err != nil {
+		return netclsError("%q: %v", c.PrettyName(), err)
+	}
+
+	dir := c.GetCgroupDir()
+	if dir == "" {
+		return netclsError("%q: failed to determine cgroup directory", c.PrettyName())
+	}
+
+	group := cgroups.NetCls.Group(dir)
+	if err := group.Write(cgroups.NetClsClassID, "%d\n", classID); err != nil {
+		return netclsError("%q: failed to set classid: %v", c.PrettyName(), err)
+	}
+
+	log.Info("%q: network class set to %q (classid 0x%x)", c.PrettyName(), class, classID)
+
+	return nil
+}
+
+// ParseClassID parses a network class given as a tc class handle
+// "MAJOR:MINOR", with both numbers in hexadecimal, into a net_cls classid.
+// An empty class gives classid 0, which resets the classid of a cgroup.
+func ParseClassID(class string) (uint32, error) {
+	if class == "" {
+		return 0, nil
+	}
+	split := strings.Split(class, ":")
+	if len(split) != 2 {
+		return 0, netclsError("invalid network class %q, expecting MAJOR:MINOR", class)
+	}
+	major, err := strconv.ParseUint(split[0], 16, 16)
+	if err != nil {
+		return 0, netclsError("invalid major number in network class %q: %v", class, err)
+	}
+	minor, err := strconv.ParseUint(split[1], 16, 16)
+	if err != nil {
+		return 0, netclsError("invalid minor number in network class %q: %v", class, err)
+	}
+	return uint32(major<<16 | minor), nil
+}
+
+// netclsError creates a network class controller-specific formatted error message.
+func netclsError(format string, args ...interface{}) error {
+	return fmt.Errorf("netclass: "+format, args...)
+}
+
+// init registers this controller.
+func init() {
+	control.Register(NetClassController, "network class controller", getNetClassController())
+}
@@ -152,7 +152,7 @@ func (d *demoter) updateDemoter(cid string, p pagePool, targetNodes idset.IDSet)
 					demotion, ok := msg.(demotion)
 					if ok {
 						pagePool = demotion.pagePool
-						targetNodes = demotion.targetNodes
+						nodes = demotion.targetNodes
 						if p.longestRange > d.maxPageMoveCount {
 							// The number of pages moved needs to be at least as large as a range in numa_maps
 							// file so that we know that all pages will be moved (even if some of them were
@@ -193,9 +193,9 @@ func (d *demoter) stopDemoter(cid string) {
 	}
 }
 
-func (d *demoter) stopUnusedDemoters(cs map[string]*container) {
+func (d *demoter) stopUnusedDemoters(active map[string]struct{}) {
 	for id := range d.containerDemoters {
-		if _, found := cs[id]; !found {
+		if _, found := active[id]; !found {
 			d.stopDemoter(id)
 		}
 	}
@@ -271,38 +271,60 @@ func (d *demoter) scanPages() {
 	d.migration.Lock()
 	defer d.migration.Unlock()
 
+	active := map[string]struct{}{}
 	for _, container := range d.migration.containers {
 		pm := container.GetPageMigration()
 		if pm == nil {
 			continue
 		}
-		dramNodes := pm.SourceNodes
-		pmemNodes := pm.TargetNodes
-		if dramNodes.Size() == 0 || pmemNodes.Size() == 0 {
-			continue
-		}
 
-		// Gather the known pages which need to be moved.
-		pagePool, err := d.getPagesForContainer(container, dramNodes)
-		if err != nil {
-			log.Error("failed to get pages for container %v", container.prettyName)
-			continue
-		}
+		// Gather the known pages which need to be moved, for each demotion step.
+		pools := map[string]pagePool{}
+		targets := map[string]idset.IDSet{}
+		for step, s := range pm.Steps() {
+			if s.SourceNodes.Size() == 0 || s.TargetNodes.Size() == 0 {
+				continue
+			}
+			pagePool, err := d.getPagesForContainer(container, s.SourceNodes)
+			if err != nil {
+				log.Error("failed to get pages for container %v", container.prettyName)
+				continue
+			}
+
+			count := 0
+			for _, pages := range pagePool.pages {
+				count += len(pages)
+			}
+			log.Debug("%d pages for (maybe) demoting from %s to %s for %v", count,
+				s.SourceNodes, s.TargetNodes, container.prettyName)
 
-		count := 0
-		for _, pages := range pagePool.pages {
-			count += len(pages)
+			id := demoterID(container.GetCacheID(), step)
+			pools[id] = pagePool
+			targets[id] = s.TargetNodes
+		}
+		if len(pools) == 0 {
+			continue
 		}
-		log.Debug("%d pages for (maybe) demoting for %v", count, container.prettyName)
 
 		// Reset the dirty bit from all pages.
 		d.resetDirtyBit(container)
 
-		// Give the pages to the page moving goroutine. Copy the page pool so that there's no race.
-		d.updateDemoter(container.GetCacheID(), copyPagePool(pagePool), pmemNodes.Clone())
+		// Give the pages to the page moving goroutines. Copy the page pools so that there's no race.
+		for id, pagePool := range pools {
+			d.updateDemoter(id, copyPagePool(pagePool), targets[id].Clone())
+			active[id] = struct{}{}
+		}
 	}
 
-	d.stopUnusedDemoters(d.migration.containers)
+	d.stopUnusedDemoters(active)
+}
+
+// demoterID returns the ID of the demoter for a demotion step of a container.
+func demoterID(cid string, step int) string {
+	if step == 0 {
+		return cid
+	}
+	return cid + "/" + strconv.Itoa(step)
 }
 
 func (d *demoter) getPagesForContainer(c *container, sourceNodes idset.IDSet) (pagePool, error) {
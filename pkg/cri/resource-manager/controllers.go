@@ -20,6 +20,7 @@ import (
 	_ "github.com/intel/cri-resource-manager/pkg/cri/resource-manager/control/cpu"
 	_ "github.com/intel/cri-resource-manager/pkg/cri/resource-manager/control/cri"
 	_ "github.com/intel/cri-resource-manager/pkg/cri/resource-manager/control/memory"
+	_ "github.com/intel/cri-resource-manager/pkg/cri/resource-manager/control/netcls"
 	_ "github.com/intel/cri-resource-manager/pkg/cri/resource-manager/control/page-migrate"
 	_ "github.com/intel/cri-resource-manager/pkg/cri/resource-manager/control/rdt"
 )
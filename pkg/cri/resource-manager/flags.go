@@ -19,6 +19,7 @@ import (
 	"time"
 
 	"github.com/intel/cri-resource-manager/pkg/cri/relay"
+	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/cache"
 	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/sockets"
 	"github.com/intel/cri-resource-manager/pkg/pidfile"
 )
@@ -44,6 +45,7 @@ type options struct {
 	MetricsTimer          time.Duration
 	RebalanceTimer        time.Duration
 	DisableUI             bool
+	CacheKeyFile          string
 }
 
 // Relay command line options.
@@ -96,4 +98,8 @@ func init() {
 
 	flag.BoolVar(&opt.DisableUI, "disable-ui", false,
 		"Disable serving container placement visualization UIs.")
+
+	flag.StringVar(&opt.CacheKeyFile, "cache-encryption-key-file", "",
+		"File with a hex-encoded AES key for encrypting the cache on disk. Defaults to $"+
+			cache.EncryptionKeyEnv+", if set.")
 }
@@ -18,11 +18,15 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"sort"
+	"strconv"
 	"sync"
+	"time"
 
 	xhttp "github.com/intel/cri-resource-manager/pkg/instrumentation/http"
 	logger "github.com/intel/cri-resource-manager/pkg/log"
 	"github.com/intel/cri-resource-manager/pkg/topology"
+	"github.com/intel/cri-resource-manager/pkg/utils/cpuset"
 )
 
 // Pod describes a single pod and its containers.
@@ -51,21 +55,46 @@ type TopologyHints topology.Hints
 
 // Assignment describes resource assignments for a single container.
 type Assignment struct {
-	ContainerID   string // ID of container for this assignment
-	SharedCPUs    string // shared CPUs
-	CPUShare      int    // CPU share/weight for SharedCPUs
-	ExclusiveCPUs string // exclusive CPUs
-	Memory        string // memory controllers
-	Pool          string // pool container is assigned to
+	ContainerID   string            // ID of container for this assignment
+	CacheID       string            // cache ID of container for this assignment
+	SharedCPUs    string            // shared CPUs
+	CPUShare      int               // CPU share/weight for SharedCPUs
+	ExclusiveCPUs string            // exclusive CPUs
+	ReservedCPUs  string            // reserved CPUs
+	Memory        string            // memory controllers
+	MemoryLimits  map[string]uint64 // memory limits per memory type
+	Pool          string            // pool container is assigned to
+	MemoryPool    string            // pool container memory is assigned from
+}
+
+// AllocationFailure describes a failed resource allocation for a container.
+type AllocationFailure struct {
+	ContainerID string    // ID of container the allocation failed for
+	Pool        string    // pool (type) the allocation was attempted from
+	Reason      string    // short, machine-readable reason for the failure
+	Message     string    // detailed error message
+	Time        time.Time // time of the failure
 }
 
 // Pool describes a single (resource) pool.
 type Pool struct {
-	Name     string   // pool name
-	CPUs     string   // CPUs in this pool
-	Memory   string   // memory controllers (NUMA nodes) for this pool
-	Parent   string   // parent pool
-	Children []string // child pools
+	Name     string        // pool name
+	CPUs     string        // CPUs in this pool
+	Memory   string        // memory controllers (NUMA nodes) for this pool
+	Parent   string        // parent pool
+	Children []string      // child pools
+	Free     *FreeCapacity // resources still free for allocation, if known
+}
+
+// FreeCapacity describes the resources of a pool still free for allocation.
+type FreeCapacity struct {
+	IsolatedCPUs        string            // free isolated CPUs
+	ReservedCPUs        string            // reserved CPUs
+	SharableCPUs        string            // free sharable CPUs
+	ExclusiveCPUs       int               // number of sharable CPUs allocatable exclusively
+	AllocatableShared   int               // allocatable shared CPU in milli-CPU
+	AllocatableReserved int               // allocatable reserved CPU in milli-CPU
+	Memory              map[string]uint64 // free memory per memory type
 }
 
 // Socket describes a single physical CPU socket in the system.
@@ -97,6 +126,8 @@ type State struct {
 	Pods        map[string]*Pod        // pods and containers
 	Assignments map[string]*Assignment // resource assignments
 	System      *System                // info about hardware/system
+	Failures    []*AllocationFailure   // recent allocation failures
+	CacheErrors []string               // cache consistency check failures
 	Error       string
 }
 
@@ -119,6 +150,9 @@ func Setup(mux *xhttp.ServeMux, state *State) (*Server, error) {
 		return nil, err
 	}
 	mux.HandleFunc("/introspect", s.serve)
+	mux.HandleFunc("/introspect/assignment", s.serveAssignment)
+	mux.HandleFunc("/introspect/cpu", s.serveCPU)
+	mux.HandleFunc("/introspect/capacity", s.serveCapacity)
 	return s, nil
 }
 
@@ -167,6 +201,147 @@ func (s *Server) serve(w http.ResponseWriter, _ *http.Request) {
 	s.RUnlock()
 }
 
+// serveAssignment serves the assignment of a single container, looked up
+// by the container or cache ID given in the 'container' query parameter.
+func (s *Server) serveAssignment(w http.ResponseWriter, req *http.Request) {
+	if !s.ready {
+		return
+	}
+	id := req.URL.Query().Get("container")
+	if id == "" {
+		http.Error(w, "missing 'container' query parameter", http.StatusBadRequest)
+		return
+	}
+
+	s.RLock()
+	a := s.lookupAssignment(id)
+	s.RUnlock()
+
+	if a == nil {
+		http.Error(w, fmt.Sprintf("no assignment for container %q", id), http.StatusNotFound)
+		return
+	}
+
+	data, err := json.Marshal(a)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to marshal assignment: %v", err),
+			http.StatusInternalServerError)
+		return
+	}
+	fmt.Fprintf(w, "%s\r\n", data)
+}
+
+// lookupAssignment looks up an assignment by container or cache ID.
+func (s *Server) lookupAssignment(id string) *Assignment {
+	if s.state == nil {
+		return nil
+	}
+	if a, ok := s.state.Assignments[id]; ok {
+		return a
+	}
+	for _, a := range s.state.Assignments {
+		if a.CacheID == id {
+			return a
+		}
+	}
+	return nil
+}
+
+// serveCPU serves the assignments of all containers which own the CPU
+// given in the 'cpu' query parameter, either exclusively or shared.
+func (s *Server) serveCPU(w http.ResponseWriter, req *http.Request) {
+	if !s.ready {
+		return
+	}
+	cpu, err := strconv.Atoi(req.URL.Query().Get("cpu"))
+	if err != nil || cpu < 0 {
+		http.Error(w, "missing or invalid 'cpu' query parameter", http.StatusBadRequest)
+		return
+	}
+
+	s.RLock()
+	owners := s.lookupCPUOwners(cpu)
+	s.RUnlock()
+
+	data, err := json.Marshal(owners)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to marshal assignments: %v", err),
+			http.StatusInternalServerError)
+		return
+	}
+	fmt.Fprintf(w, "%s\r\n", data)
+}
+
+// lookupCPUOwners looks up the assignments which include the given CPU.
+func (s *Server) lookupCPUOwners(cpu int) []*Assignment {
+	owners := []*Assignment{}
+	if s.state == nil {
+		return owners
+	}
+	for _, a := range s.state.Assignments {
+		for _, cpus := range []string{a.ExclusiveCPUs, a.SharedCPUs, a.ReservedCPUs} {
+			if cpus == "" {
+				continue
+			}
+			cset, err := cpuset.Parse(cpus)
+			if err != nil {
+				log.Warn("%s: failed to parse CPUs %q: %v", a.ContainerID, cpus, err)
+				continue
+			}
+			if cset.Contains(cpu) {
+				owners = append(owners, a)
+				break
+			}
+		}
+	}
+	sort.Slice(owners, func(i, j int) bool {
+		return owners[i].ContainerID < owners[j].ContainerID
+	})
+	return owners
+}
+
+// serveCapacity serves the free capacity of all pools, or of the single
+// pool given in the 'pool' query parameter.
+func (s *Server) serveCapacity(w http.ResponseWriter, req *http.Request) {
+	if !s.ready {
+		return
+	}
+	name := req.URL.Query().Get("pool")
+
+	s.RLock()
+	capacity := s.lookupCapacity(name)
+	s.RUnlock()
+
+	if name != "" && len(capacity) == 0 {
+		http.Error(w, fmt.Sprintf("no free capacity for pool %q", name), http.StatusNotFound)
+		return
+	}
+
+	data, err := json.Marshal(capacity)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to marshal capacity: %v", err),
+			http.StatusInternalServerError)
+		return
+	}
+	fmt.Fprintf(w, "%s\r\n", data)
+}
+
+// lookupCapacity looks up the free capacity of the named pool, or of all
+// pools if no name is given.
+func (s *Server) lookupCapacity(name string) map[string]*FreeCapacity {
+	capacity := map[string]*FreeCapacity{}
+	if s.state == nil {
+		return capacity
+	}
+	for _, pool := range s.state.Pools {
+		if pool.Free == nil || (name != "" && pool.Name != name) {
+			continue
+		}
+		capacity[pool.Name] = pool.Free
+	}
+	return capacity
+}
+
 // introspectError creates an introspection-specific error.
 func introspectError(format string, args ...interface{}) error {
 	return fmt.Errorf("introspection: "+format, args...)
@@ -0,0 +1,60 @@
+// Copyright 2026 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package introspect
+
+import (
+	"testing"
+)
+
+func TestLookupCPUOwners(t *testing.T) {
+	s := &Server{
+		state: &State{
+			Assignments: map[string]*Assignment{
+				"exclusive": {ContainerID: "exclusive", ExclusiveCPUs: "2-3", SharedCPUs: "4-15"},
+				"shared":    {ContainerID: "shared", SharedCPUs: "4-15"},
+				"overlap":   {ContainerID: "overlap", ExclusiveCPUs: "3", ReservedCPUs: "0"},
+				"unpinned":  {ContainerID: "unpinned"},
+			},
+		},
+	}
+
+	tcases := []struct {
+		cpu      int
+		expected []string
+	}{
+		{cpu: 0, expected: []string{"overlap"}},
+		{cpu: 1},
+		{cpu: 2, expected: []string{"exclusive"}},
+		{cpu: 3, expected: []string{"exclusive", "overlap"}},
+		{cpu: 8, expected: []string{"exclusive", "shared"}},
+		{cpu: 16},
+	}
+	for _, tc := range tcases {
+		owners := []string{}
+		for _, a := range s.lookupCPUOwners(tc.cpu) {
+			owners = append(owners, a.ContainerID)
+		}
+		if len(owners) != len(tc.expected) {
+			t.Errorf("CPU #%d: expected owners %v, got %v", tc.cpu, tc.expected, owners)
+			continue
+		}
+		for i := range owners {
+			if owners[i] != tc.expected[i] {
+				t.Errorf("CPU #%d: expected owners %v, got %v", tc.cpu, tc.expected, owners)
+				break
+			}
+		}
+	}
+}
@@ -17,6 +17,10 @@ package balloons
 import (
 	"fmt"
 	"path/filepath"
+	"sort"
+	"strconv"
+	"sync/atomic"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	resapi "k8s.io/apimachinery/pkg/api/resource"
@@ -26,6 +30,7 @@ import (
 	"github.com/intel/cri-resource-manager/pkg/cpuallocator"
 	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/cache"
 	cpucontrol "github.com/intel/cri-resource-manager/pkg/cri/resource-manager/control/cpu"
+	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/control/netcls"
 	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/events"
 	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/introspect"
 	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/kubernetes"
@@ -46,12 +51,33 @@ const (
 	PolicyPath = "policy." + PolicyName
 	// balloonKey is a pod annotation key, the value is a pod balloon name.
 	balloonKey = "balloon." + PolicyName + "." + kubernetes.ResmgrKeyNamespace
+	// dedicatedBalloonKey is a pod annotation key, if "true" the
+	// container runs alone in a balloon of its own.
+	dedicatedBalloonKey = "dedicated-balloon." + PolicyName + "." + kubernetes.ResmgrKeyNamespace
+	// assignedBalloonKey is a container annotation key, the value is the
+	// balloon the container is assigned to, if AnnotateAssignments is set.
+	assignedBalloonKey = "assigned-balloon." + PolicyName + "." + kubernetes.ResmgrKeyNamespace
+	// balloonReassignmentKey is a container annotation key, the value tells
+	// when and why the container was last assigned to a balloon.
+	balloonReassignmentKey = "balloon-reassignment." + PolicyName + "." + kubernetes.ResmgrKeyNamespace
+	// daemonSetKey is a pod label key marking a pod of a DaemonSet, if set to "true".
+	daemonSetKey = "daemonset." + kubernetes.ResmgrKeyNamespace
+	// daemonSetGenerationKey is a pod label key set by the DaemonSet controller.
+	daemonSetGenerationKey = "pod-template-generation"
 	// reservedBalloonDefName is the name in the reserved balloon definition.
 	reservedBalloonDefName = "reserved"
 	// defaultBalloonDefName is the name in the default balloon definition.
 	defaultBalloonDefName = "default"
 	// NoLimit value denotes no limit being set.
 	NoLimit = 0
+	// IdleCpuClassDue is the event for switching released CPUs to the idle CPU class.
+	IdleCpuClassDue = "idle-cpu-class-due"
+	// MemoryPressureDue is the event for checking system memory pressure.
+	MemoryPressureDue = "memory-pressure-due"
+	// memoryLowKey is the cgroup v2 unified resource protecting memory from reclaim.
+	memoryLowKey = "memory.low"
+	// memoryLowRequest is the MemoryLow setting for using the memory request of containers.
+	memoryLowRequest = "request"
 )
 
 // balloons contains configuration and runtime attributes of the balloons policy
@@ -62,6 +88,7 @@ type balloons struct {
 	allowed          cpuset.CPUSet             // bounding set of CPUs we're allowed to use
 	reserved         cpuset.CPUSet             // system-/kube-reserved CPUs
 	freeCpus         cpuset.CPUSet             // CPUs to be included in growing or new ballons
+	kubeletCpus      cpuset.CPUSet             // CPUs exclusively assigned by the kubelet CPU manager
 	cpuTree          *cpuTreeNode              // system CPU topology
 	cpuTreeAllocator *cpuTreeAllocator         // CPU allocator from system CPU topology
 
@@ -70,6 +97,16 @@ type balloons struct {
 	balloons           []*Balloon  // balloon instances: reserved, default and user-defined
 
 	cpuAllocator cpuallocator.CPUAllocator // CPU allocator used by the policy
+
+	failureCounts map[string]map[string]int       // allocation failures per balloon type and reason
+	failures      []*introspect.AllocationFailure // recent allocation failures
+
+	idleCpuDeadlines map[int]time.Time // released CPUs waiting to be switched to the idle CPU class
+
+	pressuredMems      idset.IDSet   // memory nodes deprioritized due to memory pressure
+	memPressureChecked time.Time     // time of the latest memory pressure check
+	memPressureStop    chan struct{} // stops periodic memory pressure checks
+	memPressureActive  atomic.Bool   // whether any nodes are deprioritized
 }
 
 // Balloon contains attributes of a balloon instance
@@ -92,7 +129,15 @@ type Balloon struct {
 	// - len(PodIDs) is the number of pods in the balloon.
 	// - len(PodIDs[podID]) is the number of containers of podID
 	//   currently assigned to the balloon.
-	PodIDs           map[string][]string
+	PodIDs map[string][]string
+	// Dedicated is true if the balloon is reserved for a single
+	// container, and no other containers can be assigned to it.
+	Dedicated bool
+	// partitionPod is the ID of the pod isolated in a cpuset
+	// partition of the balloon CPUs, and noPartition the reason
+	// last logged for not isolating them.
+	partitionPod     string
+	noPartition      string
 	cpuTreeAllocator *cpuTreeAllocator
 }
 
@@ -145,6 +190,8 @@ func CreateBalloonsPolicy(policyOptions *policy.BackendOptions) policy.Backend {
 		options:      policyOptions,
 		cch:          policyOptions.Cache,
 		cpuAllocator: cpuallocator.NewCPUAllocator(policyOptions.System),
+
+		idleCpuDeadlines: make(map[int]time.Time),
 	}
 	log.Info("creating %s policy...", PolicyName)
 	if p.cpuTree, err = NewCpuTreeFromSystem(); err != nil {
@@ -203,7 +250,7 @@ func (p *balloons) Description() string {
 func (p *balloons) Start(add []cache.Container, del []cache.Container) error {
 	log.Info("%s policy started", PolicyName)
 	// reassign all containers
-	return p.Sync(p.cch.GetContainers(), del)
+	return p.Sync(cache.ManagedContainers(p.cch.GetContainers()), del)
 }
 
 // Sync synchronizes the active policy state.
@@ -224,6 +271,8 @@ func (p *balloons) AllocateResources(c cache.Container) error {
 		c.PrettyName(),
 		p.containerRequestedMilliCpus(c.GetCacheID()),
 		p.containerLimitedMilliCpus(c.GetCacheID()))
+	p.checkMemoryPressure(time.Now())
+	p.updateKubeletCpus()
 	bln, err := p.allocateBalloon(c)
 	if err != nil {
 		return balloonsError("balloon allocation for container %s failed: %w", c.PrettyName(), err)
@@ -241,7 +290,9 @@ func (p *balloons) AllocateResources(c cache.Container) error {
 	// would mean no CPU pinning and balloon's containers would
 	// run on any CPUs.
 	if bln.AvailMilliCpus() < max(1, reqMilliCpus) {
-		p.resizeBalloon(bln, max(1, reqMilliCpus))
+		if err := p.resizeBalloon(bln, max(1, reqMilliCpus)); err != nil {
+			log.Warn("%s: balloon %s is too small: %v", c.PrettyName(), bln.PrettyName(), err)
+		}
 	}
 	p.assignContainer(c, bln)
 	if log.DebugEnabled() {
@@ -261,17 +312,22 @@ func (p *balloons) ReleaseResources(c cache.Container) error {
 		if bln.ContainerCount() == 0 {
 			// Deflate the balloon completely before
 			// freeing it.
-			p.resizeBalloon(bln, 0)
+			if err := p.resizeBalloon(bln, 0); err != nil {
+				log.Errorf("failed to deflate balloon %s: %v", bln.PrettyName(), err)
+			}
 			log.Debug("all containers removed, free balloon allocation %s", bln.PrettyName())
 			p.freeBalloon(bln)
 		} else {
 			// Make sure that the balloon will have at
 			// least 1 CPU to run remaining containers.
-			p.resizeBalloon(bln, max(1, p.requestedMilliCpus(bln)))
+			if err := p.resizeBalloon(bln, max(1, p.requestedMilliCpus(bln))); err != nil {
+				log.Errorf("failed to resize balloon %s: %v", bln.PrettyName(), err)
+			}
 		}
 	} else {
 		log.Debug("ReleaseResources: balloon-less container %s, nothing to release", c.PrettyName())
 	}
+	p.updateKubeletCpus()
 	return nil
 }
 
@@ -282,14 +338,119 @@ func (p *balloons) UpdateResources(c cache.Container) error {
 }
 
 // Rebalance tries to find an optimal allocation of resources for the current containers.
+// Containers stay in their balloons, only the CPUs of the balloons are reshaped.
 func (p *balloons) Rebalance() (bool, error) {
-	log.Debug("(not) rebalancing containers...")
-	return false, nil
+	log.Debug("rebalancing balloons...")
+	// Deflate over-provisioned balloons and repack all balloons
+	// without fixed CPUs by releasing all their CPUs and
+	// allocating them again, largest balloons first. The free
+	// CPUs stay the same over rebalancing, therefore rebalancing
+	// again without changes in balloons is a no-op.
+	blns := []*Balloon{}
+	counts := map[*Balloon]int{}
+	for _, bln := range p.balloons {
+		if p.hasFixedCpus(bln.Def) {
+			continue
+		}
+		blns = append(blns, bln)
+		counts[bln] = min(bln.Cpus.Size(), cpuCountOf(bln.Def, max(1, p.requestedMilliCpus(bln))))
+	}
+	sort.SliceStable(blns, func(i, j int) bool {
+		if counts[blns[i]] != counts[blns[j]] {
+			return counts[blns[i]] > counts[blns[j]]
+		}
+		if blns[i].Def.Name != blns[j].Def.Name {
+			return blns[i].Def.Name < blns[j].Def.Name
+		}
+		return blns[i].Instance < blns[j].Instance
+	})
+
+	oldCpus := make(map[*Balloon]cpuset.CPUSet, len(blns))
+	oldFreeCpus := p.freeCpus
+	for _, bln := range blns {
+		oldCpus[bln] = bln.Cpus
+	}
+	rollback := func(err error) (bool, error) {
+		for bln, cpus := range oldCpus {
+			bln.Cpus = cpus
+		}
+		p.freeCpus = oldFreeCpus
+		return false, balloonsError("rebalance: %w", err)
+	}
+	for _, bln := range blns {
+		if _, err := p.deflateCpus(bln, bln.Cpus.Size()); err != nil {
+			return rollback(err)
+		}
+	}
+	for _, bln := range blns {
+		if _, err := p.inflateCpus(bln, counts[bln]); err != nil {
+			return rollback(err)
+		}
+	}
+
+	changed := []*Balloon{}
+	for _, bln := range blns {
+		if bln.Cpus.Equals(oldCpus[bln]) {
+			continue
+		}
+		log.Debugf("- rebalance %s: CPUs %s -> %s", bln.PrettyName(), oldCpus[bln], bln.Cpus)
+		changed = append(changed, bln)
+	}
+	if len(changed) == 0 {
+		log.Debug("- balloons are already balanced")
+		return false, nil
+	}
+	for _, bln := range changed {
+		newCpus := bln.Cpus
+		bln.Cpus = oldCpus[bln]
+		p.forgetCpuClass(bln)
+		bln.Cpus = newCpus
+	}
+	for _, bln := range changed {
+		p.useCpuClass(bln)
+	}
+
+	// Re-share idle CPUs as both the free CPUs and the topology
+	// of the balloons may have changed.
+	repin := map[*Balloon]struct{}{}
+	for _, bln := range changed {
+		repin[bln] = struct{}{}
+	}
+	for _, bln := range p.shareIdleCpus(cpuset.New(), oldFreeCpus) {
+		repin[bln] = struct{}{}
+	}
+	for _, bln := range p.shareIdleCpus(p.freeCpus, cpuset.New()) {
+		repin[bln] = struct{}{}
+	}
+	for _, bln := range p.balloons {
+		if _, ok := repin[bln]; ok {
+			p.updatePinning(bln)
+		}
+	}
+	p.updateKubeletCpus()
+	return true, nil
 }
 
 // HandleEvent handles policy-specific events.
-func (p *balloons) HandleEvent(*events.Policy) (bool, error) {
-	log.Debug("(not) handling event...")
+func (p *balloons) HandleEvent(e *events.Policy) (bool, error) {
+	switch e.Type {
+	case IdleCpuClassDue:
+		p.applyIdleCpuClass(time.Now())
+		return false, nil
+	case MemoryPressureDue:
+		return p.checkMemoryPressure(time.Now()), nil
+	case events.ContainerStarted:
+		c, ok := e.Data.(cache.Container)
+		if !ok {
+			return false, balloonsError("%s event: expecting cache.Container Data, got %T",
+				e.Type, e.Data)
+		}
+		if bln := p.balloonByContainer(c); bln != nil {
+			p.updatePartitions(bln)
+		}
+		return false, nil
+	}
+	log.Debug("(not) handling event %s...", e.Type)
 	return false, nil
 }
 
@@ -299,8 +460,31 @@ func (p *balloons) ExportResourceData(c cache.Container) map[string]string {
 }
 
 // Introspect provides data for external introspection.
-func (p *balloons) Introspect(*introspect.State) {
-	return
+func (p *balloons) Introspect(state *introspect.State) {
+	state.Failures = make([]*introspect.AllocationFailure, len(p.failures))
+	copy(state.Failures, p.failures)
+
+	// Assignments are the effective balloons of containers, unlike
+	// container annotations which the runtime only sees at creation.
+	assignments := map[string]*introspect.Assignment{}
+	for _, bln := range p.balloons {
+		cpus := bln.Cpus.Union(bln.SharedIdleCpus)
+		for _, cID := range bln.ContainerIDs() {
+			c, ok := p.cch.LookupContainer(cID)
+			if !ok {
+				continue
+			}
+			a := &introspect.Assignment{
+				ContainerID: c.GetID(),
+				CacheID:     cID,
+				SharedCPUs:  cpus.String(),
+				Memory:      p.containerMems(c, bln).String(),
+				Pool:        bln.PrettyName(),
+			}
+			assignments[a.ContainerID] = a
+		}
+	}
+	state.Assignments = assignments
 }
 
 // balloonByContainer returns a balloon that contains a container.
@@ -320,17 +504,20 @@ func (p *balloons) balloonByContainer(c cache.Container) *Balloon {
 // balloonsByNamespace returns balloons that contain containers in a
 // namespace.
 func (p *balloons) balloonsByNamespace(namespace string) []*Balloon {
+	podIDs := map[string]struct{}{}
+	for _, pod := range p.cch.GetPodsByNamespace(namespace) {
+		podIDs[pod.GetID()] = struct{}{}
+	}
 	blns := []*Balloon{}
+	if len(podIDs) == 0 {
+		return blns
+	}
 	for _, bln := range p.balloons {
 		for podID, ctrIDs := range bln.PodIDs {
 			if len(ctrIDs) == 0 {
 				continue
 			}
-			pod, ok := p.cch.LookupPod(podID)
-			if !ok {
-				continue
-			}
-			if pod.GetNamespace() == namespace {
+			if _, ok := podIDs[podID]; ok {
 				blns = append(blns, bln)
 				break
 			}
@@ -379,6 +566,36 @@ func (p *balloons) balloonDefByName(defName string) *BalloonDef {
 	return nil
 }
 
+// wantsDedicatedBalloon returns true if a container is annotated to
+// run alone in a balloon.
+func wantsDedicatedBalloon(c cache.Container) (bool, error) {
+	value, ok := c.GetEffectiveAnnotation(dedicatedBalloonKey)
+	if !ok {
+		return false, nil
+	}
+	dedicated, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, balloonsError("invalid annotation %s: %q: %w", dedicatedBalloonKey, value, err)
+	}
+	if _, ok := c.GetEffectiveAnnotation(balloonKey); ok && dedicated {
+		return false, balloonsError("annotation %s cannot be used together with %s, balloon type of a dedicated balloon must not be named",
+			dedicatedBalloonKey, balloonKey)
+	}
+	return dedicated, nil
+}
+
+// assignableBalloons returns balloons to which new containers can be
+// assigned, skipping dedicated balloons.
+func assignableBalloons(blns []*Balloon) []*Balloon {
+	assignable := make([]*Balloon, 0, len(blns))
+	for _, bln := range blns {
+		if !bln.Dedicated {
+			assignable = append(assignable, bln)
+		}
+	}
+	return assignable
+}
+
 func (p *balloons) chooseBalloonDef(c cache.Container) (*BalloonDef, error) {
 	var blnDef *BalloonDef
 	// BalloonDef is defined by annotation?
@@ -396,6 +613,15 @@ func (p *balloons) chooseBalloonDef(c cache.Container) (*BalloonDef, error) {
 		return p.balloons[0].Def, nil
 	}
 
+	// BalloonDef is defined for DaemonSet pods?
+	if p.bpoptions.DaemonSetBalloon != "" && isDaemonSetContainer(c) {
+		blnDef = p.balloonDefByName(p.bpoptions.DaemonSetBalloon)
+		if blnDef == nil {
+			return nil, balloonsError("no balloon for DaemonSet pods %q", p.bpoptions.DaemonSetBalloon)
+		}
+		return blnDef, nil
+	}
+
 	// BalloonDef is defined by the namespace.
 	for _, blnDef := range append([]*BalloonDef{p.reservedBalloonDef, p.defaultBalloonDef}, p.bpoptions.BalloonDefs...) {
 		if namespaceMatches(c.GetNamespace(), blnDef.Namespaces) {
@@ -407,6 +633,19 @@ func (p *balloons) chooseBalloonDef(c cache.Container) (*BalloonDef, error) {
 	return p.defaultBalloonDef, nil
 }
 
+// isDaemonSetContainer returns true if the container belongs to a pod of a DaemonSet.
+func isDaemonSetContainer(c cache.Container) bool {
+	pod, ok := c.GetPod()
+	if !ok {
+		return false
+	}
+	if value, ok := pod.GetLabel(daemonSetKey); ok {
+		return value == "true"
+	}
+	_, ok = pod.GetLabel(daemonSetGenerationKey)
+	return ok
+}
+
 func (p *balloons) containerRequestedMilliCpus(contID string) int {
 	cont, ok := p.cch.LookupContainer(contID)
 	if !ok {
@@ -450,7 +689,32 @@ func (p *balloons) freeMilliCpus(bln *Balloon) int {
 // maxFreeMilliCpus returns free CPU resources in a balloon when it is
 // inflated as large as possible.
 func (p *balloons) maxFreeMilliCpus(bln *Balloon) int {
-	return bln.MaxAvailMilliCpus(p.freeCpus) - p.requestedMilliCpus(bln)
+	return p.maxAvailMilliCpus(bln) - p.requestedMilliCpus(bln)
+}
+
+// maxAvailMilliCpus returns the CPU capacity a balloon could be
+// inflated to, taking the MaxTotalCpus of its type into account.
+func (p *balloons) maxAvailMilliCpus(bln *Balloon) int {
+	maxAvail := bln.MaxAvailMilliCpus(p.freeCpus)
+	if bln.Def.MaxTotalCpus > NoLimit {
+		budget := (bln.Def.MaxTotalCpus - p.cpusOfDef(bln.Def, bln)) * 1000
+		if budget < maxAvail {
+			maxAvail = budget
+		}
+	}
+	return maxAvail
+}
+
+// cpusOfDef returns the total number of CPUs in balloons of a
+// balloon type, excluding the CPUs of the given balloon.
+func (p *balloons) cpusOfDef(blnDef *BalloonDef, exclude *Balloon) int {
+	cpus := 0
+	for _, bln := range p.balloonsByDef(blnDef) {
+		if bln != exclude {
+			cpus += bln.Cpus.Size()
+		}
+	}
+	return cpus
 }
 
 // largest helps finding the largest element and value in a slice.
@@ -485,6 +749,7 @@ func (p *balloons) resetCpuClass() error {
 	// containers on the balloon, including the reserved balloon.
 	//
 	// TODO: don't depend on cpu controller directly
+	p.idleCpuDeadlines = make(map[int]time.Time)
 	cpucontrol.Assign(p.cch, p.bpoptions.IdleCpuClass, p.allowed.UnsortedList()...)
 	log.Debugf("resetCpuClass available: %s; reserved: %s", p.allowed, p.reserved)
 	return nil
@@ -510,6 +775,9 @@ func (p *balloons) useCpuClass(bln *Balloon) error {
 	// - User-defined CPU AllocatorPriority: bln.Def.AllocatorPriority.
 	// - All existing balloon instances: p.balloons.
 	// - CPU configurations by user: bln.Def.CpuClass (for bln in p.balloons)
+	for _, cpu := range bln.Cpus.UnsortedList() {
+		delete(p.idleCpuDeadlines, cpu)
+	}
 	cpucontrol.Assign(p.cch, bln.Def.CpuClass, bln.Cpus.UnsortedList()...)
 	log.Debugf("useCpuClass Cpus: %s; CpuClass: %s", bln.Cpus, bln.Def.CpuClass)
 	return nil
@@ -519,8 +787,53 @@ func (p *balloons) useCpuClass(bln *Balloon) error {
 func (p *balloons) forgetCpuClass(bln *Balloon) {
 	// Use p.IdleCpuClass for bln.Cpus.
 	// Usual inputs: see useCpuClass
-	cpucontrol.Assign(p.cch, p.bpoptions.IdleCpuClass, bln.Cpus.UnsortedList()...)
-	log.Debugf("forgetCpuClass Cpus: %s; CpuClass: %s", bln.Cpus, bln.Def.CpuClass)
+	delay := time.Duration(p.bpoptions.IdleCpuClassDelay)
+	if delay <= 0 {
+		cpucontrol.Assign(p.cch, p.bpoptions.IdleCpuClass, bln.Cpus.UnsortedList()...)
+		log.Debugf("forgetCpuClass Cpus: %s; CpuClass: %s", bln.Cpus, bln.Def.CpuClass)
+		return
+	}
+	// Switch CPUs to the idle class only if they are still unused
+	// after the delay. useCpuClass cancels the switch.
+	deadline := time.Now().Add(delay)
+	for _, cpu := range bln.Cpus.UnsortedList() {
+		p.idleCpuDeadlines[cpu] = deadline
+	}
+	time.AfterFunc(delay, func() {
+		e := &events.Policy{
+			Type:   IdleCpuClassDue,
+			Source: PolicyName,
+		}
+		if err := p.options.SendEvent(e); err != nil {
+			log.Errorf("failed to send event for switching CPUs to idle CPU class: %v", err)
+		}
+	})
+	log.Debugf("forgetCpuClass Cpus: %s; CpuClass: %s, idle CPU class in %s", bln.Cpus, bln.Def.CpuClass, delay)
+}
+
+// applyIdleCpuClass switches released CPUs to the idle CPU class once
+// their delay has passed.
+func (p *balloons) applyIdleCpuClass(now time.Time) {
+	cpus := []int{}
+	for cpu, deadline := range p.idleCpuDeadlines {
+		if !deadline.After(now) {
+			cpus = append(cpus, cpu)
+			delete(p.idleCpuDeadlines, cpu)
+		}
+	}
+	if len(cpus) == 0 {
+		return
+	}
+	cpucontrol.Assign(p.cch, p.bpoptions.IdleCpuClass, cpus...)
+	log.Debugf("applyIdleCpuClass Cpus: %s; CpuClass: %s", cpuset.New(cpus...), p.bpoptions.IdleCpuClass)
+}
+
+// hasFixedCpus returns true if balloons of a type use the fixed set of
+// ReservedResources CPUs. The reserved balloon does, and so does the
+// default balloon unless its CPU counts are tweaked.
+func (p *balloons) hasFixedCpus(blnDef *BalloonDef) bool {
+	return blnDef == p.reservedBalloonDef ||
+		(blnDef == p.defaultBalloonDef && blnDef.MinCpus == 0 && blnDef.MaxCpus == 0)
 }
 
 func (p *balloons) newBalloon(blnDef *BalloonDef, confCpus bool) (*Balloon, error) {
@@ -529,7 +842,11 @@ func (p *balloons) newBalloon(blnDef *BalloonDef, confCpus bool) (*Balloon, erro
 	blnsOfDef := p.balloonsByDef(blnDef)
 	// Allowed to create new balloon instance from blnDef?
 	if blnDef.MaxBalloons > NoLimit && blnDef.MaxBalloons <= len(blnsOfDef) {
-		return nil, balloonsError("cannot create new %q balloon, MaxBalloons limit (%d) reached", blnDef.Name, blnDef.MaxBalloons)
+		return nil, allocError(FailureMaxBalloons, "cannot create new %q balloon, MaxBalloons limit (%d) reached", blnDef.Name, blnDef.MaxBalloons)
+	}
+	// Allowed to take MinCpus more CPUs for blnDef?
+	if blnDef.MaxTotalCpus > NoLimit && p.cpusOfDef(blnDef, nil)+blnDef.MinCpus > blnDef.MaxTotalCpus {
+		return nil, allocError(FailureMaxTotalCpus, "cannot create new %q balloon, MaxTotalCPUs limit (%d) reached", blnDef.Name, blnDef.MaxTotalCpus)
 	}
 	// Find the first unused balloon instance index.
 	freeInstance := 0
@@ -564,19 +881,16 @@ func (p *balloons) newBalloon(blnDef *BalloonDef, confCpus bool) (*Balloon, erro
 	}
 
 	// Allocate CPUs
-	if blnDef == p.reservedBalloonDef ||
-		(blnDef == p.defaultBalloonDef && blnDef.MinCpus == 0 && blnDef.MaxCpus == 0) {
-		// The reserved balloon uses ReservedResources CPUs.
-		// So does the default balloon unless its CPU counts are tweaked.
+	if p.hasFixedCpus(blnDef) {
 		cpus = p.reserved
 	} else {
 		addFromCpus, _, err := cpuTreeAllocator.ResizeCpus(cpuset.New(), p.freeCpus, blnDef.MinCpus)
 		if err != nil {
-			return nil, balloonsError("failed to choose a cpuset for allocating first %d CPUs from %#s", blnDef.MinCpus, p.freeCpus)
+			return nil, allocError(FailureInsufficientCpus, "failed to choose a cpuset for allocating first %d CPUs from %#s", blnDef.MinCpus, p.freeCpus)
 		}
 		cpus, err = p.cpuAllocator.AllocateCpus(&addFromCpus, blnDef.MinCpus, blnDef.AllocatorPriority)
 		if err != nil {
-			return nil, balloonsError("could not allocate %d MinCpus for balloon %s[%d]: %w", blnDef.MinCpus, blnDef.Name, freeInstance, err)
+			return nil, allocError(FailureInsufficientCpus, "could not allocate %d MinCpus for balloon %s[%d]: %w", blnDef.MinCpus, blnDef.Name, freeInstance, err)
 		}
 		p.freeCpus = p.freeCpus.Difference(cpus)
 	}
@@ -586,7 +900,7 @@ func (p *balloons) newBalloon(blnDef *BalloonDef, confCpus bool) (*Balloon, erro
 		PodIDs:           make(map[string][]string),
 		Cpus:             cpus,
 		SharedIdleCpus:   cpuset.New(),
-		Mems:             p.closestMems(cpus),
+		Mems:             p.balloonMems(blnDef, cpus),
 		cpuTreeAllocator: cpuTreeAllocator,
 	}
 	if confCpus {
@@ -608,6 +922,9 @@ func (p *balloons) deleteBalloon(bln *Balloon) {
 		}
 	}
 	p.balloons = remainingBalloons
+	// Restore the pod in the balloon to a regular cgroup, its
+	// containers may be reassigned to other balloons.
+	p.clearPartition(bln)
 	p.forgetCpuClass(bln)
 	p.freeCpus = p.freeCpus.Union(bln.Cpus)
 	p.cpuAllocator.ReleaseCpus(&bln.Cpus, bln.Cpus.Size(), bln.Def.AllocatorPriority)
@@ -616,6 +933,7 @@ func (p *balloons) deleteBalloon(bln *Balloon) {
 // freeBalloon clears a balloon and deletes it if allowed.
 func (p *balloons) freeBalloon(bln *Balloon) {
 	bln.PodIDs = make(map[string][]string)
+	bln.Dedicated = false
 	blnsSameDef := p.balloonsByDef(bln.Def)
 	if len(blnsSameDef) > bln.Def.MinBalloons {
 		p.deleteBalloon(bln)
@@ -639,10 +957,10 @@ func (p *balloons) chooseBalloonInstance(blnDef *BalloonDef, fm FillMethod, c ca
 		return p.balloons[0], nil
 	case FillDefaultBalloon:
 		return p.balloons[1], nil
-	case FillNewBalloon, FillNewBalloonMust:
+	case FillNewBalloon, FillNewBalloonMust, FillOverflowNewBalloon:
 		// Choosing an existing balloon without containers is
 		// preferred over instantiating a new balloon.
-		for _, bln := range p.balloonsByDef(blnDef) {
+		for _, bln := range assignableBalloons(p.balloonsByDef(blnDef)) {
 			if len(bln.PodIDs) == 0 {
 				return bln, nil
 			}
@@ -667,14 +985,14 @@ func (p *balloons) chooseBalloonInstance(blnDef *BalloonDef, fm FillMethod, c ca
 		undoFuncs = append(undoFuncs, func() {
 			p.freeCpus = p.freeCpus.Union(newBln.Cpus)
 		})
-		if newBln.MaxAvailMilliCpus(p.freeCpus) < reqMilliCpus {
+		if p.maxAvailMilliCpus(newBln) < reqMilliCpus && fm != FillOverflowNewBalloon {
 			// New balloon cannot be inflated to fit new
 			// container. Release its CPUs if already
 			// allocated (MinCPUs > 0), and never add it
 			// to the list of balloons.
 			undo()
 			if fm == FillNewBalloonMust {
-				return nil, balloonsError("not enough CPUs to run container %s requesting %s mCPU. %s.MaxCPUs: %d mCPU, free CPUs: %s",
+				return nil, allocError(FailureInsufficientCpus, "not enough CPUs to run container %s requesting %d mCPU. %s.MaxCPUs: %d mCPU, free CPUs: %d mCPU",
 					c.PrettyName(), reqMilliCpus, blnDef.Name, blnDef.MaxCpus*1000, p.freeCpus.Size()*1000)
 			} else {
 				return nil, nil
@@ -704,7 +1022,7 @@ func (p *balloons) chooseBalloonInstance(blnDef *BalloonDef, fm FillMethod, c ca
 		}
 		return newBln, nil
 	case FillSameNamespace:
-		for _, bln := range p.balloonsByNamespace(c.GetNamespace()) {
+		for _, bln := range assignableBalloons(p.balloonsByNamespace(c.GetNamespace())) {
 			if bln.Def == blnDef && p.maxFreeMilliCpus(bln) >= reqMilliCpus {
 				return bln, nil
 			}
@@ -712,7 +1030,7 @@ func (p *balloons) chooseBalloonInstance(blnDef *BalloonDef, fm FillMethod, c ca
 		return nil, nil
 	case FillSamePod:
 		if pod, ok := c.GetPod(); ok {
-			for _, bln := range p.balloonsByPod(pod) {
+			for _, bln := range assignableBalloons(p.balloonsByPod(pod)) {
 				if p.maxFreeMilliCpus(bln) >= reqMilliCpus {
 					return bln, nil
 				}
@@ -724,7 +1042,7 @@ func (p *balloons) chooseBalloonInstance(blnDef *BalloonDef, fm FillMethod, c ca
 	}
 	// Handle fill methods that need existing instances of
 	// balloonDef, and fail if there are no instances.
-	balloons := p.balloonsByDef(blnDef)
+	balloons := p.preferUnpressured(assignableBalloons(p.balloonsByDef(blnDef)))
 	if len(balloons) == 0 {
 		return nil, nil
 	}
@@ -747,6 +1065,27 @@ func (p *balloons) chooseBalloonInstance(blnDef *BalloonDef, fm FillMethod, c ca
 		if maxFreeMilliCpus >= reqMilliCpus {
 			return balloons[blnIdx], nil
 		}
+	case FillPacked:
+		// Which non-empty balloon has least free CPU left
+		// after adding the container without inflating it?
+		if bln := p.packedBalloon(balloons, reqMilliCpus, p.freeMilliCpus); bln != nil {
+			return bln, nil
+		}
+	case FillPackedInflate:
+		// Which non-empty balloon has least free CPU left
+		// after adding the container and inflating it to the
+		// maximum size?
+		if bln := p.packedBalloon(balloons, reqMilliCpus, p.maxFreeMilliCpus); bln != nil {
+			return bln, nil
+		}
+	case FillOverflowShare:
+		// Which balloon is the least loaded after inflating it
+		// to the maximum size, even if the container does not
+		// fit?
+		blnIdx, _ := largest(len(balloons), func(i int) int {
+			return p.maxFreeMilliCpus(balloons[i])
+		})
+		return balloons[blnIdx], nil
 	default:
 		return nil, balloonsError("balloon type fill method not implemented: %s", fm)
 	}
@@ -754,6 +1093,28 @@ func (p *balloons) chooseBalloonInstance(blnDef *BalloonDef, fm FillMethod, c ca
 	return nil, nil
 }
 
+// packedBalloon returns the balloon with containers that has the
+// least free mCPUs left after fitting reqMilliCpus, or nil if there
+// is no such balloon. Empty balloons are skipped to keep them idle.
+func (p *balloons) packedBalloon(balloons []*Balloon, reqMilliCpus int, freeOf func(*Balloon) int) *Balloon {
+	var packed *Balloon
+	packedFree := 0
+	for _, bln := range balloons {
+		if len(bln.PodIDs) == 0 {
+			continue
+		}
+		free := freeOf(bln)
+		if free < reqMilliCpus {
+			continue
+		}
+		if packed == nil || free < packedFree {
+			packed = bln
+			packedFree = free
+		}
+	}
+	return packed
+}
+
 func namespaceMatches(namespace string, patterns []string) bool {
 	for _, pattern := range patterns {
 		ret, err := filepath.Match(pattern, namespace)
@@ -766,21 +1127,52 @@ func namespaceMatches(namespace string, patterns []string) bool {
 
 // allocateBalloon returns a balloon allocated for a container.
 func (p *balloons) allocateBalloon(c cache.Container) (*Balloon, error) {
+	dedicated, err := wantsDedicatedBalloon(c)
+	if err != nil {
+		p.recordFailure(nil, c, err)
+		return nil, err
+	}
 	blnDef, err := p.chooseBalloonDef(c)
 	if err != nil {
+		p.recordFailure(nil, c, err)
 		return nil, err
 	}
 	if blnDef == nil {
-		return nil, balloonsError("no applicable balloon type found")
+		err = allocError(FailureNoBalloonType, "no applicable balloon type found")
+		p.recordFailure(nil, c, err)
+		return nil, err
 	}
 
-	bln, err := p.allocateBalloonOfDef(blnDef, c)
+	var bln *Balloon
+	if dedicated {
+		bln, err = p.allocateDedicatedBalloon(blnDef, c)
+	} else {
+		bln, err = p.allocateBalloonOfDef(blnDef, c)
+	}
 	if err != nil {
+		p.recordFailure(blnDef, c, err)
 		return nil, err
 	}
 	if bln == nil {
-		return nil, balloonsError("no suitable balloon instance available")
+		err = allocError(FailureNoBalloon, "no suitable balloon instance available")
+		p.recordFailure(blnDef, c, err)
+		return nil, err
+	}
+	return bln, nil
+}
+
+// allocateDedicatedBalloon returns a new balloon instantiated from a
+// definition for running a container alone.
+func (p *balloons) allocateDedicatedBalloon(blnDef *BalloonDef, c cache.Container) (*Balloon, error) {
+	if blnDef == p.reservedBalloonDef || blnDef == p.defaultBalloonDef {
+		return nil, allocError(FailureNoBalloon, "%s balloon cannot be dedicated to a container", blnDef.Name)
 	}
+	bln, err := p.chooseBalloonInstance(blnDef, FillNewBalloonMust, c)
+	if err != nil || bln == nil {
+		return nil, err
+	}
+	log.Debugf("balloon %s dedicated to %s", bln.PrettyName(), c.PrettyName())
+	bln.Dedicated = true
 	return bln, nil
 }
 
@@ -795,8 +1187,23 @@ func (p *balloons) allocateBalloonOfDef(blnDef *BalloonDef, c cache.Container) (
 	}
 
 	fillChain := []FillMethod{}
+	if blnDef.PreferPacking {
+		fillChain = append(fillChain, FillPacked, FillPackedInflate)
+	}
 	if !blnDef.PreferSpreadingPods {
 		fillChain = append(fillChain, FillSamePod)
+	} else if !p.podSpreadable(blnDef, c) {
+		bln, err := p.chooseBalloonInstance(blnDef, FillSamePod, c)
+		if err != nil || bln != nil {
+			return bln, err
+		}
+		for _, podBln := range p.balloonsByDef(blnDef) {
+			if _, ok := podBln.PodIDs[c.GetPodID()]; ok {
+				return nil, allocError(FailureNoBalloon,
+					"pod of %s requests more than MaxSpreadingPodMemory %s, refusing to spread it on new balloons",
+					c.PrettyName(), blnDef.MaxSpreadingPodMemory)
+			}
+		}
 	}
 	if blnDef.PreferPerNamespaceBalloon {
 		fillChain = append(fillChain, FillSameNamespace, FillNewBalloon)
@@ -819,7 +1226,24 @@ func (p *balloons) allocateBalloonOfDef(blnDef *BalloonDef, c cache.Container) (
 		log.Debugf("fill method %q suggests balloon instance %v", fillMethod, bln)
 		return bln, nil
 	}
-	return nil, nil
+	return p.overflowBalloon(blnDef, c)
+}
+
+// overflowBalloon returns a balloon for a container that does not fit
+// in any balloon of its type, according to the overflow method of the
+// type, or nil if the container is rejected.
+func (p *balloons) overflowBalloon(blnDef *BalloonDef, c cache.Container) (*Balloon, error) {
+	fillMethod, err := blnDef.OverflowMethod.fillMethod()
+	if err != nil || fillMethod == FillUnspecified {
+		return nil, err
+	}
+	bln, err := p.chooseBalloonInstance(blnDef, fillMethod, c)
+	if err != nil || bln == nil {
+		return nil, err
+	}
+	log.Warn("%s requesting %d mCPU overflows to %s, oversubscribing it",
+		c.PrettyName(), p.containerRequestedMilliCpus(c.GetCacheID()), bln.PrettyName())
+	return bln, nil
 }
 
 // dumpBalloon dumps balloon contents in detail.
@@ -840,13 +1264,14 @@ func (p *balloons) dumpBalloon(bln *Balloon) string {
 			}
 		}
 	}
-	s := fmt.Sprintf("Balloon %s{Cpus: %s; Mems: %s; mCPU used: %d; capacity: %d; max. capacity: %d; pods: %s; conts: %s}",
+	s := fmt.Sprintf("Balloon %s{Cpus: %s; Mems: %s; mCPU used: %d; capacity: %d; oversubscribed: %d; max. capacity: %d; pods: %s; conts: %s}",
 		bln.PrettyName(),
 		bln.Cpus,
 		bln.Mems,
 		p.requestedMilliCpus(bln),
 		bln.AvailMilliCpus(),
-		bln.MaxAvailMilliCpus(p.freeCpus),
+		max(0, -p.freeMilliCpus(bln)),
+		p.maxAvailMilliCpus(bln),
 		pods,
 		conts)
 	return s
@@ -865,6 +1290,61 @@ func (p *balloons) getPodMilliCPU(podID string) int64 {
 	return cpuRequested
 }
 
+// getPodMemoryRequest returns the memory requested by the pod of a
+// container. The request covers all containers of the pod, including
+// the ones not created yet, if the pod resource requirements are
+// known. Init containers run one at a time before the others, so the
+// largest of their requests counts if it exceeds the sum of the rest.
+func (p *balloons) getPodMemoryRequest(c cache.Container) int64 {
+	pod, ok := c.GetPod()
+	if !ok {
+		return memoryRequest(c.GetResourceRequirements())
+	}
+	reqs := pod.GetPodResourceRequirements()
+	if len(reqs.Containers) == 0 {
+		memRequested := int64(0)
+		for _, c := range pod.GetContainers() {
+			memRequested += memoryRequest(c.GetResourceRequirements())
+		}
+		return memRequested
+	}
+	memRequested, initRequested := int64(0), int64(0)
+	for _, r := range reqs.Containers {
+		memRequested += memoryRequest(r)
+	}
+	for _, r := range reqs.InitContainers {
+		if req := memoryRequest(r); req > initRequested {
+			initRequested = req
+		}
+	}
+	if initRequested > memRequested {
+		return initRequested
+	}
+	return memRequested
+}
+
+// memoryRequest returns the memory request in resource requirements.
+func memoryRequest(r corev1.ResourceRequirements) int64 {
+	if reqMem, ok := r.Requests[corev1.ResourceMemory]; ok {
+		return reqMem.Value()
+	}
+	return 0
+}
+
+// podSpreadable returns true if containers of the pod of c may be
+// spread on separate balloons of a balloon type.
+func (p *balloons) podSpreadable(blnDef *BalloonDef, c cache.Container) bool {
+	if blnDef.MaxSpreadingPodMemory == "" {
+		return true
+	}
+	limit, err := resapi.ParseQuantity(blnDef.MaxSpreadingPodMemory)
+	if err != nil {
+		log.Errorf("invalid MaxSpreadingPodMemory in balloon type %q: %v", blnDef.Name, err)
+		return true
+	}
+	return p.getPodMemoryRequest(c) <= limit.Value()
+}
+
 // changesBalloons returns true if two balloons policy configurations
 // may lead into different balloon instances or workload assignment.
 func changesBalloons(opts0, opts1 *BalloonsOptions) bool {
@@ -883,6 +1363,8 @@ func changesBalloons(opts0, opts1 *BalloonsOptions) bool {
 	// potentially changes balloons or workloads.
 	o0.IdleCpuClass = ""
 	o1.IdleCpuClass = ""
+	o0.IdleCpuClassDelay = 0
+	o1.IdleCpuClassDelay = 0
 	for i := range o0.BalloonDefs {
 		o0.BalloonDefs[i].CpuClass = ""
 		o1.BalloonDefs[i].CpuClass = ""
@@ -901,7 +1383,7 @@ func changesCpuClasses(opts0, opts1 *BalloonsOptions) bool {
 	if opts0 == nil || opts1 == nil {
 		return true
 	}
-	if opts0.IdleCpuClass != opts1.IdleCpuClass {
+	if opts0.IdleCpuClass != opts1.IdleCpuClass || opts0.IdleCpuClassDelay != opts1.IdleCpuClassDelay {
 		return true
 	}
 	if len(opts0.BalloonDefs) != len(opts1.BalloonDefs) {
@@ -915,6 +1397,82 @@ func changesCpuClasses(opts0, opts1 *BalloonsOptions) bool {
 	return false
 }
 
+// removedBalloonDefs returns the names of user-defined balloon
+// definitions that are present in opts0 but missing from opts1, if
+// removing them is the only difference between the configurations.
+// Otherwise it returns nil.
+func removedBalloonDefs(opts0, opts1 *BalloonsOptions) []string {
+	if opts0 == nil || opts1 == nil || len(opts1.BalloonDefs) >= len(opts0.BalloonDefs) {
+		return nil
+	}
+	kept := map[string]struct{}{}
+	for _, blnDef := range opts1.BalloonDefs {
+		kept[blnDef.Name] = struct{}{}
+	}
+	removed := []string{}
+	o0 := opts0.DeepCopy()
+	o0.BalloonDefs = []*BalloonDef{}
+	for _, blnDef := range opts0.BalloonDefs {
+		if _, ok := kept[blnDef.Name]; ok {
+			o0.BalloonDefs = append(o0.BalloonDefs, blnDef.DeepCopy())
+			continue
+		}
+		// Dropping customizations of built-in balloons needs a full reconfiguration.
+		if blnDef.Name == reservedBalloonDefName || blnDef.Name == defaultBalloonDefName {
+			return nil
+		}
+		removed = append(removed, blnDef.Name)
+	}
+	if utils.DumpJSON(o0) != utils.DumpJSON(opts1) {
+		return nil
+	}
+	return removed
+}
+
+// drainBalloonDefs removes balloon definitions from the active
+// configuration, deletes their balloons and reassigns the containers
+// of deleted balloons, dismissing them from the deleted balloons like
+// ReleaseResources does. Other balloons and their containers are left
+// intact, except for the idle CPUs they may share.
+func (p *balloons) drainBalloonDefs(defNames []string) {
+	drainDefs := map[*BalloonDef]struct{}{}
+	for _, defName := range defNames {
+		if blnDef := p.balloonDefByName(defName); blnDef != nil {
+			drainDefs[blnDef] = struct{}{}
+		}
+	}
+	keptDefs := make([]*BalloonDef, 0, len(p.bpoptions.BalloonDefs))
+	for _, blnDef := range p.bpoptions.BalloonDefs {
+		if _, ok := drainDefs[blnDef]; !ok {
+			keptDefs = append(keptDefs, blnDef)
+		}
+	}
+	p.bpoptions.BalloonDefs = keptDefs
+
+	evacuated := []cache.Container{}
+	for blnDef := range drainDefs {
+		for _, bln := range p.balloonsByDef(blnDef) {
+			for _, cID := range bln.ContainerIDs() {
+				if c, ok := p.cch.LookupContainer(cID); ok {
+					p.dismissContainer(c, bln)
+					evacuated = append(evacuated, c)
+				}
+			}
+			freedCpus := bln.Cpus
+			p.deleteBalloon(bln)
+			bln.PodIDs = make(map[string][]string)
+			p.updatePinning(p.shareIdleCpus(freedCpus, cpuset.New())...)
+		}
+	}
+
+	for _, c := range evacuated {
+		log.Info("evacuating container %s from removed balloon type", c.PrettyName())
+		if err := p.AllocateResources(c); err != nil {
+			log.Errorf("failed to reassign container %s: %v", c.PrettyName(), err)
+		}
+	}
+}
+
 // configNotify applies new configuration.
 func (p *balloons) configNotify(event pkgcfg.Event, source pkgcfg.Source) error {
 	log.Info("configuration %s", event)
@@ -933,6 +1491,8 @@ func (p *balloons) configNotify(event pkgcfg.Event, source pkgcfg.Source) error
 			for i := range p.bpoptions.BalloonDefs {
 				p.bpoptions.BalloonDefs[i].CpuClass = newBalloonsOptions.BalloonDefs[i].CpuClass
 			}
+			p.bpoptions.IdleCpuClass = newBalloonsOptions.IdleCpuClass
+			p.bpoptions.IdleCpuClassDelay = newBalloonsOptions.IdleCpuClassDelay
 			// (Re)configures all CPUs in balloons.
 			p.resetCpuClass()
 			for _, bln := range p.balloons {
@@ -941,12 +1501,24 @@ func (p *balloons) configNotify(event pkgcfg.Event, source pkgcfg.Source) error
 		}
 		return nil
 	}
+	if removed := removedBalloonDefs(&p.bpoptions, newBalloonsOptions); len(removed) > 0 {
+		// Options referring to the removed balloon types are
+		// invalid, validate them before draining anything.
+		if err := p.validateConfig(newBalloonsOptions); err != nil {
+			log.Error("config update failed: invalid configuration: %v", err)
+			return balloonsError("invalid configuration: %w", err)
+		}
+		log.Info("configuration changes only remove balloon types %v, draining them", removed)
+		p.drainBalloonDefs(removed)
+		return nil
+	}
 	if err := p.setConfig(newBalloonsOptions); err != nil {
 		log.Error("config update failed: %v", err)
 		return err
 	}
 	log.Info("config updated successfully")
-	p.Sync(p.cch.GetContainers(), p.cch.GetContainers())
+	containers := cache.ManagedContainers(p.cch.GetContainers())
+	p.Sync(containers, containers)
 	return nil
 }
 
@@ -980,6 +1552,18 @@ func (p *balloons) applyBalloonDef(balloons *[]*Balloon, blnDef *BalloonDef, fre
 		p.reservedBalloonDef.AllocatorPriority = blnDef.AllocatorPriority
 		p.reservedBalloonDef.CpuClass = blnDef.CpuClass
 		p.reservedBalloonDef.Namespaces = blnDef.Namespaces
+		p.reservedBalloonDef.MemoryLow = blnDef.MemoryLow
+		p.reservedBalloonDef.MemoryDistanceLimit = blnDef.MemoryDistanceLimit
+		if len(blnDef.MemoryNodes) > 0 {
+			nodes := idset.NewIDSet(p.options.System.NodeIDs()...)
+			for _, id := range blnDef.MemoryNodes {
+				if !nodes.Has(idset.ID(id)) {
+					return balloonsError("invalid reserved balloon MemoryNodes: no NUMA node #%d", id)
+				}
+			}
+			p.reservedBalloonDef.MemoryNodes = blnDef.MemoryNodes
+			reservedBalloon.Mems = p.balloonMems(reservedBalloon.Def, reservedBalloon.Cpus)
+		}
 	case defaultBalloon.Def.Name:
 		// Case 2: reconfigure the "default" balloon.
 		defaultUsesReservedCpus := true
@@ -989,11 +1573,16 @@ func (p *balloons) applyBalloonDef(balloons *[]*Balloon, blnDef *BalloonDef, fre
 		if blnDef.MinBalloons != 0 {
 			return balloonsError("cannot reconfigure the default balloon MinBalloons")
 		}
+		if len(blnDef.MemoryNodes) > 0 {
+			return balloonsError("MemoryNodes is only supported for the reserved balloon")
+		}
 		p.defaultBalloonDef.MinCpus = blnDef.MinCpus
 		p.defaultBalloonDef.MaxCpus = blnDef.MaxCpus
 		p.defaultBalloonDef.AllocatorPriority = blnDef.AllocatorPriority
 		p.defaultBalloonDef.CpuClass = blnDef.CpuClass
 		p.defaultBalloonDef.Namespaces = blnDef.Namespaces
+		p.defaultBalloonDef.MemoryLow = blnDef.MemoryLow
+		p.defaultBalloonDef.MemoryDistanceLimit = blnDef.MemoryDistanceLimit
 		if !defaultUsesReservedCpus {
 			// Overwrite existing default balloon instance
 			// that uses reserved CPUs with a balloon that
@@ -1007,6 +1596,9 @@ func (p *balloons) applyBalloonDef(balloons *[]*Balloon, blnDef *BalloonDef, fre
 		}
 	default:
 		// Case 3: create minimum amount (MinBalloons) of each user-defined balloons.
+		if len(blnDef.MemoryNodes) > 0 {
+			return balloonsError("MemoryNodes is only supported for the reserved balloon, not in %q", blnDef.Name)
+		}
 		for allocPrio := cpuallocator.CPUPriority(0); allocPrio < cpuallocator.NumCPUPriorities; allocPrio++ {
 			if blnDef.AllocatorPriority != allocPrio {
 				continue
@@ -1032,10 +1624,53 @@ func (p *balloons) validateConfig(bpoptions *BalloonsOptions) error {
 			return balloonsError("MinCpus (%d) > MaxCpus (%d) in balloon type %q",
 				blnDef.MinCpus, blnDef.MaxCpus, blnDef.Name)
 		}
+		if blnDef.MaxTotalCpus != NoLimit && blnDef.MinBalloons*blnDef.MinCpus > blnDef.MaxTotalCpus {
+			return balloonsError("MinBalloons (%d) * MinCpus (%d) > MaxTotalCpus (%d) in balloon type %q",
+				blnDef.MinBalloons, blnDef.MinCpus, blnDef.MaxTotalCpus, blnDef.Name)
+		}
 		if blnDef.MaxBalloons != NoLimit && blnDef.MinBalloons > blnDef.MaxBalloons {
 			return balloonsError("MinBalloons (%d) > MaxBalloons (%d) in balloon type %q",
 				blnDef.MinCpus, blnDef.MaxCpus, blnDef.Name)
 		}
+		if _, err := netcls.ParseClassID(blnDef.NetClass); err != nil {
+			return balloonsError("NetClass in balloon type %q: %w", blnDef.Name, err)
+		}
+		if _, err := memoryLow(blnDef.MemoryLow, 0); err != nil {
+			return balloonsError("MemoryLow in balloon type %q: %w", blnDef.Name, err)
+		}
+		if blnDef.MemoryDistanceLimit < 0 {
+			return balloonsError("negative MemoryDistanceLimit (%d) in balloon type %q",
+				blnDef.MemoryDistanceLimit, blnDef.Name)
+		}
+		if _, err := blnDef.OverflowMethod.fillMethod(); err != nil {
+			return balloonsError("OverflowMethod in balloon type %q: %w", blnDef.Name, err)
+		}
+		if blnDef.Exclusive && (blnDef.Name == reservedBalloonDefName || blnDef.Name == defaultBalloonDefName) {
+			return balloonsError("Exclusive is not supported for the %q balloon", blnDef.Name)
+		}
+		if blnDef.LoadBalancing != nil && (blnDef.Name == reservedBalloonDefName || blnDef.Name == defaultBalloonDefName) {
+			return balloonsError("LoadBalancing is not supported for the %q balloon", blnDef.Name)
+		}
+		if blnDef.MaxSpreadingPodMemory != "" {
+			if _, err := resapi.ParseQuantity(blnDef.MaxSpreadingPodMemory); err != nil {
+				return balloonsError("MaxSpreadingPodMemory in balloon type %q: %w", blnDef.Name, err)
+			}
+		}
+	}
+	if t := bpoptions.MemoryPressureThreshold; t < 0 || t > 100 {
+		return balloonsError("MemoryPressureThreshold %.2f out of range [0, 100]", t)
+	}
+	if name := bpoptions.DaemonSetBalloon; name != "" && name != reservedBalloonDefName && name != defaultBalloonDefName {
+		found := false
+		for _, blnDef := range bpoptions.BalloonDefs {
+			if blnDef.Name == name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return balloonsError("DaemonSetBalloon %q: no such balloon type", name)
+		}
 	}
 	return nil
 }
@@ -1048,6 +1683,7 @@ func (p *balloons) setConfig(bpoptions *BalloonsOptions) error {
 	if err := p.validateConfig(bpoptions); err != nil {
 		return balloonsError("invalid configuration: %w", err)
 	}
+	p.checkMemoryLow(bpoptions)
 
 	// Create the default reserved and default balloon
 	// definitions. Some properties of these definitions may be
@@ -1065,6 +1701,7 @@ func (p *balloons) setConfig(bpoptions *BalloonsOptions) error {
 	p.balloons = []*Balloon{}
 	p.freeCpus = p.allowed.Clone()
 	p.freeCpus = p.freeCpus.Difference(p.reserved)
+	p.kubeletCpus = cpuset.New()
 	p.cpuTreeAllocator = p.cpuTree.NewAllocator(cpuTreeAllocatorOptions{
 		topologyBalancing:           bpoptions.AllocatorTopologyBalancing,
 		preferSpreadOnPhysicalCores: bpoptions.PreferSpreadOnPhysicalCores,
@@ -1073,6 +1710,10 @@ func (p *balloons) setConfig(bpoptions *BalloonsOptions) error {
 	// because p.newBalloon() dereferences our options via p.bpoptions, so
 	// it would end up using the old configuration.
 	p.bpoptions = *bpoptions
+	// (Re)start or stop monitoring memory pressure.
+	p.watchMemoryPressure()
+	// Keep CPUs exclusively assigned by the kubelet out of balloons.
+	p.updateKubeletCpus()
 	// Instantiate built-in reserved and default balloons.
 	reservedBalloon, err := p.newBalloon(p.reservedBalloonDef, false)
 	if err != nil {
@@ -1118,19 +1759,67 @@ func (p *balloons) setConfig(bpoptions *BalloonsOptions) error {
 	return nil
 }
 
+// balloonMems returns memory node IDs for pinning containers of a
+// balloon of the given type running on the given CPUs.
+func (p *balloons) balloonMems(blnDef *BalloonDef, cpus cpuset.CPUSet) idset.IDSet {
+	if len(blnDef.MemoryNodes) > 0 {
+		mems := idset.NewIDSet()
+		for _, id := range blnDef.MemoryNodes {
+			mems.Add(idset.ID(id))
+		}
+		return mems
+	}
+	return p.closestMems(cpus, blnDef.MemoryDistanceLimit)
+}
+
 // closestMems returns memory node IDs good for pinning containers
-// that run on given CPUs
-func (p *balloons) closestMems(cpus cpuset.CPUSet) idset.IDSet {
-	mems := idset.NewIDSet()
+// that run on given CPUs. These are the nodes local to the CPUs and,
+// if distanceLimit is positive, the nodes within that distance from
+// any of them. Nodes under memory pressure are left out unless there
+// are no other nodes close to the CPUs.
+func (p *balloons) closestMems(cpus cpuset.CPUSet, distanceLimit int) idset.IDSet {
 	sys := p.options.System
+	local := idset.NewIDSet()
 	for _, nodeID := range sys.NodeIDs() {
 		if !cpus.Intersection(sys.Node(nodeID).CPUSet()).IsEmpty() {
-			mems.Add(nodeID)
+			local.Add(nodeID)
+		}
+	}
+	nodes := local
+	if distanceLimit > 0 {
+		nodes = nodesWithinDistance(local, sys.NodeIDs(), sys.NodeDistance, distanceLimit)
+	}
+	mems := idset.NewIDSet()
+	pressured := idset.NewIDSet()
+	for _, nodeID := range nodes.SortedMembers() {
+		if p.pressuredMems.Has(nodeID) {
+			pressured.Add(nodeID)
+			continue
 		}
+		mems.Add(nodeID)
+	}
+	// Fall back to pressured nodes only if there is nothing else.
+	if mems.Size() == 0 {
+		return pressured
 	}
 	return mems
 }
 
+// nodesWithinDistance returns the local nodes and the nodes within
+// distanceLimit from any of them.
+func nodesWithinDistance(local idset.IDSet, nodeIDs []idset.ID, distance func(from, to idset.ID) int, distanceLimit int) idset.IDSet {
+	nodes := local.Clone()
+	for _, nodeID := range nodeIDs {
+		for _, localID := range local.SortedMembers() {
+			if distance(localID, nodeID) <= distanceLimit {
+				nodes.Add(nodeID)
+				break
+			}
+		}
+	}
+	return nodes
+}
+
 // filterBalloons returns balloons for which the test function returns true
 func filterBalloons(balloons []*Balloon, test func(*Balloon) bool) (ret []*Balloon) {
 	for _, bln := range balloons {
@@ -1151,6 +1840,19 @@ func (p *balloons) availableMilliCpus(balloon *Balloon) int64 {
 	return cpuAvail - cpuRequested
 }
 
+// cpuCountOf returns the number of CPUs a balloon of a type needs for
+// running mCPUs, within the MinCpus and MaxCpus limits of the type.
+func cpuCountOf(blnDef *BalloonDef, milliCpus int) int {
+	cpuCount := (milliCpus + 999) / 1000
+	if blnDef.MaxCpus > NoLimit && cpuCount > blnDef.MaxCpus {
+		cpuCount = blnDef.MaxCpus
+	}
+	if blnDef.MinCpus > 0 && cpuCount < blnDef.MinCpus {
+		cpuCount = blnDef.MinCpus
+	}
+	return cpuCount
+}
+
 // resizeBalloon changes the CPUs allocated for a balloon, if allowed.
 func (p *balloons) resizeBalloon(bln *Balloon, newMilliCpus int) error {
 	if bln.Cpus.Equals(p.reserved) {
@@ -1158,64 +1860,151 @@ func (p *balloons) resizeBalloon(bln *Balloon, newMilliCpus int) error {
 		return nil
 	}
 	oldCpuCount := bln.Cpus.Size()
-	newCpuCount := (newMilliCpus + 999) / 1000
-	if bln.Def.MaxCpus > NoLimit && newCpuCount > bln.Def.MaxCpus {
-		newCpuCount = bln.Def.MaxCpus
-	}
-	if bln.Def.MinCpus > 0 && newCpuCount < bln.Def.MinCpus {
-		newCpuCount = bln.Def.MinCpus
+	newCpuCount := cpuCountOf(bln.Def, newMilliCpus)
+	// A balloon limited by MaxTotalCpus is inflated as much as
+	// possible, but the caller is told that it stays too small.
+	var limitErr error
+	if bln.Def.MaxTotalCpus > NoLimit && newCpuCount > oldCpuCount {
+		if budget := bln.Def.MaxTotalCpus - p.cpusOfDef(bln.Def, bln); newCpuCount > budget {
+			limitErr = allocError(FailureMaxTotalCpus,
+				"MaxTotalCPUs (%d) of balloon type %q limits %s to %d CPUs, %d needed",
+				bln.Def.MaxTotalCpus, bln.Def.Name, bln.PrettyName(), max(budget, oldCpuCount), newCpuCount)
+			newCpuCount = max(budget, oldCpuCount)
+		}
 	}
 	log.Debugf("resize %s to fit %d mCPU", bln, newMilliCpus)
 	log.Debugf("- change full CPUs from %d to %d", oldCpuCount, newCpuCount)
 	log.Debugf("- freecpus: %#s", p.freeCpus)
 	if oldCpuCount == newCpuCount {
-		return nil
+		return limitErr
 	}
 	cpuCountDelta := newCpuCount - oldCpuCount
 	p.forgetCpuClass(bln)
 	defer p.useCpuClass(bln)
 	if cpuCountDelta > 0 {
 		// Inflate the balloon.
-		addFromCpus, _, err := bln.cpuTreeAllocator.ResizeCpus(bln.Cpus, p.freeCpus, cpuCountDelta)
+		newCpus, err := p.inflateCpus(bln, cpuCountDelta)
 		if err != nil {
-			return balloonsError("resize/inflate: failed to choose a cpuset for allocating additional %d CPUs: %w", cpuCountDelta, err)
+			return balloonsError("resize/inflate: %w", err)
 		}
-		log.Debugf("- allocate CPUs %d from %#s", cpuCountDelta, addFromCpus)
-		newCpus, err := p.cpuAllocator.AllocateCpus(&addFromCpus, newCpuCount-oldCpuCount, bln.Def.AllocatorPriority)
-		if err != nil {
-			return balloonsError("resize/inflate: allocating %d CPUs for %s failed: %w", cpuCountDelta, bln, err)
-		}
-		p.freeCpus = p.freeCpus.Difference(newCpus)
-		bln.Cpus = bln.Cpus.Union(newCpus)
 		p.updatePinning(p.shareIdleCpus(p.freeCpus, newCpus)...)
 	} else {
 		// Deflate the balloon.
-		_, removeFromCpus, err := bln.cpuTreeAllocator.ResizeCpus(bln.Cpus, p.freeCpus, cpuCountDelta)
+		removedCpus, err := p.deflateCpus(bln, -cpuCountDelta)
 		if err != nil {
-			return balloonsError("resize/deflate: failed to choose a cpuset for releasing %d CPUs: %w", -cpuCountDelta, err)
+			return balloonsError("resize/deflate: %w", err)
 		}
-		log.Debugf("- releasing %d CPUs from cpuset %#s", -cpuCountDelta, removeFromCpus)
-		_, err = p.cpuAllocator.ReleaseCpus(&removeFromCpus, -cpuCountDelta, bln.Def.AllocatorPriority)
-		if err != nil {
-			return balloonsError("resize/deflate: releasing %d CPUs from %s failed: %w", -cpuCountDelta, bln, err)
-		}
-		log.Debugf("- old freeCpus: %#s, old bln.Cpus: %#s, releasing: %#s", p.freeCpus, bln.Cpus, removeFromCpus)
-		p.freeCpus = p.freeCpus.Union(removeFromCpus)
-		bln.Cpus = bln.Cpus.Difference(removeFromCpus)
-		p.updatePinning(p.shareIdleCpus(removeFromCpus, cpuset.New())...)
+		p.updatePinning(p.shareIdleCpus(removedCpus, cpuset.New())...)
 	}
 	log.Debugf("- resize successful: %s, freecpus: %#s", bln, p.freeCpus)
 	p.updatePinning(bln)
-	return nil
+	return limitErr
+}
+
+// inflateCpus allocates more CPUs for a balloon from the free CPUs.
+// It returns the allocated CPUs.
+func (p *balloons) inflateCpus(bln *Balloon, count int) (cpuset.CPUSet, error) {
+	if count <= 0 {
+		return cpuset.New(), nil
+	}
+	addFromCpus, _, err := bln.cpuTreeAllocator.ResizeCpus(bln.Cpus, p.freeCpus, count)
+	if err != nil {
+		return cpuset.New(), fmt.Errorf("failed to choose a cpuset for allocating additional %d CPUs: %w", count, err)
+	}
+	log.Debugf("- allocate CPUs %d from %#s", count, addFromCpus)
+	newCpus, err := p.cpuAllocator.AllocateCpus(&addFromCpus, count, bln.Def.AllocatorPriority)
+	if err != nil {
+		return cpuset.New(), fmt.Errorf("allocating %d CPUs for %s failed: %w", count, bln, err)
+	}
+	p.freeCpus = p.freeCpus.Difference(newCpus)
+	bln.Cpus = bln.Cpus.Union(newCpus)
+	return newCpus, nil
+}
+
+// deflateCpus releases CPUs of a balloon to the free CPUs. It returns
+// the released CPUs.
+func (p *balloons) deflateCpus(bln *Balloon, count int) (cpuset.CPUSet, error) {
+	if count <= 0 {
+		return cpuset.New(), nil
+	}
+	_, removeFromCpus, err := bln.cpuTreeAllocator.ResizeCpus(bln.Cpus, p.freeCpus, -count)
+	if err != nil {
+		return cpuset.New(), fmt.Errorf("failed to choose a cpuset for releasing %d CPUs: %w", count, err)
+	}
+	log.Debugf("- releasing %d CPUs from cpuset %#s", count, removeFromCpus)
+	_, err = p.cpuAllocator.ReleaseCpus(&removeFromCpus, count, bln.Def.AllocatorPriority)
+	if err != nil {
+		return cpuset.New(), fmt.Errorf("releasing %d CPUs from %s failed: %w", count, bln, err)
+	}
+	log.Debugf("- old freeCpus: %#s, old bln.Cpus: %#s, releasing: %#s", p.freeCpus, bln.Cpus, removeFromCpus)
+	p.freeCpus = p.freeCpus.Union(removeFromCpus)
+	bln.Cpus = bln.Cpus.Difference(removeFromCpus)
+	return removeFromCpus, nil
 }
 
 func (p *balloons) updatePinning(blns ...*Balloon) {
 	for _, bln := range blns {
 		cpus := bln.Cpus.Union(bln.SharedIdleCpus)
-		bln.Mems = p.closestMems(cpus)
+		bln.Mems = p.balloonMems(bln.Def, cpus)
 		for _, cID := range bln.ContainerIDs() {
 			if c, ok := p.cch.LookupContainer(cID); ok {
-				p.pinCpuMem(c, cpus, bln.Mems)
+				p.pinCpuMem(c, cpus, p.containerMems(c, bln))
+				p.limitCpuQuota(c, bln.Def, cpus)
+			}
+		}
+	}
+	p.updatePartitions(blns...)
+	podIDs := []string{}
+	for _, bln := range blns {
+		for podID := range bln.PodIDs {
+			podIDs = append(podIDs, podID)
+		}
+	}
+	p.updatePodMems(podIDs...)
+}
+
+// podMems returns the union of the memory nodes of all balloons
+// with containers of a pod.
+func (p *balloons) podMems(podID string) idset.IDSet {
+	mems := idset.NewIDSet()
+	for _, bln := range p.balloons {
+		if _, ok := bln.PodIDs[podID]; ok {
+			mems.Add(bln.Mems.Members()...)
+		}
+	}
+	return mems
+}
+
+// containerMems returns the memory nodes for pinning a container in
+// a balloon.
+func (p *balloons) containerMems(c cache.Container, bln *Balloon) idset.IDSet {
+	if !bln.Def.SharePodMemoryNodes {
+		return bln.Mems
+	}
+	return p.podMems(c.GetPodID())
+}
+
+// updatePodMems re-pins the memory of containers of the pods in
+// balloons that share memory nodes within pods, after the balloons
+// of the pods or their memory nodes have changed.
+func (p *balloons) updatePodMems(podIDs ...string) {
+	if p.bpoptions.PinMemory != nil && !*p.bpoptions.PinMemory {
+		return
+	}
+	for _, podID := range podIDs {
+		for _, bln := range p.balloons {
+			if !bln.Def.SharePodMemoryNodes {
+				continue
+			}
+			for _, cID := range bln.PodIDs[podID] {
+				c, ok := p.cch.LookupContainer(cID)
+				if !ok {
+					continue
+				}
+				if mems := p.podMems(podID).String(); c.GetCpusetMems() != mems {
+					log.Debug("  - pinning %s to pod memory %s", c.PrettyName(), mems)
+					c.SetCpusetMems(mems)
+				}
 			}
 		}
 	}
@@ -1283,6 +2072,42 @@ func (p *balloons) assignContainer(c cache.Container, bln *Balloon) {
 	podID := c.GetPodID()
 	bln.PodIDs[podID] = append(bln.PodIDs[podID], c.GetCacheID())
 	p.updatePinning(bln)
+	if c.GetNetClass() != bln.Def.NetClass {
+		log.Debug("  - setting network class of %s to %q", c.PrettyName(), bln.Def.NetClass)
+		c.SetNetClass(bln.Def.NetClass)
+	}
+	p.setMemoryLow(c, bln.Def)
+	if p.bpoptions.AnnotateAssignments {
+		p.annotateAssignment(c, bln, time.Now())
+	}
+}
+
+// annotateAssignment records the balloon of a container and the time
+// and reason of the latest change of it in container annotations.
+func (p *balloons) annotateAssignment(c cache.Container, bln *Balloon, now time.Time) {
+	old, _ := c.GetAnnotation(assignedBalloonKey, nil)
+	reason, changed := balloonReassignment(old, bln.PrettyName(), c.GetState() == cache.ContainerStateCreating)
+	if !changed {
+		return
+	}
+	log.Debug("  - annotating %s: %s", c.PrettyName(), reason)
+	c.SetAnnotation(assignedBalloonKey, bln.PrettyName())
+	c.SetAnnotation(balloonReassignmentKey, now.UTC().Format(time.RFC3339)+" "+reason)
+}
+
+// balloonReassignment returns the reason of assigning a container,
+// previously in balloon from, to balloon to, and whether the
+// assignment changed at all.
+func balloonReassignment(from, to string, creating bool) (string, bool) {
+	switch {
+	case from == to:
+		return "", false
+	case from == "" && creating:
+		return "created in " + to, true
+	case from == "":
+		return "assigned to " + to, true
+	}
+	return "moved from " + from + " to " + to, true
 }
 
 // dismissContainer removes a container from a balloon
@@ -1291,7 +2116,57 @@ func (p *balloons) dismissContainer(c cache.Container, bln *Balloon) {
 	bln.PodIDs[podID] = removeString(bln.PodIDs[podID], c.GetCacheID())
 	if len(bln.PodIDs[podID]) == 0 {
 		delete(bln.PodIDs, podID)
+		p.updatePodMems(podID)
+	}
+	if c.GetNetClass() != "" {
+		log.Debug("  - resetting network class of %s", c.PrettyName())
+		c.SetNetClass("")
+	}
+	p.updatePartitions(bln)
+	p.restoreCpuQuota(c)
+	if value, ok := c.GetUnifiedResource(memoryLowKey); ok && value != "0" {
+		log.Debug("  - resetting memory.low of %s", c.PrettyName())
+		c.SetUnifiedResource(memoryLowKey, "0")
+	}
+}
+
+// setMemoryLow protects the memory of a container from reclaim by
+// setting its memory.low, if the balloon definition asks for it.
+func (p *balloons) setMemoryLow(c cache.Container, blnDef *BalloonDef) {
+	if blnDef.MemoryLow == "" {
+		return
+	}
+	var request int64
+	if reqMem, ok := c.GetResourceRequirements().Requests[corev1.ResourceMemory]; ok {
+		request = reqMem.Value()
+	}
+	low, err := memoryLow(blnDef.MemoryLow, request)
+	if err != nil {
+		log.Errorf("failed to set memory.low of %s: %v", c.PrettyName(), err)
+		return
+	}
+	log.Debug("  - setting memory.low of %s to %d", c.PrettyName(), low)
+	c.SetUnifiedResource(memoryLowKey, strconv.FormatInt(low, 10))
+}
+
+// memoryLow returns the memory.low in bytes for a container with the
+// given memory request, according to a MemoryLow setting.
+func memoryLow(setting string, request int64) (int64, error) {
+	switch setting {
+	case "":
+		return 0, nil
+	case memoryLowRequest:
+		return request, nil
+	}
+	qty, err := resapi.ParseQuantity(setting)
+	if err != nil {
+		return 0, balloonsError("invalid memory amount %q, expecting a quantity or %q: %w",
+			setting, memoryLowRequest, err)
 	}
+	if qty.Sign() < 0 {
+		return 0, balloonsError("negative memory amount %q", setting)
+	}
+	return qty.Value(), nil
 }
 
 // pinCpuMem pins container to CPUs and memory nodes if flagged
@@ -1310,6 +2185,63 @@ func (p *balloons) pinCpuMem(c cache.Container, cpus cpuset.CPUSet, mems idset.I
 	}
 }
 
+// limitCpuQuota sets the CFS quota of a container to its CPU request
+// if the balloon definition asks for it and the container is pinned to
+// more CPUs than it requests. Otherwise the quota given by the CPU limit
+// of the container is restored.
+func (p *balloons) limitCpuQuota(c cache.Container, blnDef *BalloonDef, cpus cpuset.CPUSet) {
+	reqMilli, limMilli := cpuRequestAndLimit(c)
+	if !blnDef.LimitCpuToRequest || (p.bpoptions.PinCPU != nil && !*p.bpoptions.PinCPU) {
+		reqMilli = 0
+	}
+	setCpuQuota(c, reqMilli, limMilli, cpus.Size())
+}
+
+// restoreCpuQuota restores the CFS quota given by the CPU limit of a
+// container leaving a balloon.
+func (p *balloons) restoreCpuQuota(c cache.Container) {
+	_, limMilli := cpuRequestAndLimit(c)
+	setCpuQuota(c, 0, limMilli, 0)
+}
+
+// cpuRequestAndLimit returns the CPU request and limit of a container.
+func cpuRequestAndLimit(c cache.Container) (int64, int64) {
+	resources := c.GetResourceRequirements()
+	var reqMilli, limMilli int64
+	if reqCpu, ok := resources.Requests[corev1.ResourceCPU]; ok {
+		reqMilli = reqCpu.MilliValue()
+	}
+	if limCpu, ok := resources.Limits[corev1.ResourceCPU]; ok {
+		limMilli = limCpu.MilliValue()
+	}
+	return reqMilli, limMilli
+}
+
+// setCpuQuota sets the CFS quota of a container, if it changes.
+func setCpuQuota(c cache.Container, reqMilli, limMilli int64, cpuCount int) {
+	quota, period := cpuQuota(reqMilli, limMilli, cpuCount)
+	if c.GetCPUQuota() == quota && c.GetCPUPeriod() == period {
+		return
+	}
+	log.Debug("  - setting CPU quota of %s to %d/%d", c.PrettyName(), quota, period)
+	c.SetCPUPeriod(period)
+	c.SetCPUQuota(quota)
+}
+
+// cpuQuota returns the CFS quota and period for a container with the
+// given CPU request and limit, pinned to the given number of CPUs. The
+// request is used if it is smaller than the pinned CPUs. Otherwise the
+// quota is given by the limit, or it is -1 (unlimited) without one.
+func cpuQuota(reqMilli, limMilli int64, cpuCount int) (int64, int64) {
+	if reqMilli > 0 && reqMilli < int64(cpuCount)*1000 {
+		return cache.MilliCPUToQuota(reqMilli)
+	}
+	if limMilli > 0 {
+		return cache.MilliCPUToQuota(limMilli)
+	}
+	return -1, int64(kubernetes.QuotaPeriod)
+}
+
 // balloonsError formats an error from this policy.
 func balloonsError(format string, args ...interface{}) error {
 	return fmt.Errorf(PolicyName+": "+format, args...)
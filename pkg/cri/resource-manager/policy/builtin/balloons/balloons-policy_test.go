@@ -15,7 +15,23 @@
 package balloons
 
 import (
+	"os"
+	"path/filepath"
+	"strconv"
 	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	resapi "k8s.io/apimachinery/pkg/api/resource"
+
+	pkgcfg "github.com/intel/cri-resource-manager/pkg/config"
+	"github.com/intel/cri-resource-manager/pkg/cpuallocator"
+	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/cache"
+	cpucontrol "github.com/intel/cri-resource-manager/pkg/cri/resource-manager/control/cpu"
+	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/introspect"
+	policyapi "github.com/intel/cri-resource-manager/pkg/cri/resource-manager/policy"
+	"github.com/intel/cri-resource-manager/pkg/utils/cpuset"
+	idset "github.com/intel/goresctrl/pkg/utils"
 )
 
 func TestChangesBalloons(t *testing.T) {
@@ -70,6 +86,17 @@ func TestChangesBalloons(t *testing.T) {
 			},
 			expectedValue: false,
 		},
+		{
+			name: "idle cpu class delays differ",
+			opts1: &BalloonsOptions{
+				IdleCpuClass:      "icc0",
+				IdleCpuClassDelay: pkgcfg.Duration(time.Second),
+			},
+			opts2: &BalloonsOptions{
+				IdleCpuClass: "icc0",
+			},
+			expectedValue: false,
+		},
 		{
 			name: "balloon defs differ",
 			opts1: &BalloonsOptions{
@@ -94,3 +121,1022 @@ func TestChangesBalloons(t *testing.T) {
 		})
 	}
 }
+
+func TestRemovedBalloonDefs(t *testing.T) {
+	newOpts := func(defNames ...string) *BalloonsOptions {
+		opts := &BalloonsOptions{IdleCpuClass: "icc0"}
+		for _, name := range defNames {
+			opts.BalloonDefs = append(opts.BalloonDefs, &BalloonDef{Name: name, MaxCpus: 4})
+		}
+		return opts
+	}
+	tcases := []struct {
+		name     string
+		opts1    *BalloonsOptions
+		opts2    *BalloonsOptions
+		expected []string
+	}{
+		{
+			name:  "one option is nil",
+			opts2: newOpts(),
+		},
+		{
+			name:  "nothing removed",
+			opts1: newOpts("a", "b"),
+			opts2: newOpts("a", "b"),
+		},
+		{
+			name:     "one def removed",
+			opts1:    newOpts("a", "b", "c"),
+			opts2:    newOpts("a", "c"),
+			expected: []string{"b"},
+		},
+		{
+			name:     "all defs removed",
+			opts1:    newOpts("a", "b"),
+			opts2:    newOpts(),
+			expected: []string{"a", "b"},
+		},
+		{
+			name:  "def removed and another changed",
+			opts1: newOpts("a", "b"),
+			opts2: &BalloonsOptions{
+				IdleCpuClass: "icc0",
+				BalloonDefs:  []*BalloonDef{{Name: "a", MaxCpus: 8}},
+			},
+		},
+		{
+			name:  "def removed and other options changed",
+			opts1: newOpts("a", "b"),
+			opts2: &BalloonsOptions{
+				IdleCpuClass: "icc1",
+				BalloonDefs:  []*BalloonDef{{Name: "a", MaxCpus: 4}},
+			},
+		},
+		{
+			name:  "built-in def customization removed",
+			opts1: newOpts("default", "a"),
+			opts2: newOpts("a"),
+		},
+	}
+	for _, tc := range tcases {
+		t.Run(tc.name, func(t *testing.T) {
+			removed := removedBalloonDefs(tc.opts1, tc.opts2)
+			if len(removed) != len(tc.expected) {
+				t.Fatalf("expected removed %v, got %v", tc.expected, removed)
+			}
+			for i := range removed {
+				if removed[i] != tc.expected[i] {
+					t.Errorf("expected removed %v, got %v", tc.expected, removed)
+				}
+			}
+		})
+	}
+}
+
+func TestDrainInvalidConfig(t *testing.T) {
+	defer func(saved *BalloonsOptions) { balloonsOptions = saved }(balloonsOptions)
+	p := &balloons{
+		bpoptions: BalloonsOptions{
+			DaemonSetBalloon: "agents",
+			BalloonDefs:      []*BalloonDef{{Name: "a"}, {Name: "agents"}},
+		},
+	}
+	// Removing the DaemonSetBalloon type is not just a removal.
+	balloonsOptions = &BalloonsOptions{
+		DaemonSetBalloon: "agents",
+		BalloonDefs:      []*BalloonDef{{Name: "a"}},
+	}
+	if err := p.configNotify(pkgcfg.UpdateEvent, pkgcfg.ConfigFile); err == nil {
+		t.Errorf("expected an error for DaemonSetBalloon of a removed balloon type")
+	}
+	if len(p.bpoptions.BalloonDefs) != 2 {
+		t.Errorf("expected balloon types to be kept, got %d", len(p.bpoptions.BalloonDefs))
+	}
+}
+
+func TestPackedBalloon(t *testing.T) {
+	newBalloon := func(instance int, pods int) *Balloon {
+		bln := &Balloon{Def: &BalloonDef{Name: "pack"}, Instance: instance, PodIDs: map[string][]string{}}
+		for i := 0; i < pods; i++ {
+			bln.PodIDs[string(rune('a'+i))] = []string{"c"}
+		}
+		return bln
+	}
+	blns := []*Balloon{newBalloon(0, 0), newBalloon(1, 2), newBalloon(2, 1), newBalloon(3, 3)}
+	free := map[*Balloon]int{
+		blns[0]: 100,
+		blns[1]: 4000,
+		blns[2]: 1500,
+		blns[3]: 500,
+	}
+	freeOf := func(bln *Balloon) int { return free[bln] }
+
+	p := &balloons{}
+	tcases := []struct {
+		name     string
+		request  int
+		expected int
+	}{
+		{name: "tightest fit", request: 400, expected: 3},
+		{name: "skip too small", request: 1000, expected: 2},
+		{name: "only largest fits", request: 3000, expected: 1},
+		{name: "nothing fits", request: 5000, expected: -1},
+	}
+	for _, tc := range tcases {
+		t.Run(tc.name, func(t *testing.T) {
+			bln := p.packedBalloon(blns, tc.request, freeOf)
+			switch {
+			case tc.expected < 0 && bln != nil:
+				t.Errorf("expected no balloon, got instance %d", bln.Instance)
+			case tc.expected >= 0 && bln == nil:
+				t.Errorf("expected instance %d, got none", tc.expected)
+			case tc.expected >= 0 && bln.Instance != tc.expected:
+				t.Errorf("expected instance %d, got %d", tc.expected, bln.Instance)
+			}
+		})
+	}
+}
+
+func TestCpuQuota(t *testing.T) {
+	tcases := []struct {
+		name          string
+		reqMilli      int64
+		limMilli      int64
+		cpuCount      int
+		expectedQuota int64
+	}{
+		{
+			name:          "request smaller than balloon",
+			reqMilli:      1500,
+			limMilli:      4000,
+			cpuCount:      4,
+			expectedQuota: 150000,
+		},
+		{
+			name:          "request fills the balloon",
+			reqMilli:      4000,
+			limMilli:      4000,
+			cpuCount:      4,
+			expectedQuota: 400000,
+		},
+		{
+			name:          "request fills the balloon, no limit",
+			reqMilli:      4000,
+			cpuCount:      4,
+			expectedQuota: -1,
+		},
+		{
+			name:          "no request",
+			cpuCount:      4,
+			expectedQuota: -1,
+		},
+	}
+	for _, tc := range tcases {
+		t.Run(tc.name, func(t *testing.T) {
+			quota, period := cpuQuota(tc.reqMilli, tc.limMilli, tc.cpuCount)
+			if quota != tc.expectedQuota {
+				t.Errorf("expected quota %d, got %d", tc.expectedQuota, quota)
+			}
+			if period != 100000 {
+				t.Errorf("expected period 100000, got %d", period)
+			}
+		})
+	}
+}
+
+func TestLimitCpuQuota(t *testing.T) {
+	p := &balloons{}
+	c := &mockContainer{
+		resources: corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{corev1.ResourceCPU: resapi.MustParse("1")},
+			Limits:   corev1.ResourceList{corev1.ResourceCPU: resapi.MustParse("2")},
+		},
+	}
+	cpus := cpuset.MustParse("0-3")
+
+	p.limitCpuQuota(c, &BalloonDef{LimitCpuToRequest: true}, cpus)
+	if c.cpuQuota != 100000 {
+		t.Errorf("expected quota of the request 100000, got %d", c.cpuQuota)
+	}
+	p.limitCpuQuota(c, &BalloonDef{}, cpus)
+	if c.cpuQuota != 200000 {
+		t.Errorf("expected quota of the limit 200000 without limiting to the request, got %d", c.cpuQuota)
+	}
+	p.limitCpuQuota(c, &BalloonDef{LimitCpuToRequest: true}, cpus)
+	p.restoreCpuQuota(c)
+	if c.cpuQuota != 200000 {
+		t.Errorf("expected quota of the limit 200000 after leaving the balloon, got %d", c.cpuQuota)
+	}
+}
+
+func TestValidateNetClass(t *testing.T) {
+	tcases := []struct {
+		netClass    string
+		expectError bool
+	}{
+		{netClass: ""},
+		{netClass: "10:1"},
+		{netClass: "ffff:ffff"},
+		{netClass: "10", expectError: true},
+		{netClass: "10:zz", expectError: true},
+		{netClass: "10000:1", expectError: true},
+	}
+	p := &balloons{}
+	for _, tc := range tcases {
+		t.Run("NetClass "+tc.netClass, func(t *testing.T) {
+			opts := &BalloonsOptions{
+				BalloonDefs: []*BalloonDef{{Name: "net", NetClass: tc.netClass}},
+			}
+			err := p.validateConfig(opts)
+			if tc.expectError && err == nil {
+				t.Errorf("expected an error for NetClass %q", tc.netClass)
+			}
+			if !tc.expectError && err != nil {
+				t.Errorf("unexpected error for NetClass %q: %v", tc.netClass, err)
+			}
+		})
+	}
+}
+
+func TestReadMemoryPressure(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "memory")
+	content := "some avg10=12.50 avg60=3.00 avg300=1.00 total=12345\n" +
+		"full avg10=2.00 avg60=1.00 avg300=0.50 total=2345\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+	pressure, err := readMemoryPressure(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if pressure != 12.5 {
+		t.Errorf("expected pressure 12.5, got %v", pressure)
+	}
+}
+
+func TestReadMemoryRecursiveProt(t *testing.T) {
+	dir := t.TempDir()
+	tcases := []struct {
+		name        string
+		mounts      string
+		expected    bool
+		expectError bool
+	}{
+		{
+			name: "memory_recursiveprot",
+			mounts: "proc /proc proc rw,nosuid,nodev,noexec,relatime 0 0\n" +
+				"cgroup2 /sys/fs/cgroup cgroup2 rw,nosuid,nodev,noexec,relatime,nsdelegate,memory_recursiveprot 0 0\n",
+			expected: true,
+		},
+		{
+			name:   "no memory_recursiveprot",
+			mounts: "cgroup2 /sys/fs/cgroup cgroup2 rw,nosuid,nodev,noexec,relatime,nsdelegate 0 0\n",
+		},
+		{
+			name:        "no cgroup v2",
+			mounts:      "cgroup /sys/fs/cgroup/memory cgroup rw,nosuid,nodev,noexec,relatime,memory 0 0\n",
+			expectError: true,
+		},
+	}
+	for i, tc := range tcases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(dir, strconv.Itoa(i))
+			if err := os.WriteFile(path, []byte(tc.mounts), 0644); err != nil {
+				t.Fatalf("failed to write %s: %v", path, err)
+			}
+			recursiveProt, err := readMemoryRecursiveProt(path)
+			if (err != nil) != tc.expectError {
+				t.Fatalf("expected error %v, got %v", tc.expectError, err)
+			}
+			if recursiveProt != tc.expected {
+				t.Errorf("expected memory_recursiveprot %v, got %v", tc.expected, recursiveProt)
+			}
+		})
+	}
+}
+
+func TestPressuredNodes(t *testing.T) {
+	tcases := []struct {
+		name         string
+		freeFraction map[idset.ID]float64
+		expected     []idset.ID
+	}{
+		{
+			name:         "single node is never deprioritized",
+			freeFraction: map[idset.ID]float64{0: 0.01},
+		},
+		{
+			name:         "equal nodes",
+			freeFraction: map[idset.ID]float64{0: 0.5, 1: 0.5},
+		},
+		{
+			name:         "one node below average",
+			freeFraction: map[idset.ID]float64{0: 0.05, 1: 0.6, 2: 0.7},
+			expected:     []idset.ID{0},
+		},
+		{
+			name:         "two nodes below average",
+			freeFraction: map[idset.ID]float64{0: 0.1, 1: 0.9, 2: 0.1, 3: 0.9},
+			expected:     []idset.ID{0, 2},
+		},
+	}
+	for _, tc := range tcases {
+		t.Run(tc.name, func(t *testing.T) {
+			nodes := pressuredNodes(tc.freeFraction)
+			if nodes.String() != idset.NewIDSet(tc.expected...).String() {
+				t.Errorf("expected pressured nodes %v, got %v", tc.expected, nodes)
+			}
+		})
+	}
+}
+
+func TestPreferUnpressured(t *testing.T) {
+	bln0 := &Balloon{Instance: 0, Mems: idset.NewIDSet(0)}
+	bln1 := &Balloon{Instance: 1, Mems: idset.NewIDSet(1)}
+	bln01 := &Balloon{Instance: 2, Mems: idset.NewIDSet(0, 1)}
+	tcases := []struct {
+		name      string
+		pressured idset.IDSet
+		balloons  []*Balloon
+		expected  []*Balloon
+	}{
+		{
+			name:     "no pressure",
+			balloons: []*Balloon{bln0, bln1},
+			expected: []*Balloon{bln0, bln1},
+		},
+		{
+			name:      "skip pressured node",
+			pressured: idset.NewIDSet(0),
+			balloons:  []*Balloon{bln0, bln1, bln01},
+			expected:  []*Balloon{bln1, bln01},
+		},
+		{
+			name:      "keep all if all are pressured",
+			pressured: idset.NewIDSet(0, 1),
+			balloons:  []*Balloon{bln0, bln1},
+			expected:  []*Balloon{bln0, bln1},
+		},
+	}
+	for _, tc := range tcases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := &balloons{pressuredMems: tc.pressured}
+			got := p.preferUnpressured(tc.balloons)
+			if len(got) != len(tc.expected) {
+				t.Fatalf("expected %d balloons, got %d", len(tc.expected), len(got))
+			}
+			for i := range got {
+				if got[i] != tc.expected[i] {
+					t.Errorf("expected balloon %d at %d, got %d", tc.expected[i].Instance, i, got[i].Instance)
+				}
+			}
+		})
+	}
+}
+
+func TestUpdateMemoryPressure(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "memory")
+	defer func(saved string) { procPressureMemory = saved }(procPressureMemory)
+	procPressureMemory = path
+	setPressure := func(pressure string) {
+		content := "some avg10=" + pressure + " avg60=0.00 avg300=0.00 total=0\n"
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", path, err)
+		}
+	}
+
+	// Node 2 has no CPUs and little free memory, but it is never
+	// deprioritized and does not skew the average of the others.
+	sys := &mockSystem{nodes: map[idset.ID]*mockSystemNode{
+		0: {cpus: cpuset.New(0, 1), memFree: 10},
+		1: {cpus: cpuset.New(2, 3), memFree: 30},
+		2: {cpus: cpuset.New(), memFree: 1},
+	}}
+	p := &balloons{
+		options:   &policyapi.BackendOptions{System: sys},
+		bpoptions: BalloonsOptions{MemoryPressureThreshold: 20},
+	}
+	now := time.Now()
+
+	setPressure("25.00")
+	if !p.updateMemoryPressure(now) {
+		t.Fatalf("expected pressured nodes to change")
+	}
+	if p.pressuredMems.String() != idset.NewIDSet(0).String() {
+		t.Errorf("expected node 0 to be pressured, got %s", p.pressuredMems)
+	}
+	if !p.memPressureActive.Load() {
+		t.Errorf("expected memory pressure to be active")
+	}
+
+	setPressure("5.00")
+	if p.updateMemoryPressure(now.Add(time.Second)) {
+		t.Errorf("expected no check within %s of the previous one", memoryPressureCheckInterval)
+	}
+	if !p.updateMemoryPressure(now.Add(memoryPressureCheckInterval)) {
+		t.Fatalf("expected pressured nodes to change when pressure subsides")
+	}
+	if p.pressuredMems.Size() != 0 {
+		t.Errorf("expected no pressured nodes, got %s", p.pressuredMems)
+	}
+	if p.memPressureActive.Load() {
+		t.Errorf("expected memory pressure to be inactive")
+	}
+	if p.updateMemoryPressure(now.Add(2 * memoryPressureCheckInterval)) {
+		t.Errorf("expected no change without pressure")
+	}
+}
+
+func TestBalloonReassignment(t *testing.T) {
+	tcases := []struct {
+		from           string
+		to             string
+		creating       bool
+		expectedReason string
+		expectedChange bool
+	}{
+		{from: "", to: "full-core[0]", creating: true, expectedReason: "created in full-core[0]", expectedChange: true},
+		{from: "", to: "full-core[0]", expectedReason: "assigned to full-core[0]", expectedChange: true},
+		{from: "full-core[0]", to: "full-core[0]"},
+		{from: "full-core[0]", to: "default[0]", expectedReason: "moved from full-core[0] to default[0]", expectedChange: true},
+	}
+	for _, tc := range tcases {
+		t.Run(tc.from+"->"+tc.to, func(t *testing.T) {
+			reason, changed := balloonReassignment(tc.from, tc.to, tc.creating)
+			if reason != tc.expectedReason || changed != tc.expectedChange {
+				t.Errorf("expected (%q, %v), got (%q, %v)", tc.expectedReason, tc.expectedChange, reason, changed)
+			}
+		})
+	}
+}
+
+func TestReadKubeletExclusiveCpus(t *testing.T) {
+	tcases := []struct {
+		name          string
+		content       string
+		expected      string
+		expectedError bool
+	}{
+		{
+			name:     "static policy",
+			content:  `{"policyName":"static","defaultCpuSet":"0-1,6-7","entries":{"pod1":{"ctr1":"2-3"},"pod2":{"ctr2":"4","ctr3":"5"}},"checksum":1}`,
+			expected: "2-5",
+		},
+		{
+			name:    "static policy without assignments",
+			content: `{"policyName":"static","defaultCpuSet":"0-7","checksum":1}`,
+		},
+		{
+			name:    "none policy",
+			content: `{"policyName":"none","defaultCpuSet":"","checksum":1}`,
+		},
+		{
+			name:          "invalid cpuset",
+			content:       `{"policyName":"static","defaultCpuSet":"0-1","entries":{"pod1":{"ctr1":"x"}},"checksum":1}`,
+			expectedError: true,
+		},
+		{
+			name:          "invalid content",
+			content:       `{"policyName":`,
+			expectedError: true,
+		},
+	}
+	for _, tc := range tcases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "cpu_manager_state")
+			if err := os.WriteFile(path, []byte(tc.content), 0644); err != nil {
+				t.Fatalf("failed to write %s: %v", path, err)
+			}
+			cpus, err := readKubeletExclusiveCpus(path)
+			if tc.expectedError {
+				if err == nil {
+					t.Errorf("expected error, got CPUs %q", cpus)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if cpus.String() != tc.expected {
+				t.Errorf("expected CPUs %q, got %q", tc.expected, cpus)
+			}
+		})
+	}
+}
+
+func TestUpdateKubeletCpus(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cpu_manager_state")
+	writeState := func(entries string) {
+		content := `{"policyName":"static","defaultCpuSet":"0-7","entries":{` + entries + `},"checksum":1}`
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", path, err)
+		}
+	}
+
+	p := &balloons{
+		bpoptions: BalloonsOptions{KubeletCPUManagerState: path},
+		allowed:   cpuset.MustParse("0-7"),
+		reserved:  cpuset.MustParse("0"),
+		freeCpus:  cpuset.MustParse("3-7"),
+		balloons: []*Balloon{
+			{Cpus: cpuset.MustParse("1-2")},
+		},
+	}
+
+	writeState(`"pod1":{"ctr1":"2-4"},"pod2":{"ctr2":"0"}`)
+	p.updateKubeletCpus()
+	if p.kubeletCpus.String() != "2-4" {
+		t.Errorf("expected kubelet CPUs 2-4, got %q", p.kubeletCpus)
+	}
+	if p.freeCpus.String() != "5-7" {
+		t.Errorf("expected free CPUs 5-7, got %q", p.freeCpus)
+	}
+
+	writeState(`"pod2":{"ctr2":"0"}`)
+	p.updateKubeletCpus()
+	if !p.kubeletCpus.IsEmpty() {
+		t.Errorf("expected no kubelet CPUs, got %q", p.kubeletCpus)
+	}
+	if p.freeCpus.String() != "3-7" {
+		t.Errorf("expected free CPUs 3-7, got %q", p.freeCpus)
+	}
+}
+
+func TestMemoryLow(t *testing.T) {
+	tcases := []struct {
+		name          string
+		setting       string
+		request       int64
+		expected      int64
+		expectedError bool
+	}{
+		{
+			name:    "not set",
+			request: 1 << 30,
+		},
+		{
+			name:     "fixed amount",
+			setting:  "512Mi",
+			request:  1 << 30,
+			expected: 512 << 20,
+		},
+		{
+			name:     "memory request",
+			setting:  "request",
+			request:  1 << 30,
+			expected: 1 << 30,
+		},
+		{
+			name:    "memory request without request",
+			setting: "request",
+		},
+		{
+			name:          "invalid amount",
+			setting:       "lots",
+			expectedError: true,
+		},
+		{
+			name:          "negative amount",
+			setting:       "-1Gi",
+			expectedError: true,
+		},
+	}
+	for _, tc := range tcases {
+		t.Run(tc.name, func(t *testing.T) {
+			low, err := memoryLow(tc.setting, tc.request)
+			if tc.expectedError {
+				if err == nil {
+					t.Errorf("expected error, got %d", low)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if low != tc.expected {
+				t.Errorf("expected memory.low %d, got %d", tc.expected, low)
+			}
+		})
+	}
+}
+
+func TestPodMems(t *testing.T) {
+	newBalloon := func(mems []idset.ID, pods ...string) *Balloon {
+		bln := &Balloon{Def: &BalloonDef{Name: "spread"}, Mems: idset.NewIDSet(mems...), PodIDs: map[string][]string{}}
+		for _, pod := range pods {
+			bln.PodIDs[pod] = []string{pod + "-ctr"}
+		}
+		return bln
+	}
+	p := &balloons{
+		balloons: []*Balloon{
+			newBalloon([]idset.ID{0}, "pod1", "pod2"),
+			newBalloon([]idset.ID{1}, "pod1"),
+			newBalloon([]idset.ID{2, 3}, "pod1", "pod3"),
+		},
+	}
+	for pod, expected := range map[string]string{
+		"pod1": "0,1,2,3",
+		"pod2": "0",
+		"pod3": "2,3",
+		"pod4": "",
+	} {
+		if mems := p.podMems(pod).String(); mems != expected {
+			t.Errorf("expected memory nodes %q for %s, got %q", expected, pod, mems)
+		}
+	}
+}
+
+func TestValidateMaxSpreadingPodMemory(t *testing.T) {
+	p := &balloons{}
+	for value, expectError := range map[string]bool{
+		"":    false,
+		"4Gi": false,
+		"4G":  false,
+		"big": true,
+	} {
+		opts := &BalloonsOptions{
+			BalloonDefs: []*BalloonDef{{Name: "spread", PreferSpreadingPods: true, MaxSpreadingPodMemory: value}},
+		}
+		err := p.validateConfig(opts)
+		if expectError && err == nil {
+			t.Errorf("expected an error for MaxSpreadingPodMemory %q", value)
+		}
+		if !expectError && err != nil {
+			t.Errorf("unexpected error for MaxSpreadingPodMemory %q: %v", value, err)
+		}
+	}
+}
+
+func TestOverflowMethod(t *testing.T) {
+	tcases := []struct {
+		method      OverflowMethod
+		expected    FillMethod
+		expectError bool
+	}{
+		{method: "", expected: FillUnspecified},
+		{method: OverflowReject, expected: FillUnspecified},
+		{method: OverflowShare, expected: FillOverflowShare},
+		{method: OverflowNewBalloon, expected: FillOverflowNewBalloon},
+		{method: "newBalloon", expectError: true},
+	}
+	p := &balloons{}
+	for _, tc := range tcases {
+		t.Run("OverflowMethod "+string(tc.method), func(t *testing.T) {
+			fm, err := tc.method.fillMethod()
+			if tc.expectError {
+				if err == nil {
+					t.Errorf("expected an error, got fill method %s", fm)
+				}
+			} else if err != nil || fm != tc.expected {
+				t.Errorf("expected fill method %s, got %s (error %v)", tc.expected, fm, err)
+			}
+			opts := &BalloonsOptions{
+				BalloonDefs: []*BalloonDef{{Name: "overflow", OverflowMethod: tc.method}},
+			}
+			if err := p.validateConfig(opts); (err != nil) != tc.expectError {
+				t.Errorf("unexpected validation result %v", err)
+			}
+		})
+	}
+}
+
+func TestNodesWithinDistance(t *testing.T) {
+	// Nodes 0 and 1 have CPUs in separate sockets, nodes 2 and 3
+	// are HBM nodes close to nodes 0 and 1, respectively.
+	distances := [][]int{
+		{10, 21, 13, 23},
+		{21, 10, 23, 13},
+		{13, 23, 10, 23},
+		{23, 13, 23, 10},
+	}
+	distance := func(from, to idset.ID) int { return distances[from][to] }
+	nodeIDs := []idset.ID{0, 1, 2, 3}
+
+	tcases := []struct {
+		name     string
+		local    []idset.ID
+		limit    int
+		expected string
+	}{
+		{name: "local node only", local: []idset.ID{0}, limit: 10, expected: "0"},
+		{name: "nearby HBM", local: []idset.ID{0}, limit: 13, expected: "0,2"},
+		{name: "nearby HBM of both sockets", local: []idset.ID{0, 1}, limit: 13, expected: "0,1,2,3"},
+		{name: "remote socket", local: []idset.ID{1}, limit: 21, expected: "0,1,3"},
+	}
+	for _, tc := range tcases {
+		t.Run(tc.name, func(t *testing.T) {
+			nodes := nodesWithinDistance(idset.NewIDSet(tc.local...), nodeIDs, distance, tc.limit)
+			if nodes.String() != tc.expected {
+				t.Errorf("expected nodes %q, got %q", tc.expected, nodes)
+			}
+		})
+	}
+}
+
+func TestCpusetPartition(t *testing.T) {
+	yes, no := true, false
+	tcases := []struct {
+		name     string
+		pinCpu   *bool
+		def      BalloonDef
+		expected string
+	}{
+		{name: "regular balloon"},
+		{name: "load balancing", def: BalloonDef{LoadBalancing: &yes}},
+		{name: "exclusive", def: BalloonDef{Exclusive: true}, expected: cpucontrol.PartitionRoot},
+		{name: "no load balancing", def: BalloonDef{LoadBalancing: &no}, expected: cpucontrol.PartitionIsolated},
+		{name: "exclusive without load balancing", def: BalloonDef{Exclusive: true, LoadBalancing: &no}, expected: cpucontrol.PartitionIsolated},
+		{name: "no CPU pinning", pinCpu: &no, def: BalloonDef{Exclusive: true, LoadBalancing: &no}},
+	}
+	for _, tc := range tcases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := &balloons{bpoptions: BalloonsOptions{PinCPU: tc.pinCpu}}
+			if partition := p.cpusetPartition(&tc.def); partition != tc.expected {
+				t.Errorf("expected partition %q, got %q", tc.expected, partition)
+			}
+		})
+	}
+}
+
+func TestRebalance(t *testing.T) {
+	cch := &mockCache{containers: map[string]cache.Container{}}
+	tree, csit := newCpuTreeFromInt5([5]int{2, 1, 1, 4, 2})
+	allocator := tree.NewAllocator(cpuTreeAllocatorOptions{})
+	p := &balloons{
+		cch:          cch,
+		cpuTree:      tree,
+		cpuAllocator: cpuallocator.NewCPUAllocator(nil),
+		// CPUs 2-4 are used by the reserved balloon with fixed CPUs.
+		reserved:         cpuset.New(2, 3, 4),
+		freeCpus:         cpuset.New(8, 9, 10, 11, 12, 13, 14, 15),
+		idleCpuDeadlines: map[int]time.Time{},
+	}
+	p.reservedBalloonDef = &BalloonDef{Name: reservedBalloonDefName, MemoryNodes: []int{0}}
+	newBalloon := func(name string, cpus cpuset.CPUSet, cpuRequest string) *Balloon {
+		bln := &Balloon{
+			Def:              &BalloonDef{Name: name, MaxCpus: NoLimit, MemoryNodes: []int{0}},
+			Cpus:             cpus,
+			PodIDs:           map[string][]string{},
+			cpuTreeAllocator: allocator,
+		}
+		if cpuRequest != "" {
+			id := name + "-container"
+			bln.PodIDs[name+"-pod"] = []string{id}
+			cch.containers[id] = &mockContainer{
+				name: id,
+				resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceCPU: resapi.MustParse(cpuRequest)},
+				},
+			}
+		}
+		return bln
+	}
+	reserved := &Balloon{Def: p.reservedBalloonDef, Cpus: p.reserved, PodIDs: map[string][]string{}}
+	// blnA is over-provisioned and spread over both packages.
+	blnA := newBalloon("a", cpuset.New(0, 1, 5, 6, 7), "3")
+	blnB := newBalloon("b", cpuset.New(), "")
+	p.balloons = []*Balloon{reserved, blnA, blnB}
+	allCpus := p.freeCpus.Union(blnA.Cpus)
+
+	changed, err := p.Rebalance()
+	if err != nil || !changed {
+		t.Fatalf("expected balloons to be rebalanced, got changed %v, error %v", changed, err)
+	}
+	if !reserved.Cpus.Equals(p.reserved) {
+		t.Errorf("expected reserved balloon CPUs %s to stay, got %s", p.reserved, reserved.Cpus)
+	}
+	if blnA.Cpus.Size() != 3 {
+		t.Errorf("expected %s to be deflated to 3 CPUs, got %s", blnA.PrettyName(), blnA.Cpus)
+	}
+	verifySame(t, "package", blnA.Cpus, csit)
+	if c, _ := cch.LookupContainer("a-container"); c.GetCpusetCpus() != blnA.Cpus.String() {
+		t.Errorf("expected container to be pinned to %s, got %q", blnA.Cpus, c.GetCpusetCpus())
+	}
+	if !blnB.Cpus.IsEmpty() {
+		t.Errorf("expected no CPUs for empty %s, got %s", blnB.PrettyName(), blnB.Cpus)
+	}
+	if !p.freeCpus.Union(blnA.Cpus).Equals(allCpus) || !p.freeCpus.Intersection(blnA.Cpus).IsEmpty() {
+		t.Errorf("expected CPUs %s to be split between %s (%s) and free CPUs (%s)",
+			allCpus, blnA.PrettyName(), blnA.Cpus, p.freeCpus)
+	}
+
+	cpus, freeCpus := blnA.Cpus, p.freeCpus
+	if changed, err := p.Rebalance(); err != nil || changed {
+		t.Errorf("expected rebalancing again to be a no-op, got changed %v, error %v", changed, err)
+	}
+	if !blnA.Cpus.Equals(cpus) || !p.freeCpus.Equals(freeCpus) {
+		t.Errorf("expected unchanged CPUs %s and free CPUs %s, got %s and %s",
+			cpus, freeCpus, blnA.Cpus, p.freeCpus)
+	}
+}
+
+func TestWantsDedicatedBalloon(t *testing.T) {
+	tcases := []struct {
+		name        string
+		annotations map[string]string
+		expected    bool
+		expectedErr bool
+	}{
+		{name: "no annotations"},
+		{name: "dedicated", annotations: map[string]string{dedicatedBalloonKey: "true"}, expected: true},
+		{name: "not dedicated", annotations: map[string]string{dedicatedBalloonKey: "false"}},
+		{name: "invalid value", annotations: map[string]string{dedicatedBalloonKey: "yes please"}, expectedErr: true},
+		{name: "named balloon", annotations: map[string]string{balloonKey: "special"}},
+		{
+			name:        "dedicated named balloon",
+			annotations: map[string]string{dedicatedBalloonKey: "true", balloonKey: "special"},
+			expectedErr: true,
+		},
+		{
+			name:        "not dedicated named balloon",
+			annotations: map[string]string{dedicatedBalloonKey: "false", balloonKey: "special"},
+		},
+	}
+	for _, tc := range tcases {
+		t.Run(tc.name, func(t *testing.T) {
+			dedicated, err := wantsDedicatedBalloon(&mockContainer{annotations: tc.annotations})
+			if (err != nil) != tc.expectedErr {
+				t.Fatalf("expected error %v, got %v", tc.expectedErr, err)
+			}
+			if dedicated != tc.expected {
+				t.Errorf("expected dedicated %v, got %v", tc.expected, dedicated)
+			}
+		})
+	}
+	blns := []*Balloon{{Instance: 0}, {Instance: 1, Dedicated: true}, {Instance: 2}}
+	if assignable := assignableBalloons(blns); len(assignable) != 2 || assignable[0] != blns[0] || assignable[1] != blns[2] {
+		t.Errorf("expected dedicated balloon to be skipped, got %d balloons", len(assignable))
+	}
+}
+
+func TestIsolatedPartition(t *testing.T) {
+	no := false
+	def := &BalloonDef{Name: "isolated", LoadBalancing: &no}
+	p := &balloons{
+		cch: &mockCache{pods: map[string]cache.Pod{
+			"pod-a": &mockPod{id: "pod-a"},
+			"pod-b": &mockPod{id: "pod-b"},
+		}},
+	}
+	if partition := p.cpusetPartition(def); partition != cpucontrol.PartitionIsolated {
+		t.Fatalf("expected partition %q, got %q", cpucontrol.PartitionIsolated, partition)
+	}
+
+	blnA := &Balloon{Def: def, PodIDs: map[string][]string{"pod-a": {"a0", "a1"}}}
+	blnB := &Balloon{Def: def, Instance: 1, PodIDs: map[string][]string{}}
+	p.balloons = []*Balloon{blnA, blnB}
+	tcases := []struct {
+		name     string
+		podIDs   map[string][]string
+		expected string
+	}{
+		{name: "empty balloon"},
+		{name: "single pod", podIDs: map[string][]string{"pod-b": {"b0"}}, expected: "pod-b"},
+		{name: "two pods", podIDs: map[string][]string{"pod-b": {"b0"}, "pod-c": {"c0"}}},
+		{name: "pod split across balloons", podIDs: map[string][]string{"pod-a": {"a2"}}},
+		{name: "unknown pod", podIDs: map[string][]string{"pod-c": {"c0"}}},
+	}
+	for _, tc := range tcases {
+		t.Run(tc.name, func(t *testing.T) {
+			blnB.PodIDs = tc.podIDs
+			pod, reason := p.partitionPod(blnB)
+			switch {
+			case tc.expected == "" && pod != nil:
+				t.Errorf("expected no pod to isolate, got %s", pod.GetID())
+			case tc.expected != "" && (pod == nil || pod.GetID() != tc.expected):
+				t.Errorf("expected pod %s to isolate, got %v (%s)", tc.expected, pod, reason)
+			case pod == nil && len(tc.podIDs) > 0 && reason == "":
+				t.Errorf("expected a reason for not isolating the balloon")
+			}
+		})
+	}
+
+}
+
+func TestPodMemoryRequest(t *testing.T) {
+	memory := func(value string) corev1.ResourceRequirements {
+		return corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{corev1.ResourceMemory: resapi.MustParse(value)},
+		}
+	}
+	p := &balloons{}
+	tcases := []struct {
+		name     string
+		pod      *mockPod
+		expected string
+	}{
+		{
+			name: "all containers of the pod",
+			pod: &mockPod{resources: cache.PodResourceRequirements{
+				Containers: map[string]corev1.ResourceRequirements{"a": memory("1G"), "b": memory("2G")},
+			}},
+			expected: "3G",
+		},
+		{
+			name: "larger init container",
+			pod: &mockPod{resources: cache.PodResourceRequirements{
+				InitContainers: map[string]corev1.ResourceRequirements{"init": memory("4G")},
+				Containers:     map[string]corev1.ResourceRequirements{"a": memory("1G"), "b": memory("2G")},
+			}},
+			expected: "4G",
+		},
+		{
+			name: "smaller init container",
+			pod: &mockPod{resources: cache.PodResourceRequirements{
+				InitContainers: map[string]corev1.ResourceRequirements{"init": memory("1G")},
+				Containers:     map[string]corev1.ResourceRequirements{"a": memory("1G"), "b": memory("2G")},
+			}},
+			expected: "3G",
+		},
+		{
+			name: "no pod resource requirements",
+			pod: &mockPod{containers: []cache.Container{
+				&mockContainer{resources: memory("1G")},
+				&mockContainer{resources: memory("1G")},
+			}},
+			expected: "2G",
+		},
+	}
+	for _, tc := range tcases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := &mockContainer{pod: tc.pod, resources: memory("1G")}
+			expected := resapi.MustParse(tc.expected)
+			if req := p.getPodMemoryRequest(c); req != expected.Value() {
+				t.Errorf("expected pod memory request %d, got %d", expected.Value(), req)
+			}
+		})
+	}
+}
+
+func TestChooseDaemonSetBalloon(t *testing.T) {
+	reservedDef := &BalloonDef{Name: "reserved"}
+	defaultDef := &BalloonDef{Name: "default"}
+	agentDef := &BalloonDef{Name: "agents"}
+	p := &balloons{
+		bpoptions: BalloonsOptions{
+			DaemonSetBalloon:       "agents",
+			ReservedPoolNamespaces: []string{"monitoring"},
+			BalloonDefs:            []*BalloonDef{agentDef},
+		},
+		reservedBalloonDef: reservedDef,
+		defaultBalloonDef:  defaultDef,
+		balloons:           []*Balloon{{Def: reservedDef}, {Def: defaultDef}},
+	}
+	daemonSet := &mockPod{labels: map[string]string{daemonSetGenerationKey: "1"}}
+	tcases := []struct {
+		name      string
+		namespace string
+		pod       cache.Pod
+		expected  *BalloonDef
+	}{
+		{name: "DaemonSet pod", namespace: "default", pod: daemonSet, expected: agentDef},
+		{name: "regular pod", namespace: "default", pod: &mockPod{}, expected: defaultDef},
+		{name: "kube-system DaemonSet pod", namespace: "kube-system", pod: daemonSet, expected: reservedDef},
+		{name: "reserved namespace DaemonSet pod", namespace: "monitoring", pod: daemonSet, expected: reservedDef},
+	}
+	for _, tc := range tcases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := &mockContainer{namespace: tc.namespace, pod: tc.pod}
+			blnDef, err := p.chooseBalloonDef(c)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if blnDef != tc.expected {
+				t.Errorf("expected balloon type %s, got %s", tc.expected.Name, blnDef.Name)
+			}
+		})
+	}
+}
+
+func TestIntrospectAssignments(t *testing.T) {
+	blnDef := &BalloonDef{Name: "full-core"}
+	bln := &Balloon{
+		Def:            blnDef,
+		Instance:       1,
+		Cpus:           cpuset.New(2, 3),
+		SharedIdleCpus: cpuset.New(4),
+		Mems:           idset.NewIDSet(0),
+		PodIDs:         map[string][]string{"pod": {"ctr-a", "gone"}},
+	}
+	p := &balloons{
+		cch: &mockCache{containers: map[string]cache.Container{
+			"ctr-a": &mockContainer{id: "cri-a"},
+		}},
+		balloons: []*Balloon{bln},
+	}
+
+	state := &introspect.State{}
+	p.Introspect(state)
+	if len(state.Assignments) != 1 {
+		t.Fatalf("expected 1 assignment, got %d", len(state.Assignments))
+	}
+	a, ok := state.Assignments["cri-a"]
+	if !ok {
+		t.Fatalf("no assignment for container cri-a")
+	}
+	if a.CacheID != "ctr-a" || a.Pool != bln.PrettyName() || a.SharedCPUs != "2-4" || a.Memory != "0" {
+		t.Errorf("unexpected assignment %+v", *a)
+	}
+}
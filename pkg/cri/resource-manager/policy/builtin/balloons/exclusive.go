@@ -0,0 +1,166 @@
+// Copyright 2026 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package balloons
+
+import (
+	"fmt"
+
+	"github.com/intel/cri-resource-manager/pkg/cgroups"
+	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/cache"
+	cpucontrol "github.com/intel/cri-resource-manager/pkg/cri/resource-manager/control/cpu"
+)
+
+// cpusetPartition returns the cpuset partition type of containers in
+// balloons of a type, or "" if they are not isolated in partitions.
+func (p *balloons) cpusetPartition(blnDef *BalloonDef) string {
+	if p.bpoptions.PinCPU != nil && !*p.bpoptions.PinCPU {
+		return ""
+	}
+	if blnDef.LoadBalancing != nil && !*blnDef.LoadBalancing {
+		return cpucontrol.PartitionIsolated
+	}
+	if blnDef.Exclusive {
+		return cpucontrol.PartitionRoot
+	}
+	return ""
+}
+
+// updatePartitions updates the cpuset partitions of balloons, and of
+// other balloons with containers of the same pods.
+func (p *balloons) updatePartitions(blns ...*Balloon) {
+	for _, bln := range p.balloons {
+		if p.cpusetPartition(bln.Def) == "" {
+			continue
+		}
+		for _, updated := range blns {
+			if bln == updated || sharePods(bln, updated) {
+				p.updatePartition(bln)
+				break
+			}
+		}
+	}
+}
+
+// sharePods returns true if two balloons have containers of the same pod.
+func sharePods(a, b *Balloon) bool {
+	for podID := range a.PodIDs {
+		if _, ok := b.PodIDs[podID]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// updatePartition isolates the CPUs of a balloon in a cgroup v2 cpuset
+// partition, if the balloon type asks for it. The CPUs of a partition
+// must not be used by its siblings, therefore the partition is the
+// cgroup of the only pod in the balloon, and the pod must not have
+// containers in other balloons. Other balloons, and pods in cgroup
+// hierarchies that do not allow partitions, are left alone and a
+// warning is logged once.
+func (p *balloons) updatePartition(bln *Balloon) {
+	partition := p.cpusetPartition(bln.Def)
+	if partition == "" {
+		return
+	}
+	pod, reason := p.partitionPod(bln)
+	if pod != nil && pod.GetID() != bln.partitionPod {
+		p.clearPartition(bln)
+	}
+	if pod != nil {
+		group, err := podCpusetGroup(pod)
+		if err == nil {
+			_, err = cpucontrol.WritePartition(group, bln.Cpus, partition)
+		}
+		if err == nil {
+			if bln.partitionPod == "" {
+				log.Info("balloon %s: CPUs %s isolated in a %s cpuset partition of pod %s",
+					bln.PrettyName(), bln.Cpus, partition, pod.GetName())
+			}
+			bln.partitionPod = pod.GetID()
+			bln.noPartition = ""
+			return
+		}
+		reason = err.Error()
+	}
+	p.clearPartition(bln)
+	if reason != "" && reason != bln.noPartition {
+		log.Warn("balloon %s: CPUs %s are not isolated in a %s cpuset partition: %s",
+			bln.PrettyName(), bln.Cpus, partition, reason)
+	}
+	bln.noPartition = reason
+}
+
+// clearPartition turns the pod cgroup isolated for a balloon back into
+// a regular cgroup.
+func (p *balloons) clearPartition(bln *Balloon) {
+	if bln.partitionPod == "" {
+		return
+	}
+	podID := bln.partitionPod
+	bln.partitionPod = ""
+	pod, ok := p.cch.LookupPod(podID)
+	if !ok {
+		return
+	}
+	group, err := podCpusetGroup(pod)
+	if err == nil {
+		_, err = cpucontrol.ClearPartition(group)
+	}
+	if err != nil {
+		log.Warn("balloon %s: failed to remove cpuset partition of pod %s: %v",
+			bln.PrettyName(), pod.GetName(), err)
+		return
+	}
+	log.Debug("balloon %s: removed cpuset partition of pod %s", bln.PrettyName(), pod.GetName())
+}
+
+// partitionPod returns the pod to isolate in a cpuset partition for a
+// balloon, or the reason why the balloon can't be isolated. A balloon
+// without pods has nothing to isolate.
+func (p *balloons) partitionPod(bln *Balloon) (cache.Pod, string) {
+	switch len(bln.PodIDs) {
+	case 0:
+		return nil, ""
+	case 1:
+	default:
+		return nil, fmt.Sprintf("balloon has %d pods, a partition needs a single pod", len(bln.PodIDs))
+	}
+	var podID string
+	for podID = range bln.PodIDs {
+	}
+	for _, other := range p.balloons {
+		if _, ok := other.PodIDs[podID]; ok && other != bln {
+			return nil, fmt.Sprintf("pod has containers also in balloon %s", other.PrettyName())
+		}
+	}
+	pod, ok := p.cch.LookupPod(podID)
+	if !ok {
+		return nil, fmt.Sprintf("pod %s not found", podID)
+	}
+	return pod, ""
+}
+
+// podCpusetGroup returns the cgroup v2 cpuset group of a pod.
+func podCpusetGroup(pod cache.Pod) (cgroups.Group, error) {
+	if cgroups.DetectSystemCgroupVersion() != 2 {
+		return "", balloonsError("cpuset partitions need cgroup v2")
+	}
+	dir := pod.GetCgroupParentDir()
+	if dir == "" {
+		return "", balloonsError("failed to determine cgroup directory of pod %s", pod.GetName())
+	}
+	return cgroups.Cpuset.Group(dir), nil
+}
@@ -0,0 +1,105 @@
+// Copyright 2026 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package balloons
+
+import (
+	"errors"
+	"time"
+
+	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/cache"
+	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/introspect"
+)
+
+// Reasons for failing to allocate a balloon for a container.
+const (
+	// FailureInsufficientCpus: not enough free CPUs for a new or inflated balloon.
+	FailureInsufficientCpus = "insufficient-cpus"
+	// FailureMaxBalloons: MaxBalloons of the balloon type reached.
+	FailureMaxBalloons = "max-balloons"
+	// FailureMaxTotalCpus: MaxTotalCpus of the balloon type reached.
+	FailureMaxTotalCpus = "max-total-cpus"
+	// FailureNoBalloonType: no balloon type matches the container.
+	FailureNoBalloonType = "no-balloon-type"
+	// FailureNoBalloon: no balloon instance of the type could be used.
+	FailureNoBalloon = "no-suitable-balloon"
+	// FailureOther: any other reason.
+	FailureOther = "other"
+)
+
+// maxRecentFailures is the number of failures kept for introspection.
+const maxRecentFailures = 16
+
+// allocationError is an error with a reason for a failed allocation.
+type allocationError struct {
+	reason string
+	err    error
+}
+
+// allocError creates a formatted error with an allocation failure reason.
+func allocError(reason, format string, args ...interface{}) error {
+	return &allocationError{
+		reason: reason,
+		err:    balloonsError(format, args...),
+	}
+}
+
+func (e *allocationError) Error() string {
+	return e.err.Error()
+}
+
+func (e *allocationError) Unwrap() error {
+	return e.err
+}
+
+// failureReason returns the allocation failure reason for an error.
+func failureReason(err error) string {
+	var aerr *allocationError
+	if errors.As(err, &aerr) {
+		return aerr.reason
+	}
+	return FailureOther
+}
+
+// recordFailure counts and remembers a failed allocation for a container.
+func (p *balloons) recordFailure(blnDef *BalloonDef, c cache.Container, err error) {
+	defName := ""
+	if blnDef != nil {
+		defName = blnDef.Name
+	}
+	reason := failureReason(err)
+
+	if p.failureCounts == nil {
+		p.failureCounts = map[string]map[string]int{}
+	}
+	if p.failureCounts[defName] == nil {
+		p.failureCounts[defName] = map[string]int{}
+	}
+	p.failureCounts[defName][reason]++
+
+	failure := &introspect.AllocationFailure{
+		ContainerID: c.GetID(),
+		Pool:        defName,
+		Reason:      reason,
+		Message:     err.Error(),
+		Time:        time.Now(),
+	}
+	if len(p.failures) >= maxRecentFailures {
+		p.failures = p.failures[1:]
+	}
+	p.failures = append(p.failures, failure)
+
+	log.Warnf("allocation for container %s in balloon type %q failed (%s): %v",
+		c.PrettyName(), defName, reason, err)
+}
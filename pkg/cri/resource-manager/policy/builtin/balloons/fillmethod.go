@@ -59,20 +59,60 @@ const (
 	// FillDefaultBalloon: put a container into the default
 	// balloon.
 	FillDefaultBalloon
+	// FillOverflowShare: put a container into the balloon with
+	// most free CPU even if it does not fit, oversubscribing the
+	// balloon.
+	FillOverflowShare
+	// FillOverflowNewBalloon: create a new balloon for a
+	// container even if it cannot be inflated enough to fit it,
+	// oversubscribing the balloon.
+	FillOverflowNewBalloon
 )
 
 var fillMethodNames = map[FillMethod]string{
-	FillUnspecified:     "unspecified",
-	FillBalanced:        "balanced",
-	FillBalancedInflate: "balanced-inflate",
-	FillPacked:          "packed",
-	FillPackedInflate:   "packed-inflate",
-	FillSameNamespace:   "same-namespace",
-	FillSamePod:         "same-pod",
-	FillNewBalloon:      "new-balloon",
-	FillNewBalloonMust:  "new-balloon-must",
-	FillDefaultBalloon:  "default-balloon",
-	FillReservedBalloon: "reserved-balloon",
+	FillUnspecified:        "unspecified",
+	FillBalanced:           "balanced",
+	FillBalancedInflate:    "balanced-inflate",
+	FillPacked:             "packed",
+	FillPackedInflate:      "packed-inflate",
+	FillSameNamespace:      "same-namespace",
+	FillSamePod:            "same-pod",
+	FillNewBalloon:         "new-balloon",
+	FillNewBalloonMust:     "new-balloon-must",
+	FillDefaultBalloon:     "default-balloon",
+	FillReservedBalloon:    "reserved-balloon",
+	FillOverflowShare:      "overflow-share",
+	FillOverflowNewBalloon: "overflow-new-balloon",
+}
+
+// OverflowMethod specifies what to do with a container that does not
+// fit in any balloon of its type.
+type OverflowMethod string
+
+const (
+	// OverflowReject: refuse to run the container.
+	OverflowReject OverflowMethod = "reject"
+	// OverflowShare: put the container in the existing balloon
+	// with most free CPU, oversubscribing it.
+	OverflowShare OverflowMethod = "share"
+	// OverflowNewBalloon: create a new balloon for the container,
+	// oversubscribing it.
+	OverflowNewBalloon OverflowMethod = "new-balloon"
+)
+
+// fillMethod returns the fill method for overflowing containers, or
+// FillUnspecified if they are rejected.
+func (om OverflowMethod) fillMethod() (FillMethod, error) {
+	switch om {
+	case "", OverflowReject:
+		return FillUnspecified, nil
+	case OverflowShare:
+		return FillOverflowShare, nil
+	case OverflowNewBalloon:
+		return FillOverflowNewBalloon, nil
+	}
+	return FillUnspecified, balloonsError("invalid overflow method %q, expecting %q, %q or %q",
+		om, OverflowReject, OverflowShare, OverflowNewBalloon)
 }
 
 // String stringifies a FillMethod
@@ -33,7 +33,14 @@ type balloonsOptionsWrapped struct {
 	PinMemory *bool `json:"PinMemory,omitempty"`
 	// IdleCpuClass controls how unusded CPUs outside any a
 	// balloons are (re)configured.
-	IdleCpuClass string `json:"IdleCPUClass",omitempty"`
+	IdleCpuClass string `json:"IdleCPUClass,omitempty"`
+	// IdleCpuClassDelay is the delay before CPUs released from
+	// balloons are switched to the IdleCpuClass. If the CPUs are
+	// taken into use again within the delay, they are not
+	// switched at all. This avoids reconfiguring CPUs back and
+	// forth when balloons are rapidly inflated and deflated. The
+	// default is 0: switch immediately.
+	IdleCpuClassDelay pkgcfg.Duration `json:"IdleCPUClassDelay,omitempty"`
 	// ReservedPoolNamespaces is a list of namespace globs that
 	// will be allocated to reserved CPUs.
 	ReservedPoolNamespaces []string `json:"ReservedPoolNamespaces,omitempty"`
@@ -56,6 +63,34 @@ type balloonsOptionsWrapped struct {
 	// overridden with the balloon type specific setting with the same
 	// name.
 	PreferSpreadOnPhysicalCores bool `json:"PreferSpreadOnPhysicalCores,omitempty"`
+	// DaemonSetBalloon is the name of the balloon type that pods
+	// of DaemonSets are assigned to by default. DaemonSet pods
+	// are recognized by the pod-template-generation label set
+	// by the DaemonSet controller, or by the daemonset label
+	// of the resource manager which an admission webhook can
+	// add. DaemonSet pods in reserved namespaces stay in the
+	// reserved balloon. If empty, DaemonSet pods are treated
+	// like any other.
+	DaemonSetBalloon string `json:"DaemonSetBalloon,omitempty"`
+	// MemoryPressureThreshold is the system memory pressure
+	// (PSI "some avg10" percentage) above which NUMA nodes with
+	// CPUs and less free memory than average are deprioritized
+	// when pinning memory and choosing balloons. Pressure is
+	// checked periodically and running containers are repinned
+	// on changes. Nodes are re-enabled when the pressure falls
+	// below the threshold. The default is 0: memory pressure is
+	// not monitored.
+	MemoryPressureThreshold float64 `json:"MemoryPressureThreshold,omitempty"`
+	// AnnotateAssignments records the balloon of each container, and
+	// the time and reason of its latest change, in container
+	// annotations for debugging. The default is false.
+	AnnotateAssignments bool `json:"AnnotateAssignments,omitempty"`
+	// KubeletCPUManagerState is the path of the kubelet CPU manager
+	// state file, usually /var/lib/kubelet/cpu_manager_state. If set,
+	// CPUs that the kubelet static CPU manager policy has exclusively
+	// assigned to containers are never used in balloons. The default
+	// is empty: kubelet CPU assignments are not checked.
+	KubeletCPUManagerState string `json:"KubeletCPUManagerState,omitempty"`
 	// BallonDefs contains balloon type definitions.
 	BalloonDefs []*BalloonDef `json:"BalloonTypes,omitempty"`
 }
@@ -67,7 +102,7 @@ type BalloonDef struct {
 	// Namespaces control which namespaces are assigned into
 	// balloon instances from this definition. This is used by
 	// namespace assign methods.
-	Namespaces []string `json:"Namespaces",omitempty`
+	Namespaces []string `json:"Namespaces,omitempty"`
 	// MaxCpus specifies the maximum number of CPUs exclusively
 	// usable by containers in a balloon. Balloon size will not be
 	// inflated larger than MaxCpus.
@@ -100,10 +135,28 @@ type BalloonDef struct {
 	// is allowed to co-exist. If reached, new balloons cannot be
 	// created anymore.
 	MaxBalloons int `json:"MaxBalloons"`
+	// MaxTotalCpus is the maximum number of CPUs in all balloon
+	// instances of this type in total. Balloons of the type are
+	// not created or inflated beyond this budget. The default is
+	// 0: no limit.
+	MaxTotalCpus int `json:"MaxTotalCPUs,omitempty"`
 	// PreferSpreadingPods: containers of the same pod may be
 	// placed on separate balloons. The default is false: prefer
 	// placing containers of a pod to the same balloon(s).
 	PreferSpreadingPods bool
+	// MaxSpreadingPodMemory is the total memory request of a pod,
+	// like "4Gi", above which its containers are not spread on
+	// separate balloons of this type, even if PreferSpreadingPods
+	// is true. Such a container is placed in a balloon of this
+	// type that already has containers of its pod, or it fails to
+	// run. The default is empty: no limit.
+	MaxSpreadingPodMemory string `json:"MaxSpreadingPodMemory,omitempty"`
+	// SharePodMemoryNodes: pin containers in balloons of this type
+	// to the memory nodes of all balloons of their pod, so that
+	// containers of a pod spread on several balloons share the
+	// same NUMA memory. The default is false: containers use the
+	// memory nodes of their own balloon.
+	SharePodMemoryNodes bool `json:"SharePodMemoryNodes,omitempty"`
 	// PreferPerNamespaceBalloon: if true, containers in different
 	// namespaces are preferrably placed in separate balloons,
 	// even if the balloon type is the same for all of them. On
@@ -116,12 +169,67 @@ type BalloonDef struct {
 	// prefer using filling free capacity and possibly inflating
 	// existing balloons before creating new ones.
 	PreferNewBalloons bool
+	// PreferPacking: prefer adding containers to the balloon
+	// where they fit tightest, that is the balloon which has the
+	// least free CPU left afterwards, before any other fill
+	// methods. This keeps the number of balloons with containers
+	// minimal and leaves other balloons idle. The default is
+	// false.
+	PreferPacking bool
+	// OverflowMethod controls what happens to a container that
+	// does not fit in any balloon of this type, for instance
+	// because it requests more than MaxCpus: "reject" refuses to
+	// run it, "share" puts it in the balloon with most free CPU
+	// and "new-balloon" in a new balloon, oversubscribing the
+	// balloon. The default is "reject".
+	OverflowMethod OverflowMethod `json:"OverflowMethod,omitempty"`
+	// LimitCpuToRequest: if the balloon has more CPUs than a
+	// container requests, set the CFS quota of the container to
+	// its CPU request. This caps the CPU time the container can
+	// use within the balloon. The default is false: containers
+	// can use all CPUs of the balloon up to their CPU limit.
+	LimitCpuToRequest bool `json:"LimitCPUToRequest,omitempty"`
+	// NetClass is the network class of containers in the balloon,
+	// given as a tc class handle "MAJOR:MINOR" in hexadecimal. It is
+	// set as the net_cls classid of the containers, so that their
+	// traffic can be shaped by tc. The default is no network class.
+	NetClass string `json:"NetClass,omitempty"`
 	// ShareIdleCpusInSame <topology-level>: if there are idle
 	// CPUs, that is CPUs not in any balloon, in the same
 	// <topology-level> as any CPU in the balloon, then allow
 	// workloads to run on those (shared) CPUs in addition to the
 	// (dedicated) CPUs of the balloon.
 	ShareIdleCpusInSame CPUTopologyLevel `json:"ShareIdleCPUsInSame,omitempty"`
+	// MemoryNodes pins the memory of containers in the balloon to
+	// these NUMA nodes, regardless of the CPUs of the balloon. This
+	// is only supported for the reserved balloon. The default is to
+	// use the memory nodes closest to the CPUs of the balloon.
+	MemoryNodes []int `json:"MemoryNodes,omitempty"`
+	// MemoryDistanceLimit widens the memory nodes of containers in
+	// the balloon from the nodes local to the CPUs of the balloon
+	// to all nodes within this NUMA distance from them, for
+	// instance to include nearby HBM nodes. The default is 0: use
+	// only the local nodes.
+	MemoryDistanceLimit int `json:"MemoryDistanceLimit,omitempty"`
+	// MemoryLow protects the memory of containers in the balloon
+	// from reclaim by setting their cgroup v2 memory.low. It is
+	// either an amount of memory per container, like "512Mi", or
+	// "request" for the memory request of each container. The
+	// default is empty: memory.low is not set.
+	MemoryLow string `json:"MemoryLow,omitempty"`
+	// Exclusive isolates the CPUs of balloons of this type from
+	// other cgroups and kernel housekeeping by turning the cgroup v2
+	// cgroup of the single pod in a balloon into a cpuset partition
+	// root of the balloon CPUs. Needs kernel support for exclusive
+	// cpuset partitions and a parent cgroup allowing the partition.
+	// The default is false.
+	Exclusive bool `json:"Exclusive,omitempty"`
+	// LoadBalancing: if false, the CPUs of balloons of this type
+	// are isolated like with Exclusive, but in a cgroup v2 cpuset
+	// partition of the "isolated" type, where the kernel does
+	// not balance the load of tasks across the CPUs. The default
+	// is true: the kernel balances the load normally.
+	LoadBalancing *bool `json:"LoadBalancing,omitempty"`
 }
 
 var defaultPinCPU bool = true
@@ -149,6 +257,10 @@ func (bdef *BalloonDef) DeepCopy() *BalloonDef {
 	outBdef := *bdef
 	outBdef.Namespaces = make([]string, len(bdef.Namespaces))
 	copy(outBdef.Namespaces, bdef.Namespaces)
+	if bdef.MemoryNodes != nil {
+		outBdef.MemoryNodes = make([]int, len(bdef.MemoryNodes))
+		copy(outBdef.MemoryNodes, bdef.MemoryNodes)
+	}
 	return &outBdef
 }
 
@@ -0,0 +1,99 @@
+// Copyright 2026 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package balloons
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/intel/cri-resource-manager/pkg/utils/cpuset"
+)
+
+const (
+	// kubeletStaticPolicy is the name of the kubelet CPU manager policy
+	// which assigns exclusive CPUs to containers.
+	kubeletStaticPolicy = "static"
+)
+
+// kubeletCPUManagerState is the part of the kubelet CPU manager
+// checkpoint file we are interested in.
+type kubeletCPUManagerState struct {
+	PolicyName    string                       `json:"policyName"`
+	DefaultCPUSet string                       `json:"defaultCpuSet"`
+	Entries       map[string]map[string]string `json:"entries,omitempty"`
+}
+
+// readKubeletExclusiveCpus returns the CPUs the kubelet CPU manager
+// has exclusively assigned to containers, according to its checkpoint.
+func readKubeletExclusiveCpus(path string) (cpuset.CPUSet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cpuset.New(), err
+	}
+	state := kubeletCPUManagerState{}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return cpuset.New(), balloonsError("failed to parse kubelet CPU manager state %s: %w", path, err)
+	}
+	cpus := cpuset.New()
+	if state.PolicyName != kubeletStaticPolicy {
+		return cpus, nil
+	}
+	for podUID, containers := range state.Entries {
+		for name, entry := range containers {
+			cset, err := cpuset.Parse(entry)
+			if err != nil {
+				return cpuset.New(), balloonsError("invalid cpuset %q of container %s/%s in %s: %w",
+					entry, podUID, name, path, err)
+			}
+			cpus = cpus.Union(cset)
+		}
+	}
+	return cpus, nil
+}
+
+// updateKubeletCpus refreshes the set of CPUs exclusively assigned by
+// the kubelet CPU manager and keeps them out of free CPUs, so that
+// balloons never get them. CPUs released by the kubelet are returned to
+// free CPUs.
+func (p *balloons) updateKubeletCpus() {
+	path := p.bpoptions.KubeletCPUManagerState
+	if path == "" {
+		p.kubeletCpus = cpuset.New()
+		return
+	}
+
+	cpus, err := readKubeletExclusiveCpus(path)
+	if err != nil {
+		log.Error("failed to read kubelet exclusive CPUs: %v", err)
+		return
+	}
+	cpus = cpus.Intersection(p.allowed).Difference(p.reserved)
+
+	if !cpus.Equals(p.kubeletCpus) {
+		log.Info("kubelet exclusive CPUs changed from %q to %q", p.kubeletCpus, cpus)
+	}
+
+	inBalloons := cpuset.New()
+	for _, bln := range p.balloons {
+		inBalloons = inBalloons.Union(bln.Cpus)
+	}
+	if conflict := cpus.Intersection(inBalloons); !conflict.IsEmpty() {
+		log.Warn("kubelet exclusive CPUs %q are already used by balloons", conflict)
+	}
+
+	released := p.kubeletCpus.Difference(cpus).Intersection(p.allowed).Difference(p.reserved)
+	p.freeCpus = p.freeCpus.Union(released.Difference(inBalloons)).Difference(cpus)
+	p.kubeletCpus = cpus
+}
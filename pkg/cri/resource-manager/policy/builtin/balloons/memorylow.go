@@ -0,0 +1,79 @@
+// Copyright 2026 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package balloons
+
+import (
+	"bufio"
+	"os"
+	"strings"
+)
+
+// procMounts is the file listing the mounted filesystems.
+var procMounts = "/proc/mounts"
+
+// readMemoryRecursiveProt returns true if the cgroup v2 hierarchy listed
+// in a mounts file is mounted with the memory_recursiveprot option.
+func readMemoryRecursiveProt(path string) (bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 4 || fields[2] != "cgroup2" {
+			continue
+		}
+		for _, option := range strings.Split(fields[3], ",") {
+			if option == "memory_recursiveprot" {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+	if err := scanner.Err(); err != nil {
+		return false, err
+	}
+	return false, balloonsError("no cgroup2 mount found in %s", path)
+}
+
+// checkMemoryLow warns if MemoryLow is used in balloon types but cgroup
+// v2 is not mounted with memory_recursiveprot. The memory.low of a
+// container is only effective up to the protection of its ancestors,
+// and the kubelet does not set memory.low on the pod and QoS class
+// cgroups in between. With memory_recursiveprot the protection of the
+// kubepods cgroup reaches the containers nevertheless.
+func (p *balloons) checkMemoryLow(bpoptions *BalloonsOptions) {
+	used := false
+	for _, blnDef := range bpoptions.BalloonDefs {
+		if blnDef.MemoryLow != "" {
+			used = true
+			break
+		}
+	}
+	if !used {
+		return
+	}
+	recursiveProt, err := readMemoryRecursiveProt(procMounts)
+	if err != nil {
+		log.Warn("MemoryLow is used but failed to check for memory_recursiveprot: %v", err)
+		return
+	}
+	if !recursiveProt {
+		log.Warn("MemoryLow is used but cgroup v2 is not mounted with memory_recursiveprot, " +
+			"memory.low of containers may have no effect")
+	}
+}
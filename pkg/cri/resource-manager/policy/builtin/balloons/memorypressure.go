@@ -0,0 +1,223 @@
+// Copyright 2026 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package balloons
+
+import (
+	"bufio"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/events"
+	idset "github.com/intel/goresctrl/pkg/utils"
+)
+
+const (
+	// memoryPressureCheckInterval is the minimum interval between
+	// two consecutive memory pressure checks.
+	memoryPressureCheckInterval = 5 * time.Second
+)
+
+// procPressureMemory is the PSI file for system-wide memory pressure.
+var procPressureMemory = "/proc/pressure/memory"
+
+// readMemoryPressure returns the "some avg10" value from a PSI file.
+func readMemoryPressure(path string) (float64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || fields[0] != "some" {
+			continue
+		}
+		for _, field := range fields[1:] {
+			if value, ok := strings.CutPrefix(field, "avg10="); ok {
+				return strconv.ParseFloat(value, 64)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, err
+	}
+	return 0, balloonsError("no \"some avg10\" entry found in %s", path)
+}
+
+// pressuredNodes returns the nodes whose fraction of free memory is
+// below the average of all nodes. These are the nodes that are most
+// likely the source of system memory pressure.
+func pressuredNodes(freeFraction map[idset.ID]float64) idset.IDSet {
+	nodes := idset.NewIDSet()
+	if len(freeFraction) < 2 {
+		return nodes
+	}
+	sum := 0.0
+	for _, free := range freeFraction {
+		sum += free
+	}
+	avg := sum / float64(len(freeFraction))
+	for id, free := range freeFraction {
+		if free < avg {
+			nodes.Add(id)
+		}
+	}
+	return nodes
+}
+
+// watchMemoryPressure (re)starts periodic memory pressure checks, or
+// stops them if memory pressure is not monitored. To keep quiet while
+// there is no pressure, the checks only send an event for updating the
+// pressured nodes if the pressure is above the threshold, or if some
+// nodes are still deprioritized.
+func (p *balloons) watchMemoryPressure() {
+	if p.memPressureStop != nil {
+		close(p.memPressureStop)
+		p.memPressureStop = nil
+	}
+	threshold := p.bpoptions.MemoryPressureThreshold
+	if threshold <= 0 {
+		p.pressuredMems = idset.NewIDSet()
+		p.memPressureActive.Store(false)
+		return
+	}
+	stop := make(chan struct{})
+	p.memPressureStop = stop
+	go func() {
+		ticker := time.NewTicker(memoryPressureCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+			}
+			if !p.memPressureActive.Load() {
+				if pressure, err := readMemoryPressure(procPressureMemory); err != nil || pressure < threshold {
+					continue
+				}
+			}
+			e := &events.Policy{
+				Type:   MemoryPressureDue,
+				Source: PolicyName,
+			}
+			if err := p.options.SendEvent(e); err != nil {
+				log.Errorf("failed to send event for checking memory pressure: %v", err)
+			}
+		}
+	}()
+}
+
+// checkMemoryPressure refreshes the set of NUMA nodes under memory
+// pressure and repins the balloons whose memory nodes change. It
+// returns true if any balloons were repinned.
+func (p *balloons) checkMemoryPressure(now time.Time) bool {
+	if !p.updateMemoryPressure(now) {
+		return false
+	}
+	changed := []*Balloon{}
+	for _, bln := range p.balloons {
+		mems := p.balloonMems(bln.Def, bln.Cpus.Union(bln.SharedIdleCpus))
+		if mems.String() != bln.Mems.String() {
+			changed = append(changed, bln)
+		}
+	}
+	if len(changed) == 0 {
+		return false
+	}
+	p.updatePinning(changed...)
+	return true
+}
+
+// updateMemoryPressure refreshes the set of NUMA nodes that are
+// deprioritized due to memory pressure. It returns true if the set
+// changed. Nodes without CPUs are never deprioritized: balloons do
+// not prefer them in the first place, and their free memory says
+// nothing about the pressure caused by the workloads on the CPUs.
+func (p *balloons) updateMemoryPressure(now time.Time) bool {
+	threshold := p.bpoptions.MemoryPressureThreshold
+	if threshold <= 0 {
+		return false
+	}
+	if now.Sub(p.memPressureChecked) < memoryPressureCheckInterval {
+		return false
+	}
+	p.memPressureChecked = now
+
+	pressure, err := readMemoryPressure(procPressureMemory)
+	if err != nil {
+		log.Debugf("failed to read memory pressure: %v", err)
+		return false
+	}
+
+	pressured := idset.NewIDSet()
+	if pressure >= threshold {
+		sys := p.options.System
+		freeFraction := map[idset.ID]float64{}
+		for _, id := range sys.NodeIDs() {
+			node := sys.Node(id)
+			if node.CPUSet().IsEmpty() {
+				continue
+			}
+			info, err := node.MemoryInfo()
+			if err != nil || info.MemTotal == 0 {
+				continue
+			}
+			freeFraction[id] = float64(info.MemFree) / float64(info.MemTotal)
+		}
+		pressured = pressuredNodes(freeFraction)
+	}
+
+	changed := false
+	for _, id := range p.pressuredMems.SortedMembers() {
+		if !pressured.Has(id) {
+			log.Info("memory pressure on node #%d subsided, re-enabling it", id)
+			changed = true
+		}
+	}
+	for _, id := range pressured.SortedMembers() {
+		if !p.pressuredMems.Has(id) {
+			log.Info("memory pressure %.2f >= %.2f, deprioritizing node #%d", pressure, threshold, id)
+			changed = true
+		}
+	}
+	p.pressuredMems = pressured
+	p.memPressureActive.Store(pressured.Size() > 0)
+	return changed
+}
+
+// preferUnpressured filters out balloons whose memory nodes are all
+// under memory pressure, unless that would leave no balloons at all.
+func (p *balloons) preferUnpressured(balloons []*Balloon) []*Balloon {
+	if p.pressuredMems.Size() == 0 {
+		return balloons
+	}
+	preferred := []*Balloon{}
+	for _, bln := range balloons {
+		for _, id := range bln.Mems.Members() {
+			if !p.pressuredMems.Has(id) {
+				preferred = append(preferred, bln)
+				break
+			}
+		}
+	}
+	if len(preferred) == 0 {
+		return balloons
+	}
+	return preferred
+}
@@ -27,6 +27,7 @@ import (
 // Prometheus Metric descriptor indices and descriptor table
 const (
 	balloonsDesc = iota
+	failuresDesc
 )
 
 var descriptors = []*prometheus.Desc{
@@ -56,11 +57,27 @@ var descriptors = []*prometheus.Desc{
 			"tot_req_millicpu",
 		}, nil,
 	),
+	failuresDesc: prometheus.NewDesc(
+		"balloons_allocation_failures",
+		"Number of failed container allocations",
+		[]string{
+			"balloon_type",
+			"reason",
+		}, nil,
+	),
 }
 
 // Metrics defines the balloons-specific metrics from policy level.
 type Metrics struct {
 	Balloons []*BalloonMetrics
+	Failures []*FailureMetrics
+}
+
+// FailureMetrics define metrics of failed allocations.
+type FailureMetrics struct {
+	DefName string
+	Reason  string
+	Count   int
 }
 
 // BalloonMetrics define metrics of a balloon instance.
@@ -136,6 +153,15 @@ func (p *balloons) PollMetrics() policy.Metrics {
 		sort.Strings(cNames)
 		bm.ContainerNames = strings.Join(cNames, ",")
 	}
+	for defName, counts := range p.failureCounts {
+		for reason, count := range counts {
+			policyMetrics.Failures = append(policyMetrics.Failures, &FailureMetrics{
+				DefName: defName,
+				Reason:  reason,
+				Count:   count,
+			})
+		}
+	}
 
 	return policyMetrics
 }
@@ -147,7 +173,7 @@ func (p *balloons) CollectMetrics(m policy.Metrics) ([]prometheus.Metric, error)
 	if !ok {
 		return nil, balloonsError("type mismatch in balloons metrics")
 	}
-	promMetrics := make([]prometheus.Metric, len(metrics.Balloons))
+	promMetrics := make([]prometheus.Metric, len(metrics.Balloons), len(metrics.Balloons)+len(metrics.Failures))
 	for index, bm := range metrics.Balloons {
 		promMetrics[index] = prometheus.MustNewConstMetric(
 			descriptors[balloonsDesc],
@@ -174,5 +200,13 @@ func (p *balloons) CollectMetrics(m policy.Metrics) ([]prometheus.Metric, error)
 			bm.ContainerNames,
 			strconv.Itoa(bm.ContainerReqMilliCpus))
 	}
+	for _, fm := range metrics.Failures {
+		promMetrics = append(promMetrics, prometheus.MustNewConstMetric(
+			descriptors[failuresDesc],
+			prometheus.CounterValue,
+			float64(fm.Count),
+			fm.DefName,
+			fm.Reason))
+	}
 	return promMetrics, nil
 }
@@ -0,0 +1,184 @@
+// Copyright 2026 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package balloons
+
+import (
+	"encoding/json"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/cache"
+	system "github.com/intel/cri-resource-manager/pkg/sysfs"
+	"github.com/intel/cri-resource-manager/pkg/utils/cpuset"
+	idset "github.com/intel/goresctrl/pkg/utils"
+)
+
+// The mocks below implement the parts of the cache and sysfs interfaces
+// the policy uses. Calling any other method of the embedded interfaces
+// panics.
+
+type mockSystemNode struct {
+	system.Node
+	cpus    cpuset.CPUSet
+	memFree uint64
+}
+
+func (fake *mockSystemNode) CPUSet() cpuset.CPUSet {
+	return fake.cpus
+}
+
+func (fake *mockSystemNode) MemoryInfo() (*system.MemInfo, error) {
+	return &system.MemInfo{MemTotal: 100, MemFree: fake.memFree, MemUsed: 100 - fake.memFree}, nil
+}
+
+type mockSystem struct {
+	system.System
+	nodes map[idset.ID]*mockSystemNode
+}
+
+func (fake *mockSystem) NodeIDs() []idset.ID {
+	ids := []idset.ID{}
+	for id := range fake.nodes {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func (fake *mockSystem) Node(id idset.ID) system.Node {
+	return fake.nodes[id]
+}
+
+type mockContainer struct {
+	cache.Container
+	name        string
+	id          string
+	namespace   string
+	pod         cache.Pod
+	resources   corev1.ResourceRequirements
+	annotations map[string]string
+	cpusetCpus  string
+	cpusetMems  string
+	cpuShares   int64
+	cpuQuota    int64
+	cpuPeriod   int64
+}
+
+func (m *mockContainer) PrettyName() string {
+	return m.name
+}
+func (m *mockContainer) GetID() string {
+	return m.id
+}
+func (m *mockContainer) GetNamespace() string {
+	return m.namespace
+}
+func (m *mockContainer) GetPod() (cache.Pod, bool) {
+	return m.pod, m.pod != nil
+}
+func (m *mockContainer) GetResourceRequirements() corev1.ResourceRequirements {
+	return m.resources
+}
+func (m *mockContainer) GetEffectiveAnnotation(key string) (string, bool) {
+	value, ok := m.annotations[key]
+	return value, ok
+}
+func (m *mockContainer) GetCpusetCpus() string {
+	return m.cpusetCpus
+}
+func (m *mockContainer) SetCpusetCpus(cpus string) {
+	m.cpusetCpus = cpus
+}
+func (m *mockContainer) GetCpusetMems() string {
+	return m.cpusetMems
+}
+func (m *mockContainer) SetCpusetMems(mems string) {
+	m.cpusetMems = mems
+}
+func (m *mockContainer) SetCPUShares(shares int64) {
+	m.cpuShares = shares
+}
+func (m *mockContainer) GetCPUQuota() int64 {
+	return m.cpuQuota
+}
+func (m *mockContainer) SetCPUQuota(quota int64) {
+	m.cpuQuota = quota
+}
+func (m *mockContainer) GetCPUPeriod() int64 {
+	return m.cpuPeriod
+}
+func (m *mockContainer) SetCPUPeriod(period int64) {
+	m.cpuPeriod = period
+}
+
+type mockPod struct {
+	cache.Pod
+	id         string
+	labels     map[string]string
+	resources  cache.PodResourceRequirements
+	containers []cache.Container
+}
+
+func (m *mockPod) GetID() string {
+	return m.id
+}
+func (m *mockPod) GetLabel(key string) (string, bool) {
+	value, ok := m.labels[key]
+	return value, ok
+}
+func (m *mockPod) GetPodResourceRequirements() cache.PodResourceRequirements {
+	return m.resources
+}
+func (m *mockPod) GetContainers() []cache.Container {
+	return m.containers
+}
+
+type mockCache struct {
+	cache.Cache
+	pods       map[string]cache.Pod
+	containers map[string]cache.Container
+	entries    map[string][]byte
+}
+
+func (m *mockCache) LookupPod(id string) (cache.Pod, bool) {
+	pod, ok := m.pods[id]
+	return pod, ok
+}
+func (m *mockCache) LookupContainer(id string) (cache.Container, bool) {
+	c, ok := m.containers[id]
+	return c, ok
+}
+func (m *mockCache) SetControllerEntry(controller, key string, obj interface{}) {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		panic(err)
+	}
+	if m.entries == nil {
+		m.entries = map[string][]byte{}
+	}
+	m.entries[controller+"/"+key] = data
+}
+func (m *mockCache) GetControllerEntry(controller, key string, obj interface{}) bool {
+	data, ok := m.entries[controller+"/"+key]
+	if !ok {
+		return false
+	}
+	return json.Unmarshal(data, obj) == nil
+}
+func (m *mockCache) GetActivePolicy() string {
+	return PolicyName
+}
+func (m *mockCache) GetPolicyEntry(string, interface{}) bool {
+	return false
+}
@@ -354,7 +354,7 @@ func (p *dynamicPools) Description() string {
 // Start prepares this policy for accepting allocation/release requests.
 func (p *dynamicPools) Start(add []cache.Container, del []cache.Container) error {
 	log.Info("%s policy started", PolicyName)
-	return p.Sync(p.cch.GetContainers(), nil)
+	return p.Sync(cache.ManagedContainers(p.cch.GetContainers()), nil)
 }
 
 // Sync synchronizes the active policy state.
@@ -745,7 +745,8 @@ func (p *dynamicPools) configNotify(event pkgcfg.Event, source pkgcfg.Source) er
 		return err
 	}
 	log.Info("config updated successfully")
-	p.Sync(p.cch.GetContainers(), p.cch.GetContainers())
+	containers := cache.ManagedContainers(p.cch.GetContainers())
+	p.Sync(containers, containers)
 	return nil
 }
 
@@ -178,7 +178,7 @@ func (p *podpools) Description() string {
 // Start prepares this policy for accepting allocation/release requests.
 func (p *podpools) Start(add []cache.Container, del []cache.Container) error {
 	log.Info("%s policy started", PolicyName)
-	return p.Sync(p.cch.GetContainers(), del)
+	return p.Sync(cache.ManagedContainers(p.cch.GetContainers()), del)
 }
 
 // Sync synchronizes the active policy state.
@@ -423,7 +423,7 @@ func (p *podpools) configNotify(event pkgcfg.Event, source pkgcfg.Source) error
 		return err
 	}
 	log.Info("config updated successfully")
-	p.Sync(p.cch.GetContainers(), nil)
+	p.Sync(cache.ManagedContainers(p.cch.GetContainers()), nil)
 	return nil
 }
 
@@ -0,0 +1,74 @@
+// Copyright 2026 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package topologyaware
+
+import (
+	"sort"
+)
+
+// balancer spreads exclusive allocations round-robin across the topmost
+// level of the pool tree with more than one pool, typically the sockets.
+type balancer struct {
+	pools []Node // pools to spread allocations across
+	next  int    // index of the pool to prefer next
+}
+
+// newBalancer creates a balancer for the pool tree, or returns nil if
+// there is nothing to balance across.
+func (p *policy) newBalancer() *balancer {
+	n := p.root
+	for len(n.Children()) == 1 {
+		n = n.Children()[0]
+	}
+	if len(n.Children()) < 2 {
+		return nil
+	}
+	return &balancer{pools: n.Children()}
+}
+
+// rank returns how far the pool is from the next preferred one in
+// round-robin order, or len(b.pools) if it is not under any of them.
+func (b *balancer) rank(pool Node) int {
+	for n := pool; !n.IsNil(); n = n.Parent() {
+		for idx, top := range b.pools {
+			if n.NodeID() == top.NodeID() {
+				return (idx - b.next + len(b.pools)) % len(b.pools)
+			}
+		}
+	}
+	return len(b.pools)
+}
+
+// balancePools moves the pools under the next pool in round-robin order
+// first, if an initial placement is being balanced and the request asks
+// for exclusive CPUs. Otherwise the order of the pools is preserved.
+func (p *policy) balancePools(req Request, pools []Node) []Node {
+	b := p.balancer
+	if b == nil || req.FullCPUs() == 0 || len(pools) == 0 {
+		return pools
+	}
+
+	balanced := make([]Node, len(pools))
+	copy(balanced, pools)
+	sort.SliceStable(balanced, func(i, j int) bool {
+		return b.rank(balanced[i]) < b.rank(balanced[j])
+	})
+
+	if rank := b.rank(balanced[0]); rank < len(b.pools) {
+		b.next = (b.next + rank + 1) % len(b.pools)
+		log.Debug("%s: balanced to %s", req.GetContainer().PrettyName(), balanced[0].Name())
+	}
+	return balanced
+}
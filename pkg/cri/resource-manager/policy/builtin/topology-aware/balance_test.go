@@ -0,0 +1,77 @@
+// Copyright 2026 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package topologyaware
+
+import (
+	"testing"
+)
+
+func TestBalancePools(t *testing.T) {
+	p := &policy{}
+	nodes := map[string]Node{}
+	add := func(name string, parent Node) Node {
+		n := p.NewVirtualNode(name, parent)
+		n.(*virtualnode).id = len(nodes)
+		nodes[name] = n
+		return n
+	}
+	root := add("root", nilnode)
+	for _, socket := range []string{"socket #0", "socket #1"} {
+		s := add(socket, root)
+		add(socket+"/node #0", s)
+		add(socket+"/node #1", s)
+	}
+
+	// pools in the order of their scores
+	pools := []Node{
+		nodes["socket #0/node #0"], nodes["socket #0/node #1"], nodes["socket #0"],
+		nodes["socket #1/node #0"], nodes["socket #1/node #1"], nodes["socket #1"],
+		root,
+	}
+	exclusive := &request{container: &mockContainer{name: "exclusive"}, full: 2}
+	shared := &request{container: &mockContainer{name: "shared"}, fraction: 500}
+
+	if balanced := p.balancePools(exclusive, pools); balanced[0] != pools[0] {
+		t.Errorf("expected unchanged order without balancing, got %s first", balanced[0].Name())
+	}
+
+	p.root = root
+	p.balancer = p.newBalancer()
+	if p.balancer == nil || len(p.balancer.pools) != 2 {
+		t.Fatalf("expected balancing across the 2 sockets")
+	}
+
+	for idx, expected := range []string{
+		"socket #0/node #0", "socket #1/node #0", "socket #0/node #0", "socket #1/node #0",
+	} {
+		balanced := p.balancePools(exclusive, pools)
+		if balanced[0].Name() != expected {
+			t.Errorf("allocation #%d: expected %s first, got %s", idx, expected, balanced[0].Name())
+		}
+		if last := balanced[len(balanced)-1]; last != root {
+			t.Errorf("allocation #%d: expected root last, got %s", idx, last.Name())
+		}
+		if balanced := p.balancePools(shared, pools); balanced[0] != pools[0] {
+			t.Errorf("expected unchanged order for shared request, got %s first", balanced[0].Name())
+		}
+	}
+
+	// full socket #1: its pools are filtered out, allocation stays on socket #0
+	p.balancer.next = 1
+	if balanced := p.balancePools(exclusive, pools[:3]); balanced[0] != pools[0] || p.balancer.next != 1 {
+		t.Errorf("expected %s first and socket #1 next, got %s and %d",
+			pools[0].Name(), balanced[0].Name(), p.balancer.next)
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright 2026 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package topologyaware
+
+import (
+	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/cache"
+)
+
+// containerUpdate is the latest CPU and memory pinning set for a container in a batch.
+type containerUpdate struct {
+	container cache.Container
+	cpus      *string
+	mems      *string
+	shares    *int64
+}
+
+// updateBatch collects the pinning of containers over several grant updates.
+// Only the final pinning of each container is applied, and only if it differs
+// from the current one, so each changed container is marked for a single CRI
+// update and unchanged containers are not updated at all.
+type updateBatch struct {
+	updates map[string]*containerUpdate
+	order   []string
+}
+
+// newUpdateBatch creates a new, empty update batch.
+func newUpdateBatch() *updateBatch {
+	return &updateBatch{
+		updates: make(map[string]*containerUpdate),
+	}
+}
+
+// update returns the batched update of the given container.
+func (b *updateBatch) update(c cache.Container) *containerUpdate {
+	id := c.GetCacheID()
+	u, ok := b.updates[id]
+	if !ok {
+		u = &containerUpdate{container: c}
+		b.updates[id] = u
+		b.order = append(b.order, id)
+	}
+	return u
+}
+
+// apply applies the batched updates, returning the number of changed containers.
+func (b *updateBatch) apply() int {
+	changed := 0
+	for _, id := range b.order {
+		u := b.updates[id]
+		c := u.container
+		updated := false
+		if u.cpus != nil && *u.cpus != c.GetCpusetCpus() {
+			c.SetCpusetCpus(*u.cpus)
+			updated = true
+		}
+		if u.mems != nil && *u.mems != c.GetCpusetMems() {
+			c.SetCpusetMems(*u.mems)
+			updated = true
+		}
+		if u.shares != nil && *u.shares != c.GetCPUShares() {
+			c.SetCPUShares(*u.shares)
+			updated = true
+		}
+		if updated {
+			changed++
+		}
+	}
+	return changed
+}
+
+// batchUpdates runs fn, collecting container pinning into a single batch
+// which is applied once fn returns. Nested calls join the ongoing batch.
+func (p *policy) batchUpdates(fn func()) {
+	if p.batch != nil {
+		fn()
+		return
+	}
+
+	p.batch = newUpdateBatch()
+	defer func() {
+		b := p.batch
+		p.batch = nil
+		changed := b.apply()
+		log.Debug("* applied batched updates, %d of %d containers changed", changed, len(b.order))
+	}()
+
+	fn()
+}
+
+// setCpusetCpus sets the cpuset of a container, or batches it if a batch is ongoing.
+func (p *policy) setCpusetCpus(c cache.Container, cpus string) {
+	if p.batch == nil {
+		c.SetCpusetCpus(cpus)
+		return
+	}
+	p.batch.update(c).cpus = &cpus
+}
+
+// setCpusetMems sets the memory nodes of a container, or batches it if a batch is ongoing.
+func (p *policy) setCpusetMems(c cache.Container, mems string) {
+	if p.batch == nil {
+		c.SetCpusetMems(mems)
+		return
+	}
+	p.batch.update(c).mems = &mems
+}
+
+// setCPUShares sets the CPU shares of a container, or batches it if a batch is ongoing.
+func (p *policy) setCPUShares(c cache.Container, shares int64) {
+	if p.batch == nil {
+		c.SetCPUShares(shares)
+		return
+	}
+	p.batch.update(c).shares = &shares
+}
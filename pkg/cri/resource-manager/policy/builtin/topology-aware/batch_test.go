@@ -0,0 +1,97 @@
+// Copyright 2026 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package topologyaware
+
+import (
+	"testing"
+)
+
+// recordingContainer is a mock container which counts its pinning updates.
+type recordingContainer struct {
+	mockContainer
+	cpus    string
+	mems    string
+	shares  int64
+	updates int
+}
+
+func (m *recordingContainer) GetCpusetCpus() string { return m.cpus }
+func (m *recordingContainer) GetCpusetMems() string { return m.mems }
+func (m *recordingContainer) GetCPUShares() int64   { return m.shares }
+func (m *recordingContainer) SetCpusetCpus(cpus string) {
+	m.cpus = cpus
+	m.updates++
+}
+func (m *recordingContainer) SetCpusetMems(mems string) {
+	m.mems = mems
+	m.updates++
+}
+func (m *recordingContainer) SetCPUShares(shares int64) {
+	m.shares = shares
+	m.updates++
+}
+
+func TestBatchUpdates(t *testing.T) {
+	moved := &recordingContainer{
+		mockContainer: mockContainer{returnValueForGetCacheID: "moved"},
+		cpus:          "0-3",
+		mems:          "0",
+		shares:        2,
+	}
+	unmoved := &recordingContainer{
+		mockContainer: mockContainer{returnValueForGetCacheID: "unmoved"},
+		cpus:          "4-7",
+		mems:          "1",
+		shares:        2,
+	}
+
+	p := &policy{}
+	p.batchUpdates(func() {
+		// release, then reallocate both containers
+		for _, c := range []*recordingContainer{moved, unmoved} {
+			p.setCpusetCpus(c, "0-7")
+			p.setCpusetMems(c, "0,1")
+		}
+		p.batchUpdates(func() {
+			p.setCpusetCpus(moved, "8-11")
+			p.setCPUShares(moved, 1024)
+			p.setCpusetCpus(unmoved, "4-7")
+			p.setCpusetMems(unmoved, "1")
+		})
+		if moved.updates != 0 || unmoved.updates != 0 {
+			t.Errorf("updates applied before the end of the batch")
+		}
+		p.setCpusetMems(moved, "0")
+	})
+
+	if p.batch != nil {
+		t.Errorf("batch not cleared after applying it")
+	}
+	if moved.cpus != "8-11" || moved.mems != "0" || moved.shares != 1024 {
+		t.Errorf("unexpected pinning of moved container: cpus %q, mems %q, shares %d",
+			moved.cpus, moved.mems, moved.shares)
+	}
+	if moved.updates != 2 {
+		t.Errorf("expected 2 updates to moved container, got %d", moved.updates)
+	}
+	if unmoved.updates != 0 {
+		t.Errorf("expected no updates to unmoved container, got %d", unmoved.updates)
+	}
+
+	p.setCpusetCpus(unmoved, "4-7")
+	if unmoved.updates != 1 {
+		t.Errorf("expected unbatched update to be applied directly")
+	}
+}
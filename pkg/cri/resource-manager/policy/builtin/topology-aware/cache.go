@@ -16,6 +16,7 @@ package topologyaware
 
 import (
 	"encoding/json"
+	"maps"
 	"time"
 
 	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/cache"
@@ -25,11 +26,15 @@ import (
 
 const (
 	keyAllocations = "allocations"
+	keyPodPools    = "podpools"
+	keyDemoted     = "demoted"
 	keyConfig      = "config"
 )
 
 func (p *policy) saveAllocations() {
 	p.cache.SetPolicyEntry(keyAllocations, cache.Cachable(&p.allocations))
+	p.cache.SetPolicyEntry(keyPodPools, p.podPoolOwners())
+	p.cache.SetPolicyEntry(keyDemoted, maps.Clone(p.demoted))
 	p.cache.Save()
 }
 
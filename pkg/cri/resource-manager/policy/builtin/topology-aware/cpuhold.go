@@ -0,0 +1,103 @@
+// Copyright 2026 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package topologyaware
+
+import (
+	"time"
+
+	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/cache"
+	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/events"
+)
+
+// heldGrant is a released grant with its exclusive CPUs held out of reallocation.
+type heldGrant struct {
+	grant Grant
+	timer *time.Timer
+}
+
+// holdExclusiveCPUs releases the grant of the container except for its exclusive
+// CPUs, which are held out of reallocation for the configured grace period. It
+// returns false if the CPUs need not be held, in which case nothing is released.
+func (p *policy) holdExclusiveCPUs(container cache.Container) (Grant, bool) {
+	delay := time.Duration(opt.ExclusiveCPUReleaseDelay)
+	if delay <= 0 {
+		return nil, false
+	}
+
+	id := container.GetCacheID()
+	grant, ok := p.allocations.grants[id]
+	if !ok || grant.ExclusiveCPUs().IsEmpty() {
+		return nil, false
+	}
+
+	log.Debug("* releasing resources of %s, holding exclusive CPUs %s for %v",
+		container.PrettyName(), grant.ExclusiveCPUs(), delay)
+
+	grant.GetMemoryNode().FreeSupply().ReleaseMemory(grant)
+	grant.StopTimer()
+
+	delete(p.allocations.grants, id)
+	p.saveAllocations()
+
+	if held, ok := p.heldGrants[id]; ok {
+		held.timer.Stop()
+		p.releaseCPUs(held.grant)
+	}
+	p.heldGrants[id] = &heldGrant{
+		grant: grant,
+		timer: time.AfterFunc(delay, func() {
+			e := &events.Policy{
+				Type:   HeldCPUsReleased,
+				Source: PolicyName,
+				Data:   id,
+			}
+			if err := p.options.SendEvent(e); err != nil {
+				log.Error("failed to send event for releasing held CPUs of %s: %v", id, err)
+			}
+		}),
+	}
+
+	return grant, true
+}
+
+// releaseHeldCPUs returns the held exclusive CPUs of a released grant to its pool.
+func (p *policy) releaseHeldCPUs(id string) bool {
+	held, ok := p.heldGrants[id]
+	if !ok {
+		log.Debug("no held CPUs for %s, nothing to release", id)
+		return false
+	}
+	delete(p.heldGrants, id)
+
+	log.Debug("* releasing held exclusive CPUs %s of %s", held.grant.ExclusiveCPUs(), id)
+	p.releaseCPUs(held.grant)
+	p.updateSharedAllocations(&held.grant)
+	p.root.Dump("<post-release-held>")
+
+	return true
+}
+
+// releaseCPUs returns the CPUs of a released grant to its pool.
+func (p *policy) releaseCPUs(grant Grant) {
+	grant.GetCPUNode().FreeSupply().ReleaseCPU(grant)
+}
+
+// dropHeldCPUs forgets about all held CPUs, for instance when pools are rebuilt.
+func (p *policy) dropHeldCPUs() {
+	for _, held := range p.heldGrants {
+		held.timer.Stop()
+	}
+	p.heldGrants = make(map[string]*heldGrant)
+}
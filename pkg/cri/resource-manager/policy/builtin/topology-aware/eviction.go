@@ -0,0 +1,192 @@
+// Copyright 2026 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package topologyaware
+
+import (
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/cache"
+)
+
+// evictionCandidates returns the grants which can be demoted to make room for
+// a request, in the order they should be demoted in: lowest priority first,
+// then BestEffort before Burstable, then the most exclusive CPUs first.
+func (p *policy) evictionCandidates(req Request) []Grant {
+	priority := podPriority(req.GetContainer())
+	candidates := []Grant{}
+	for _, g := range p.allocations.grants {
+		c := g.GetContainer()
+		switch {
+		case c.GetQOSClass() == corev1.PodQOSGuaranteed:
+			continue
+		case podPriority(c) >= priority:
+			continue
+		case g.CPUType() != cpuNormal:
+			continue
+		case g.GetCPUNode().IsRootNode() && g.ExclusiveCPUs().IsEmpty():
+			continue // nothing to gain, it already uses shared root CPUs
+		case len(p.grantContainers(g)) > 1:
+			continue // shared pod grants are left alone
+		case g.ColdStart() > 0:
+			continue // demotion would cut the cold start period short
+		}
+		candidates = append(candidates, g)
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		ci, cj := candidates[i].GetContainer(), candidates[j].GetContainer()
+		if pi, pj := podPriority(ci), podPriority(cj); pi != pj {
+			return pi < pj
+		}
+		if qi, qj := ci.GetQOSClass(), cj.GetQOSClass(); qi != qj {
+			return qi == corev1.PodQOSBestEffort
+		}
+		if ei, ej := candidates[i].ExclusiveCPUs().Size(), candidates[j].ExclusiveCPUs().Size(); ei != ej {
+			return ei > ej
+		}
+		return ci.GetCacheID() < cj.GetCacheID()
+	})
+
+	return candidates
+}
+
+// evictLowerPriority tries to make room for a request which fits no pool, by
+// moving lower-priority containers to shared CPUs in the root pool, one at a
+// time, until the request fits. If the request does not fit even after all
+// such containers have been moved, the original grants are restored. Returns
+// true if room was made.
+func (p *policy) evictLowerPriority(req Request, affinity map[int]int32) bool {
+	if !opt.PriorityEviction {
+		return false
+	}
+
+	candidates := p.evictionCandidates(req)
+	if len(candidates) == 0 {
+		return false
+	}
+
+	log.Info("* trying to make room for %s by demoting lower-priority containers",
+		req.GetContainer().PrettyName())
+
+	demoted := map[string]Grant{}
+	for _, old := range candidates {
+		c := old.GetContainer()
+		id := c.GetCacheID()
+
+		p.releasePool(c)
+
+		demotion := newRequest(c).(*request)
+		demotion.fraction = 1000*demotion.full + demotion.fraction
+		if cpu, ok := c.GetResourceRequirements().Requests[corev1.ResourceCPU]; ok {
+			demotion.fraction = int(cpu.MilliValue())
+		}
+		demotion.full = 0
+		demotion.isolate = false
+
+		g, err := p.root.FreeSupply().Allocate(demotion)
+		if err != nil {
+			log.Debug("  - failed to demote %s: %v", c.PrettyName(), err)
+			if err := p.reinstateGrants(map[string]Grant{id: old}); err != nil {
+				log.Error("failed to restore grant of %s: %v", c.PrettyName(), err)
+			}
+			continue
+		}
+
+		log.Info("  - demoted %s from %s to shared CPUs of %s",
+			c.PrettyName(), old.GetCPUNode().Name(), p.root.Name())
+
+		p.allocations.grants[id] = g
+		demoted[id] = old
+
+		if _, pools := p.sortPoolsByScore(req, affinity); len(pools) > 0 {
+			for id, old := range demoted {
+				p.applyGrant(p.allocations.grants[id])
+				p.demoted[id] = old.GetCPUNode().Name()
+			}
+			p.saveAllocations()
+			return true
+		}
+	}
+
+	log.Info("  - demoting lower-priority containers does not make room, restoring them")
+	for id := range demoted {
+		if g, ok := p.allocations.grants[id]; ok {
+			g.Release()
+			delete(p.allocations.grants, id)
+		}
+	}
+	if err := p.reinstateGrants(demoted); err != nil {
+		log.Error("failed to restore demoted grants: %v", err)
+	}
+	p.saveAllocations()
+
+	return false
+}
+
+// restoreDemoted tries to move demoted containers back to pools fitting their
+// original request, highest priority first, once there is room for them again.
+// Demoted containers which still do not fit are left on shared root CPUs.
+func (p *policy) restoreDemoted() {
+	if len(p.demoted) == 0 {
+		return
+	}
+
+	containers := []cache.Container{}
+	for id := range p.demoted {
+		c, ok := p.cache.LookupContainer(id)
+		if _, granted := p.allocations.grants[id]; !ok || !granted {
+			delete(p.demoted, id)
+			continue
+		}
+		containers = append(containers, c)
+	}
+	sort.Slice(containers, func(i, j int) bool {
+		if pi, pj := podPriority(containers[i]), podPriority(containers[j]); pi != pj {
+			return pi > pj
+		}
+		return containers[i].GetCacheID() < containers[j].GetCacheID()
+	})
+
+	for _, c := range containers {
+		id := c.GetCacheID()
+		affinity, err := p.calculatePoolAffinities(c)
+		if err != nil {
+			continue
+		}
+		if _, pools := p.sortPoolsByScore(newRequest(c), affinity); len(pools) == 0 {
+			continue
+		}
+
+		old, _ := p.releasePool(c)
+		g, err := p.allocatePool(c, p.demoted[id])
+		if err != nil {
+			log.Debug("failed to restore demoted %s: %v", c.PrettyName(), err)
+			if err := p.reinstateGrants(map[string]Grant{id: old}); err != nil {
+				log.Error("failed to restore grant of %s: %v", c.PrettyName(), err)
+			}
+			continue
+		}
+
+		log.Info("restored demoted %s from shared CPUs of %s to %s",
+			c.PrettyName(), p.root.Name(), g.GetCPUNode().Name())
+
+		delete(p.demoted, id)
+		p.applyGrant(g)
+		p.updateSharedAllocations(&g)
+	}
+	p.saveAllocations()
+}
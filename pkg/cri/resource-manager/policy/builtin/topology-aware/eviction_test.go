@@ -0,0 +1,160 @@
+// Copyright 2026 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package topologyaware
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/kubernetes"
+	policyapi "github.com/intel/cri-resource-manager/pkg/cri/resource-manager/policy"
+	system "github.com/intel/cri-resource-manager/pkg/sysfs"
+	"github.com/intel/cri-resource-manager/pkg/utils"
+	"github.com/intel/cri-resource-manager/pkg/utils/cpuset"
+	v1 "k8s.io/api/core/v1"
+	resapi "k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestEvictionCandidates(t *testing.T) {
+	policy := &policy{
+		allocations: allocations{
+			grants: make(map[string]Grant),
+		},
+	}
+	policy.allocations.policy = policy
+	root := policy.NewVirtualNode("root", nilnode)
+	leaf := policy.NewVirtualNode("leaf", root)
+
+	newContainer := func(id string, qos v1.PodQOSClass, priority string) *mockContainer {
+		pod := &mockPod{annotations: map[string]string{}}
+		if priority != "" {
+			pod.annotations[kubernetes.ResmgrKey(keyPodPriority)] = priority
+		}
+		return &mockContainer{
+			name:                     id,
+			returnValueForGetCacheID: id,
+			returnValueForQOSClass:   qos,
+			pod:                      pod,
+		}
+	}
+	addGrant := func(c *mockContainer, node Node, exclusive cpuset.CPUSet) {
+		policy.allocations.grants[c.GetCacheID()] = &grant{
+			container: c,
+			node:      node,
+			exclusive: exclusive,
+			cpuType:   cpuNormal,
+		}
+	}
+
+	addGrant(newContainer("guaranteed", v1.PodQOSGuaranteed, "0"), leaf, cpuset.New(1))
+	addGrant(newContainer("high-priority", v1.PodQOSBurstable, "2000"), leaf, cpuset.New(2))
+	addGrant(newContainer("root-shared", v1.PodQOSBestEffort, "0"), root, cpuset.New())
+	addGrant(newContainer("burstable", v1.PodQOSBurstable, "0"), leaf, cpuset.New())
+	addGrant(newContainer("burstable-exclusive", v1.PodQOSBurstable, "0"), leaf, cpuset.New(3, 4))
+	addGrant(newContainer("besteffort", v1.PodQOSBestEffort, "0"), leaf, cpuset.New())
+	addGrant(newContainer("low-priority", v1.PodQOSBurstable, "-10"), leaf, cpuset.New())
+
+	req := &request{container: newContainer("new", v1.PodQOSGuaranteed, "1000")}
+	expected := []string{"low-priority", "besteffort", "burstable-exclusive", "burstable"}
+
+	candidates := policy.evictionCandidates(req)
+	if len(candidates) != len(expected) {
+		t.Fatalf("expected %d eviction candidates, got %d: %v", len(expected), len(candidates), candidates)
+	}
+	for i, g := range candidates {
+		if id := g.GetContainer().GetCacheID(); id != expected[i] {
+			t.Errorf("expected eviction candidate #%d to be %s, got %s", i, expected[i], id)
+		}
+	}
+}
+
+func TestRestoreDemoted(t *testing.T) {
+	dir, err := os.MkdirTemp("", "cri-resource-manager-test-sysfs-")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+
+	err = utils.UncompressTbz2(path.Join("testdata", "sysfs.tar.bz2"), dir)
+	if err != nil {
+		panic(err)
+	}
+
+	sys, err := system.DiscoverSystemAt(path.Join(dir, "sysfs", "desktop", "sys"))
+	if err != nil {
+		panic(err)
+	}
+
+	demoted := &mockContainer{
+		name:                     "demoted",
+		returnValueForGetCacheID: "demoted",
+		returnValueForQOSClass:   v1.PodQOSBurstable,
+		returnValueForGetResourceRequirements: v1.ResourceRequirements{
+			Requests: v1.ResourceList{v1.ResourceCPU: resapi.MustParse("2")},
+		},
+		pod: &mockPod{name: "pod", annotations: map[string]string{}},
+	}
+	reserved, _ := resapi.ParseQuantity("750m")
+	policyOptions := &policyapi.BackendOptions{
+		Cache: &mockCache{
+			returnValue1ForLookupContainer: demoted,
+			returnValue2ForLookupContainer: true,
+		},
+		System: sys,
+		Reserved: policyapi.ConstraintSet{
+			policyapi.DomainCPU: reserved,
+		},
+	}
+	p := CreateTopologyAwarePolicy(policyOptions).(*policy)
+
+	// Take all but one sharable CPU exclusively, leaving no room for the
+	// original request of the demoted container.
+	supply := p.root.FreeSupply()
+	sharable := supply.SharableCPUs().List()
+	blocker := newGrant(p.root, &mockContainer{name: "blocker"}, cpuNormal,
+		cpuset.New(sharable[1:]...), 0, 0, nil, 0)
+	if err := supply.Reserve(blocker); err != nil {
+		t.Fatalf("failed to reserve exclusive CPUs: %v", err)
+	}
+
+	shared := newGrant(p.root, demoted, cpuNormal, cpuset.New(), 100, 0, nil, 0)
+	if err := supply.Reserve(shared); err != nil {
+		t.Fatalf("failed to reserve shared CPU: %v", err)
+	}
+	p.allocations.grants["demoted"] = shared
+	p.demoted["demoted"] = p.root.Name()
+
+	p.restoreDemoted()
+	if _, ok := p.demoted["demoted"]; !ok {
+		t.Fatalf("demoted container restored without room for it")
+	}
+	if g := p.allocations.grants["demoted"]; g != shared {
+		t.Fatalf("demoted grant changed without room for the container: %s", g)
+	}
+
+	supply.ReleaseCPU(blocker)
+	p.restoreDemoted()
+	if _, ok := p.demoted["demoted"]; ok {
+		t.Fatalf("demoted container not restored once there is room for it")
+	}
+	g, ok := p.allocations.grants["demoted"]
+	if !ok || g == shared {
+		t.Fatalf("demoted container not reallocated")
+	}
+	if g.ExclusiveCPUs().Size() != 2 {
+		t.Errorf("expected restored grant with 2 exclusive CPUs, got %s", g)
+	}
+}
@@ -15,6 +15,12 @@
 package topologyaware
 
 import (
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	resapi "k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/intel/cri-resource-manager/pkg/apis/resmgr"
 	config "github.com/intel/cri-resource-manager/pkg/config"
 )
 
@@ -30,10 +36,257 @@ type options struct {
 	PreferShared bool `json:"PreferSharedCPUs"`
 	// ReservedPoolNamespaces is a list of namespace globs that will be allocated to reserved CPUs
 	ReservedPoolNamespaces []string `json:"ReservedPoolNamespaces,omitempty"`
+	// IsolatedPoolNamespaces is a list of namespace globs that isolated CPUs are restricted to.
+	IsolatedPoolNamespaces []string `json:"IsolatedPoolNamespaces,omitempty"`
 	// ColocatePods causes all containers in a pod to have affinity for each other.
 	ColocatePods bool `json:"ColocatePods"`
 	// ColocateNamespaces causes all containers in a namespace to have affinity for each other.
 	ColocateNamespaces bool `json:"ColocateNamespaces"`
+	// ExclusiveCPURoundUp maps QoS classes to a fractional CPU threshold. A
+	// request of the given class with a fractional part at or above the
+	// threshold is rounded up to a full exclusive CPU.
+	ExclusiveCPURoundUp map[corev1.PodQOSClass]resapi.Quantity `json:"ExclusiveCPURoundUp,omitempty"`
+	// CpusetPartition causes the cgroups of pods with only exclusive CPUs to be
+	// turned into cgroup v2 cpuset partition roots.
+	CpusetPartition bool `json:"CpusetPartition"`
+	// CpusetExclusive causes the exclusive CPUs of containers to be written
+	// to cpuset.cpus.exclusive, if the kernel and the pod cgroup allow it.
+	CpusetExclusive bool `json:"CpusetExclusive"`
+	// HBMBandwidth is the bandwidth budget of a single HBM NUMA node, in bytes/s.
+	// Zero disables HBM bandwidth accounting.
+	HBMBandwidth resapi.Quantity `json:"HBMBandwidth,omitempty"`
+	// HBMBandwidthPerCPU is the estimated HBM bandwidth used per requested CPU, in bytes/s.
+	HBMBandwidthPerCPU resapi.Quantity `json:"HBMBandwidthPerCPU,omitempty"`
+	// ReservedCPUSharingThreshold lets shared allocations also use reserved
+	// CPUs while their utilization is below this percentage. Zero disables it.
+	ReservedCPUSharingThreshold int `json:"ReservedCPUSharingThreshold,omitempty"`
+	// ExclusiveCPUReleaseDelay is the grace period before released exclusive CPUs are reallocated.
+	ExclusiveCPUReleaseDelay config.Duration `json:"ExclusiveCPUReleaseDelay,omitempty"`
+	// MaxContainersPerPool limits the number of containers in any pool, including its children.
+	MaxContainersPerPool int `json:"MaxContainersPerPool,omitempty"`
+	// MaxContainersByPool overrides MaxContainersPerPool for pools by name.
+	MaxContainersByPool map[string]int `json:"MaxContainersByPool,omitempty"`
+	// MaxActiveCPUs limits the number of CPUs activated by exclusive and shared
+	// allocations in pools by name. Exclusive CPUs are refused beyond the limit.
+	MaxActiveCPUs map[string]int `json:"MaxActiveCPUs,omitempty"`
+	// PowerBudget is the power envelope, in watts, of the CPU packages of pools by
+	// name. Exclusive CPUs are refused while RAPL readings exceed the budget.
+	PowerBudget map[string]float64 `json:"PowerBudget,omitempty"`
+	// MemorySpilloverOrder maps QoS classes to the order of memory types to allocate from,
+	// for instance "dram,hbm,pmem". The default order is "pmem,dram,hbm".
+	MemorySpilloverOrder map[corev1.PodQOSClass]string `json:"MemorySpilloverOrder,omitempty"`
+	// MemoryTypeFallback maps memory types ("dram", "pmem", "hbm") to the handling
+	// of pools lacking them: "fail", "nearest" (the default) or "any".
+	MemoryTypeFallback map[string]memoryFallback `json:"MemoryTypeFallback,omitempty"`
+	// PreferLocality resolves conflicts between CPU and memory locality in pool scoring.
+	PreferLocality locality `json:"PreferLocality,omitempty"`
+	// PriorityEviction lets containers which fit no pool demote the grants of
+	// lower-priority BestEffort and Burstable containers to shared root CPUs,
+	// until there is room for them again.
+	PriorityEviction bool `json:"PriorityEviction,omitempty"`
+	// AvoidIRQCPUs is a set of CPUs exclusive allocations avoid unless necessary,
+	// or "auto" to avoid CPUs handling far more interrupts than the median CPU.
+	AvoidIRQCPUs string `json:"AvoidIRQCPUs,omitempty"`
+	// PreferLLCLocality biases exclusive CPU allocation towards the last-level
+	// caches closest to the memory nodes of the allocation.
+	PreferLLCLocality bool `json:"PreferLLCLocality,omitempty"`
+	// DedicatedHugePageNodes is a list of NUMA nodes whose hugepages are dedicated
+	// to hugepage workloads and not counted in the memory of regular grants.
+	DedicatedHugePageNodes []int `json:"DedicatedHugePageNodes,omitempty"`
+	// ReserveSMTSiblings extends the reserved CPUs with their SMT siblings.
+	ReserveSMTSiblings bool `json:"ReserveSMTSiblings,omitempty"`
+	// PrePinnedContainers controls how containers created with a cpuset already set are handled.
+	PrePinnedContainers prePinnedMode `json:"PrePinnedContainers,omitempty"`
+	// SharedOnlyPods is a list of pod selectors. Containers of matching pods only get shared CPUs.
+	SharedOnlyPods []*resmgr.Expression `json:"SharedOnlyPods,omitempty"`
+	// SharedOnlyJobPods presumes pods of Jobs short-lived and only gives them shared CPUs.
+	SharedOnlyJobPods bool `json:"SharedOnlyJobPods,omitempty"`
+	// PoolLevels controls which of the "die" and "numa" topology levels get pools.
+	PoolLevels map[string]poolLevelMode `json:"PoolLevels,omitempty"`
+	// InitialPlacement controls how exclusive allocations of containers synchronized
+	// at startup are placed, "packed" by pool score or "balanced" across the pools.
+	InitialPlacement placementMode `json:"InitialPlacement,omitempty"`
+}
+
+// placementMode controls the initial placement of exclusive allocations.
+type placementMode string
+
+const (
+	// packedPlacement places containers in the best scoring pools.
+	packedPlacement placementMode = "packed"
+	// balancedPlacement spreads containers round-robin across the top-level pools.
+	balancedPlacement placementMode = "balanced"
+)
+
+// initialPlacement returns the configured initial placement mode.
+func initialPlacement() placementMode {
+	if opt.InitialPlacement == "" {
+		return packedPlacement
+	}
+	return opt.InitialPlacement
+}
+
+// poolLevelMode controls whether pools are created for a topology level.
+type poolLevelMode string
+
+const (
+	// autoPoolLevel creates pools for a level unless they would be lone children.
+	autoPoolLevel poolLevelMode = "auto"
+	// alwaysPoolLevel always creates pools for a level.
+	alwaysPoolLevel poolLevelMode = "always"
+	// neverPoolLevel never creates pools for a level.
+	neverPoolLevel poolLevelMode = "never"
+
+	// diePoolLevel is the topology level of CPU dies.
+	diePoolLevel = "die"
+	// numaPoolLevel is the topology level of NUMA nodes.
+	numaPoolLevel = "numa"
+)
+
+// poolLevel returns the configured pool creation mode for a topology level.
+func poolLevel(level string) poolLevelMode {
+	if mode, ok := opt.PoolLevels[level]; ok && mode != "" {
+		return mode
+	}
+	return autoPoolLevel
+}
+
+// locality is the preferred kind of locality when pool CPU and memory scores disagree.
+type locality string
+
+const (
+	// preferCPULocality lets CPU locality and capacity decide before memory.
+	preferCPULocality locality = "cpu"
+	// preferMemoryLocality lets memory type and capacity decide before CPU.
+	preferMemoryLocality locality = "memory"
+	// preferBalancedLocality is the default scoring.
+	preferBalancedLocality locality = "balanced"
+)
+
+// localityPreference returns the configured locality preference.
+func localityPreference() locality {
+	if opt.PreferLocality == "" {
+		return preferBalancedLocality
+	}
+	return opt.PreferLocality
+}
+
+// memoryFallback controls the memory used if a pool lacks the requested type.
+type memoryFallback string
+
+const (
+	// failMemoryFallback fails allocations from pools lacking the requested memory type.
+	failMemoryFallback memoryFallback = "fail"
+	// nearestMemoryFallback falls back to the nearest other memory type of the pool.
+	nearestMemoryFallback memoryFallback = "nearest"
+	// anyMemoryFallback falls back to all memory of the pool.
+	anyMemoryFallback memoryFallback = "any"
+)
+
+// memoryTypeFallback returns the configured fallback for a memory type. For
+// a combination of types the strictest fallback of any of them is used.
+func memoryTypeFallback(mt memoryType) memoryFallback {
+	fallback := anyMemoryFallback
+	for _, bit := range []memoryType{memoryDRAM, memoryPMEM, memoryHBM} {
+		if mt&bit == 0 {
+			continue
+		}
+		switch opt.MemoryTypeFallback[strings.ToLower(memoryTypeNames[bit])] {
+		case failMemoryFallback:
+			return failMemoryFallback
+		case anyMemoryFallback:
+		default:
+			fallback = nearestMemoryFallback
+		}
+	}
+	return fallback
+}
+
+// prePinnedMode controls how containers created with a cpuset already set are handled.
+type prePinnedMode string
+
+const (
+	// overwritePrePinned allocates resources for pre-pinned containers like for any other.
+	overwritePrePinned prePinnedMode = "overwrite"
+	// respectPrePinned leaves pre-pinned containers alone and keeps their CPUs out of grants.
+	respectPrePinned prePinnedMode = "respect"
+)
+
+// prePinnedContainers returns the configured handling of pre-pinned containers.
+func prePinnedContainers() prePinnedMode {
+	if opt.PrePinnedContainers == "" {
+		return overwritePrePinned
+	}
+	return opt.PrePinnedContainers
+}
+
+// validate checks the options for invalid values. It has no side effects, so
+// a rejected configuration never takes effect in the policy.
+func (o *options) validate() error {
+	switch o.PrePinnedContainers {
+	case "", overwritePrePinned, respectPrePinned:
+	default:
+		return policyError("invalid handling of pre-pinned containers %q, expecting %q or %q",
+			o.PrePinnedContainers, overwritePrePinned, respectPrePinned)
+	}
+	for _, expr := range o.SharedOnlyPods {
+		if err := expr.Validate(); err != nil {
+			return policyError("invalid shared-only pod selector %s: %v", expr, err)
+		}
+	}
+	for pool, limit := range o.MaxActiveCPUs {
+		if limit < 0 {
+			return policyError("invalid max. active CPUs %d for pool %s", limit, pool)
+		}
+	}
+	for pool, budget := range o.PowerBudget {
+		if budget <= 0 {
+			return policyError("invalid power budget %f for pool %s", budget, pool)
+		}
+	}
+	for qos, value := range o.MemorySpilloverOrder {
+		if _, err := parseMemorySpillover(value); err != nil {
+			return policyError("invalid memory spillover order for %s: %v", qos, err)
+		}
+	}
+	for name, fallback := range o.MemoryTypeFallback {
+		if mt, ok := memoryNamedTypes[name]; !ok || mt == memoryAll {
+			return policyError("invalid memory type %q for fallback, expecting dram, pmem or hbm", name)
+		}
+		switch fallback {
+		case failMemoryFallback, nearestMemoryFallback, anyMemoryFallback:
+		default:
+			return policyError("invalid %s memory fallback %q, expecting %q, %q or %q",
+				name, fallback, failMemoryFallback, nearestMemoryFallback, anyMemoryFallback)
+		}
+	}
+	for level, mode := range o.PoolLevels {
+		switch level {
+		case diePoolLevel, numaPoolLevel:
+		default:
+			return policyError("invalid pool level %q, expecting %q or %q",
+				level, diePoolLevel, numaPoolLevel)
+		}
+		switch mode {
+		case "", autoPoolLevel, alwaysPoolLevel, neverPoolLevel:
+		default:
+			return policyError("invalid mode %q for pool level %s, expecting %q, %q or %q",
+				mode, level, autoPoolLevel, alwaysPoolLevel, neverPoolLevel)
+		}
+	}
+	switch o.PreferLocality {
+	case "", preferCPULocality, preferMemoryLocality, preferBalancedLocality:
+	default:
+		return policyError("invalid locality preference %q, expecting %q, %q or %q",
+			o.PreferLocality, preferCPULocality, preferMemoryLocality, preferBalancedLocality)
+	}
+	switch o.InitialPlacement {
+	case "", packedPlacement, balancedPlacement:
+	default:
+		return policyError("invalid initial placement %q, expecting %q or %q",
+			o.InitialPlacement, packedPlacement, balancedPlacement)
+	}
+	return nil
 }
 
 // Our runtime configuration.
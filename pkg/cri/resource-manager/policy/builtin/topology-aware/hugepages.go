@@ -0,0 +1,234 @@
+// Copyright 2026 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package topologyaware
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	resapi "k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/cache"
+	idset "github.com/intel/goresctrl/pkg/utils"
+)
+
+// hugePageRequests returns the hugepage requests of the container in bytes, by page size.
+func hugePageRequests(c cache.Container) map[uint64]uint64 {
+	requests := map[uint64]uint64{}
+	for name, qty := range c.GetResourceRequirements().Limits {
+		if !strings.HasPrefix(string(name), corev1.ResourceHugePagesPrefix) {
+			continue
+		}
+		size, err := resapi.ParseQuantity(strings.TrimPrefix(string(name), corev1.ResourceHugePagesPrefix))
+		if err != nil || size.Value() <= 0 {
+			log.Error("%s: invalid hugepage resource %q", c.PrettyName(), name)
+			continue
+		}
+		if qty.Value() > 0 {
+			requests[uint64(size.Value())] = uint64(qty.Value())
+		}
+	}
+	return requests
+}
+
+// hugePageSizeString returns the CRI notation for the page size (for instance "2MB").
+func hugePageSizeString(size uint64) string {
+	switch {
+	case size >= 1<<30 && size%(1<<30) == 0:
+		return fmt.Sprintf("%dGB", size>>30)
+	case size >= 1<<20 && size%(1<<20) == 0:
+		return fmt.Sprintf("%dMB", size>>20)
+	default:
+		return fmt.Sprintf("%dKB", size>>10)
+	}
+}
+
+// freeHugePages returns the amount of hugepages of the given size in the nodes,
+// in bytes, which are free and not yet granted to containers other than c.
+func (p *policy) freeHugePages(c cache.Container, memset idset.IDSet, size uint64) uint64 {
+	free := uint64(0)
+	for _, id := range memset.Members() {
+		pages, err := p.sys.Node(id).FreeHugePages(size)
+		if err != nil {
+			log.Debug("failed to get free %s hugepages of node #%d: %v", hugePageSizeString(size), id, err)
+			continue
+		}
+		free += pages * size
+	}
+	if granted := p.grantedHugePages(c, memset, size); granted < free {
+		free -= granted
+	} else {
+		free = 0
+	}
+	return free
+}
+
+// grantedHugePages returns the amount of hugepages of the given size, in bytes,
+// requested by containers other than c with grants using any of the nodes. Only
+// containers which are not running yet are counted, as the hugepages of running
+// containers are already reflected in the free hugepages of the nodes.
+func (p *policy) grantedHugePages(c cache.Container, memset idset.IDSet, size uint64) uint64 {
+	granted := uint64(0)
+	for id, grant := range p.allocations.grants {
+		if c != nil && id == c.GetCacheID() {
+			continue
+		}
+		owner := grant.GetContainer()
+		if owner.GetState() == cache.ContainerStateRunning {
+			continue
+		}
+		amount, ok := hugePageRequests(owner)[size]
+		if !ok {
+			continue
+		}
+		for _, node := range grant.Memset().Members() {
+			if memset.Has(node) {
+				granted += amount
+				break
+			}
+		}
+	}
+	return granted
+}
+
+// hasFreeHugePages checks if the nodes have enough free hugepages for the requests of c.
+func (p *policy) hasFreeHugePages(c cache.Container, memset idset.IDSet, requests map[uint64]uint64) bool {
+	for size, amount := range requests {
+		if free := p.freeHugePages(c, memset, size); free < amount {
+			log.Debug("  - nodes %s have %s free %s hugepages, %s requested",
+				memset, prettyMem(free), hugePageSizeString(size), prettyMem(amount))
+			return false
+		}
+	}
+	return true
+}
+
+// bindHugePages makes sure the hugepage requests of the container can be
+// satisfied from the memory nodes of the grant. If the memory node of the
+// grant does not have enough free hugepages, the grant is moved up in the
+// tree to the closest ancestor which does.
+func (p *policy) bindHugePages(grant Grant) error {
+	c := grant.GetContainer()
+	requests := hugePageRequests(c)
+	if len(requests) == 0 {
+		return nil
+	}
+
+	log.Debug("* checking free hugepages for %s", c.PrettyName())
+
+	memType := grant.MemoryType()
+	node := grant.GetMemoryNode()
+	for ; !node.IsNil(); node = node.Parent() {
+		if p.hasFreeHugePages(c, node.GetMemset(memType), requests) {
+			break
+		}
+	}
+	if node.IsNil() {
+		return policyError("not enough free hugepages for %s", c.PrettyName())
+	}
+
+	if node.NodeID() != grant.GetMemoryNode().NodeID() {
+		log.Warn("%s: not enough free hugepages in %s, falling back to %s",
+			c.PrettyName(), grant.GetMemoryNode().Name(), node.Name())
+		if err := node.FreeSupply().ReallocateMemory(grant); err != nil {
+			return policyError("failed to move memory of %s to %s: %v",
+				c.PrettyName(), node.Name(), err)
+		}
+		grant.SetMemoryNode(node)
+		grant.UpdateExtraMemoryReservation()
+		p.pushedUpGrants++
+	}
+
+	return nil
+}
+
+// grantMems returns the memory nodes to pin the containers of the grant to,
+// or an empty string if memory is not pinned. Containers requesting hugepages
+// are pinned even if PinMemory is disabled, as their hugepages come from the
+// memory nodes they are pinned to.
+func grantMems(grant Grant) string {
+	if opt.PinMemory {
+		return grant.Memset().String()
+	}
+	if len(hugePageRequests(grant.GetContainer())) > 0 {
+		log.Debug("  => binding hugepages of %s to memory %s",
+			grant.GetContainer().PrettyName(), grant.Memset())
+		return grant.Memset().String()
+	}
+	return ""
+}
+
+// dedicatedHugePageNodes returns the NUMA nodes whose hugepages are dedicated to hugepage workloads.
+func dedicatedHugePageNodes() idset.IDSet {
+	nodes := idset.NewIDSet()
+	for _, id := range opt.DedicatedHugePageNodes {
+		nodes.Add(idset.ID(id))
+	}
+	return nodes
+}
+
+// normalMemory returns the amount of memory of the NUMA node available for
+// regular memory grants. If the hugepages of the node are dedicated to hugepage
+// workloads, they are not counted in.
+func (p *policy) normalMemory(id idset.ID, total uint64) uint64 {
+	if !p.hugePageNodes.Has(id) {
+		return total
+	}
+	hugepages, err := p.sys.Node(id).TotalHugePages()
+	if err != nil {
+		log.Error("failed to get hugepages of NUMA node #%d: %v", id, err)
+		return total
+	}
+	if hugepages > total {
+		hugepages = total
+	}
+	log.Debug("dedicating %s of hugepages of NUMA node #%d to hugepage workloads",
+		prettyMem(hugepages), id)
+	return total - hugepages
+}
+
+// preferHugePageNodes steers containers requesting hugepages to the pools with
+// dedicated hugepage nodes, as long as they have enough free hugepages. These
+// pools are moved first, otherwise the order of the pools is preserved.
+func (p *policy) preferHugePageNodes(c cache.Container, pools []Node) []Node {
+	if p.hugePageNodes.Size() == 0 {
+		return pools
+	}
+	requests := hugePageRequests(c)
+	if len(requests) == 0 {
+		return pools
+	}
+
+	preferred := make([]Node, 0, len(pools))
+	others := make([]Node, 0, len(pools))
+	for _, pool := range pools {
+		dedicated := idset.NewIDSet()
+		for _, id := range pool.GetMemset(memoryAll).Members() {
+			if p.hugePageNodes.Has(id) {
+				dedicated.Add(id)
+			}
+		}
+		if dedicated.Size() > 0 && p.hasFreeHugePages(c, dedicated, requests) {
+			preferred = append(preferred, pool)
+		} else {
+			others = append(others, pool)
+		}
+	}
+	if len(preferred) > 0 {
+		log.Debug("* %s: preferring pools with dedicated hugepages", c.PrettyName())
+	}
+	return append(preferred, others...)
+}
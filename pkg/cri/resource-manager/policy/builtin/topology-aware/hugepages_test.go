@@ -0,0 +1,173 @@
+// Copyright 2026 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package topologyaware
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	resapi "k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/cache"
+	system "github.com/intel/cri-resource-manager/pkg/sysfs"
+	idset "github.com/intel/goresctrl/pkg/utils"
+)
+
+func TestHugePageRequests(t *testing.T) {
+	c := &mockContainer{
+		returnValueForGetResourceRequirements: v1.ResourceRequirements{
+			Limits: v1.ResourceList{
+				v1.ResourceCPU:    resapi.MustParse("2"),
+				v1.ResourceMemory: resapi.MustParse("1Gi"),
+				"hugepages-1Gi":   resapi.MustParse("4Gi"),
+				"hugepages-2Mi":   resapi.MustParse("64Mi"),
+			},
+		},
+	}
+
+	requests := hugePageRequests(c)
+	expected := map[uint64]uint64{
+		1 << 30: 4 << 30,
+		2 << 20: 64 << 20,
+	}
+	if len(requests) != len(expected) {
+		t.Fatalf("expected hugepage requests %v, got %v", expected, requests)
+	}
+	for size, amount := range expected {
+		if requests[size] != amount {
+			t.Errorf("expected %d bytes of %s hugepages, got %d",
+				amount, hugePageSizeString(size), requests[size])
+		}
+	}
+
+	for size, str := range map[uint64]string{1 << 30: "1GB", 2 << 20: "2MB", 64 << 10: "64KB"} {
+		if s := hugePageSizeString(size); s != str {
+			t.Errorf("expected page size %d to be %q, got %q", size, str, s)
+		}
+	}
+}
+
+func TestDedicatedHugePages(t *testing.T) {
+	p := &policy{
+		sys: &mockSystem{
+			nodes: []system.Node{
+				&mockSystemNode{id: 0, memTotal: 16 << 30, hugePages: 8 << 30, freeHugePages: 8},
+				&mockSystemNode{id: 1, memTotal: 16 << 30, hugePages: 2 << 30},
+			},
+		},
+		hugePageNodes: idset.NewIDSet(0),
+	}
+
+	if mem := p.normalMemory(0, 16<<30); mem != 8<<30 {
+		t.Errorf("expected 8G of normal memory in node #0, got %d", mem)
+	}
+	if mem := p.normalMemory(1, 16<<30); mem != 16<<30 {
+		t.Errorf("expected 16G of normal memory in node #1, got %d", mem)
+	}
+
+	node0 := &numanode{node: node{name: "node0", mem: idset.NewIDSet(0), pMem: idset.NewIDSet(), hbm: idset.NewIDSet()}}
+	node1 := &numanode{node: node{name: "node1", mem: idset.NewIDSet(1), pMem: idset.NewIDSet(), hbm: idset.NewIDSet()}}
+	pools := []Node{node1, node0}
+
+	regular := &mockContainer{name: "regular"}
+	if got := p.preferHugePageNodes(regular, pools); got[0] != node1 {
+		t.Errorf("expected regular container to keep pool order, got %s first", got[0].Name())
+	}
+
+	hugepages := &mockContainer{
+		name: "hugepages",
+		returnValueForGetResourceRequirements: v1.ResourceRequirements{
+			Limits: v1.ResourceList{
+				"hugepages-1Gi": resapi.MustParse("4Gi"),
+			},
+		},
+	}
+	if got := p.preferHugePageNodes(hugepages, pools); got[0] != node0 || got[1] != node1 {
+		t.Errorf("expected hugepage container to prefer node0, got %s first", got[0].Name())
+	}
+
+	p.sys.Node(0).(*mockSystemNode).freeHugePages = 2
+	if got := p.preferHugePageNodes(hugepages, pools); got[0] != node1 {
+		t.Errorf("expected pool order kept without enough free hugepages, got %s first", got[0].Name())
+	}
+}
+
+func TestGrantedHugePages(t *testing.T) {
+	p := &policy{
+		sys: &mockSystem{
+			nodes: []system.Node{
+				&mockSystemNode{id: 0, freeHugePages: 8},
+				&mockSystemNode{id: 1, freeHugePages: 8},
+			},
+		},
+	}
+	p.allocations = p.newAllocations()
+	hugepages := func(name, cacheID string, state cache.ContainerState) *mockContainer {
+		return &mockContainer{
+			name:                     name,
+			returnValueForGetCacheID: cacheID,
+			state:                    state,
+			returnValueForGetResourceRequirements: v1.ResourceRequirements{
+				Limits: v1.ResourceList{
+					"hugepages-1Gi": resapi.MustParse("3Gi"),
+				},
+			},
+		}
+	}
+	pending := hugepages("pending", "pending", cache.ContainerStateCreated)
+	running := hugepages("running", "running", cache.ContainerStateRunning)
+	p.allocations.grants["pending"] = &grant{container: pending, memset: idset.NewIDSet(0)}
+	p.allocations.grants["running"] = &grant{container: running, memset: idset.NewIDSet(0)}
+
+	other := hugepages("other", "other", cache.ContainerStateCreated)
+	if free := p.freeHugePages(other, idset.NewIDSet(0), 1<<30); free != 5<<30 {
+		t.Errorf("expected 5G of free hugepages in node #0 with a pending grant, got %d", free)
+	}
+	if free := p.freeHugePages(pending, idset.NewIDSet(0), 1<<30); free != 8<<30 {
+		t.Errorf("expected own grant not to count, got %d free", free)
+	}
+	if free := p.freeHugePages(other, idset.NewIDSet(1), 1<<30); free != 8<<30 {
+		t.Errorf("expected 8G of free hugepages in node #1, got %d", free)
+	}
+	if p.hasFreeHugePages(other, idset.NewIDSet(0), map[uint64]uint64{1 << 30: 6 << 30}) {
+		t.Errorf("expected pending grant to leave too few hugepages in node #0")
+	}
+}
+
+func TestGrantMems(t *testing.T) {
+	defer func(saved bool) { opt.PinMemory = saved }(opt.PinMemory)
+	hugepages := &grant{
+		container: &mockContainer{
+			name: "hugepages",
+			returnValueForGetResourceRequirements: v1.ResourceRequirements{
+				Limits: v1.ResourceList{"hugepages-1Gi": resapi.MustParse("2Gi")},
+			},
+		},
+		memset: idset.NewIDSet(1),
+	}
+	regular := &grant{container: &mockContainer{name: "regular"}, memset: idset.NewIDSet(1)}
+
+	opt.PinMemory = true
+	if mems := grantMems(regular); mems != "1" {
+		t.Errorf("expected memory to be pinned to node 1, got %q", mems)
+	}
+	opt.PinMemory = false
+	if mems := grantMems(regular); mems != "" {
+		t.Errorf("expected memory not to be pinned, got %q", mems)
+	}
+	if mems := grantMems(hugepages); mems != "1" {
+		t.Errorf("expected hugepages to be bound to node 1 without PinMemory, got %q", mems)
+	}
+}
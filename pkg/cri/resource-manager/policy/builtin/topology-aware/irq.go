@@ -0,0 +1,138 @@
+// Copyright 2026 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package topologyaware
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/intel/cri-resource-manager/pkg/utils/cpuset"
+)
+
+const (
+	// autoIRQCPUs is the AvoidIRQCPUs value for detecting IRQ-heavy CPUs.
+	autoIRQCPUs = "auto"
+	// irqOutlierFactor is how many times the median interrupt count a CPU
+	// must exceed to be considered IRQ-heavy.
+	irqOutlierFactor = 2
+)
+
+// procInterrupts is the file to read interrupt counts from.
+var procInterrupts = "/proc/interrupts"
+
+// updateIRQCPUs updates the set of CPUs exclusive allocations should avoid.
+func (p *policy) updateIRQCPUs() error {
+	switch opt.AvoidIRQCPUs {
+	case "":
+		p.irqCPUs = cpuset.New()
+	case autoIRQCPUs:
+		f, err := os.Open(procInterrupts)
+		if err != nil {
+			return policyError("failed to detect IRQ CPUs: %v", err)
+		}
+		defer f.Close()
+		counts, err := parseInterrupts(f)
+		if err != nil {
+			return policyError("failed to detect IRQ CPUs: %v", err)
+		}
+		p.irqCPUs = irqHeavyCPUs(counts)
+	default:
+		cset, err := cpuset.Parse(opt.AvoidIRQCPUs)
+		if err != nil {
+			return policyError("invalid IRQ CPUs %q: %v", opt.AvoidIRQCPUs, err)
+		}
+		p.irqCPUs = cset
+	}
+	return nil
+}
+
+// parseInterrupts parses interrupt counts in /proc/interrupts format and
+// returns the total number of device interrupts handled by each CPU.
+// Architecture-specific per-CPU interrupts (timers, IPIs, etc.), which
+// have a non-numeric name, are ignored.
+func parseInterrupts(r io.Reader) (map[int]uint64, error) {
+	scanner := bufio.NewScanner(r)
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+		return nil, policyError("missing interrupt header")
+	}
+
+	cpus := []int{}
+	for _, column := range strings.Fields(scanner.Text()) {
+		id, err := strconv.Atoi(strings.TrimPrefix(column, "CPU"))
+		if err != nil {
+			return nil, policyError("invalid interrupt header column %q", column)
+		}
+		cpus = append(cpus, id)
+	}
+
+	counts := make(map[int]uint64, len(cpus))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 1 {
+			continue
+		}
+		if _, err := strconv.Atoi(strings.TrimSuffix(fields[0], ":")); err != nil {
+			continue
+		}
+		for i, field := range fields[1:] {
+			if i >= len(cpus) {
+				break
+			}
+			count, err := strconv.ParseUint(field, 10, 64)
+			if err != nil {
+				break
+			}
+			counts[cpus[i]] += count
+		}
+	}
+
+	return counts, scanner.Err()
+}
+
+// irqHeavyCPUs returns the CPUs which are outliers in handling interrupts.
+// These handle more than irqOutlierFactor times the median count, and more
+// than the average. An even distribution has no IRQ-heavy CPUs, and a median
+// of zero does not make every CPU with some interrupts an outlier.
+func irqHeavyCPUs(counts map[int]uint64) cpuset.CPUSet {
+	if len(counts) == 0 {
+		return cpuset.New()
+	}
+
+	sorted := make([]uint64, 0, len(counts))
+	total := uint64(0)
+	for _, count := range counts {
+		sorted = append(sorted, count)
+		total += count
+	}
+	slices.Sort(sorted)
+	median := sorted[(len(sorted)-1)/2]
+	threshold := max(irqOutlierFactor*median, total/uint64(len(counts)))
+
+	heavy := []int{}
+	for id, count := range counts {
+		if count > threshold {
+			heavy = append(heavy, id)
+		}
+	}
+
+	return cpuset.New(heavy...)
+}
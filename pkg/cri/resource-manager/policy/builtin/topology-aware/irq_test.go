@@ -0,0 +1,85 @@
+// Copyright 2026 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package topologyaware
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestIRQHeavyCPUs(t *testing.T) {
+	interrupts := `           CPU0       CPU1       CPU2       CPU3
+  0:         40          0          0          0   IO-APIC   2-edge      timer
+  8:          0          0          0          0   IO-APIC   8-edge      rtc0
+ 24:     100000         10          5     200000   PCI-MSI 327680-edge      xhci_hcd
+ 25:         12         20         15         10   PCI-MSI 524288-edge      eth0
+NMI:        100        100        100        100   Non-maskable interrupts
+LOC:    9000000    9000000    9000000    9000000   Local timer interrupts
+ERR:          0
+`
+	counts, err := parseInterrupts(strings.NewReader(interrupts))
+	if err != nil {
+		t.Fatalf("failed to parse interrupts: %v", err)
+	}
+
+	expected := map[int]uint64{0: 100052, 1: 30, 2: 20, 3: 200010}
+	for cpu, count := range expected {
+		if counts[cpu] != count {
+			t.Errorf("expected %d interrupts for CPU%d, got %d", count, cpu, counts[cpu])
+		}
+	}
+
+	if heavy := irqHeavyCPUs(counts); heavy.String() != "0,3" {
+		t.Errorf("expected IRQ-heavy CPUs 0,3, got %s", heavy)
+	}
+
+	tcases := []struct {
+		name     string
+		counts   map[int]uint64
+		expected string
+	}{
+		{
+			name:     "even distribution",
+			counts:   map[int]uint64{0: 1000, 1: 1000, 2: 1000, 3: 1000},
+			expected: "",
+		},
+		{
+			name:     "nearly even distribution",
+			counts:   map[int]uint64{0: 1100, 1: 900, 2: 1050, 3: 950},
+			expected: "",
+		},
+		{
+			name:     "single outlier",
+			counts:   map[int]uint64{0: 1000, 1: 1200, 2: 9000, 3: 900},
+			expected: "2",
+		},
+		{
+			name:     "mostly idle CPUs",
+			counts:   map[int]uint64{0: 0, 1: 3, 2: 0, 3: 500, 4: 0},
+			expected: "3",
+		},
+	}
+	for _, tc := range tcases {
+		t.Run(tc.name, func(t *testing.T) {
+			if heavy := irqHeavyCPUs(tc.counts); heavy.String() != tc.expected {
+				t.Errorf("expected IRQ-heavy CPUs %q, got %q", tc.expected, heavy)
+			}
+		})
+	}
+
+	if _, err := parseInterrupts(strings.NewReader("           CPU0  bogus\n")); err == nil {
+		t.Errorf("expected invalid interrupt header to fail")
+	}
+}
@@ -0,0 +1,143 @@
+// Copyright 2026 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package topologyaware
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/intel/cri-resource-manager/pkg/utils/cpuset"
+	idset "github.com/intel/goresctrl/pkg/utils"
+)
+
+// sysCPUDir is the sysfs directory to discover CPU caches from.
+var sysCPUDir = "/sys/devices/system/cpu"
+
+// updateLLCs discovers the last-level cache domains of our CPUs, if needed.
+// The domains are rediscovered whenever the policy is (re)initialized, as
+// the set of allowed CPUs may have changed.
+func (p *policy) updateLLCs() error {
+	if !opt.PreferLLCLocality {
+		p.llcs = nil
+		return nil
+	}
+	if p.llcs != nil {
+		return nil
+	}
+	llcs, err := discoverLLCs(sysCPUDir, p.allowed)
+	if err != nil {
+		return policyError("failed to discover last-level caches: %v", err)
+	}
+	p.llcs = llcs
+	return nil
+}
+
+// discoverLLCs returns the sets of CPUs sharing a last-level cache.
+func discoverLLCs(dir string, cpus cpuset.CPUSet) ([]cpuset.CPUSet, error) {
+	llcs := []cpuset.CPUSet{}
+	seen := map[string]struct{}{}
+	for _, id := range cpus.List() {
+		indices, err := filepath.Glob(filepath.Join(dir, "cpu"+strconv.Itoa(id), "cache", "index*"))
+		if err != nil {
+			return nil, err
+		}
+		shared, maxLevel := "", 0
+		for _, index := range indices {
+			kind, err := readSysfsEntry(filepath.Join(index, "type"))
+			if err != nil || kind == "Instruction" {
+				continue
+			}
+			entry, err := readSysfsEntry(filepath.Join(index, "level"))
+			if err != nil {
+				continue
+			}
+			level, err := strconv.Atoi(entry)
+			if err != nil || level <= maxLevel {
+				continue
+			}
+			if entry, err = readSysfsEntry(filepath.Join(index, "shared_cpu_list")); err != nil {
+				continue
+			}
+			shared, maxLevel = entry, level
+		}
+		if shared == "" {
+			continue
+		}
+		if _, ok := seen[shared]; ok {
+			continue
+		}
+		seen[shared] = struct{}{}
+		cset, err := cpuset.Parse(shared)
+		if err != nil {
+			return nil, err
+		}
+		llcs = append(llcs, cset.Intersection(cpus))
+	}
+	return llcs, nil
+}
+
+func readSysfsEntry(path string) (string, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(buf)), nil
+}
+
+// llcPreferredCPUs returns the subset of CPUs to take cnt exclusive CPUs from
+// to stay within the last-level cache domains closest to the given memory
+// nodes. A single domain with enough free CPUs is preferred, the one with the
+// fewest free CPUs first, to keep larger domains available for larger requests.
+// An empty set is returned if there is no preference.
+func (p *policy) llcPreferredCPUs(from cpuset.CPUSet, cnt int, mems idset.IDSet) cpuset.CPUSet {
+	if len(p.llcs) == 0 {
+		return cpuset.New()
+	}
+
+	local := cpuset.New()
+	for _, id := range mems.Members() {
+		if node := p.sys.Node(id); node != nil {
+			local = local.Union(node.CPUSet())
+		}
+	}
+
+	near := cpuset.New()
+	for _, llc := range p.llcs {
+		if local.IsEmpty() || !llc.Intersection(local).IsEmpty() {
+			near = near.Union(llc.Intersection(from))
+		}
+	}
+
+	best := cpuset.New()
+	for _, llc := range p.llcs {
+		free := llc.Intersection(near)
+		if free.Size() < cnt {
+			continue
+		}
+		if best.IsEmpty() || free.Size() < best.Size() {
+			best = free
+		}
+	}
+
+	switch {
+	case !best.IsEmpty():
+		return best
+	case near.Size() >= cnt:
+		return near
+	}
+	return cpuset.New()
+}
@@ -0,0 +1,129 @@
+// Copyright 2026 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package topologyaware
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	system "github.com/intel/cri-resource-manager/pkg/sysfs"
+	"github.com/intel/cri-resource-manager/pkg/utils/cpuset"
+	idset "github.com/intel/goresctrl/pkg/utils"
+)
+
+func TestDiscoverLLCs(t *testing.T) {
+	dir := t.TempDir()
+	// 8 CPUs, private L1/L2 caches, two L3 caches shared by 0-3 and 4-7.
+	for cpu := 0; cpu < 8; cpu++ {
+		l3 := "0-3"
+		if cpu >= 4 {
+			l3 = "4-7"
+		}
+		for index, entries := range []map[string]string{
+			{"type": "Data", "level": "1", "shared_cpu_list": strconv.Itoa(cpu)},
+			{"type": "Instruction", "level": "1", "shared_cpu_list": strconv.Itoa(cpu)},
+			{"type": "Unified", "level": "2", "shared_cpu_list": strconv.Itoa(cpu)},
+			{"type": "Unified", "level": "3", "shared_cpu_list": l3},
+		} {
+			path := filepath.Join(dir, "cpu"+strconv.Itoa(cpu), "cache", "index"+strconv.Itoa(index))
+			if err := os.MkdirAll(path, 0755); err != nil {
+				t.Fatalf("failed to create %s: %v", path, err)
+			}
+			for name, value := range entries {
+				if err := os.WriteFile(filepath.Join(path, name), []byte(value+"\n"), 0644); err != nil {
+					t.Fatalf("failed to write %s/%s: %v", path, name, err)
+				}
+			}
+		}
+	}
+
+	llcs, err := discoverLLCs(dir, cpuset.MustParse("1-7"))
+	if err != nil {
+		t.Fatalf("failed to discover LLCs: %v", err)
+	}
+	if len(llcs) != 2 || llcs[0].String() != "1-3" || llcs[1].String() != "4-7" {
+		t.Errorf("expected LLCs [1-3 4-7], got %v", llcs)
+	}
+}
+
+func TestLLCPreferredCPUs(t *testing.T) {
+	p := &policy{
+		sys: &mockSystem{
+			nodes: []system.Node{
+				&mockSystemNode{id: 0, cpus: cpuset.MustParse("0-7")},
+				&mockSystemNode{id: 1, cpus: cpuset.MustParse("8-15")},
+			},
+		},
+		llcs: []cpuset.CPUSet{
+			cpuset.MustParse("0-3"),
+			cpuset.MustParse("4-7"),
+			cpuset.MustParse("8-11"),
+			cpuset.MustParse("12-15"),
+		},
+	}
+
+	tcases := []struct {
+		name     string
+		from     string
+		cnt      int
+		mems     []idset.ID
+		expected string
+	}{
+		{
+			name:     "best fitting LLC of the memory node",
+			from:     "0-15",
+			cnt:      2,
+			mems:     []idset.ID{1},
+			expected: "8-11",
+		},
+		{
+			name:     "smallest fitting LLC",
+			from:     "1-15",
+			cnt:      3,
+			mems:     []idset.ID{0},
+			expected: "1-3",
+		},
+		{
+			name:     "LLCs of the memory node if no single one fits",
+			from:     "2-7,10-15",
+			cnt:      5,
+			mems:     []idset.ID{0},
+			expected: "2-7",
+		},
+		{
+			name:     "any LLC without local memory nodes",
+			from:     "1-15",
+			cnt:      4,
+			expected: "4-7",
+		},
+		{
+			name:     "no preference if memory node has too few CPUs",
+			from:     "6-15",
+			cnt:      3,
+			mems:     []idset.ID{0},
+			expected: "",
+		},
+	}
+	for _, tc := range tcases {
+		t.Run(tc.name, func(t *testing.T) {
+			cpus := p.llcPreferredCPUs(cpuset.MustParse(tc.from), tc.cnt, idset.NewIDSet(tc.mems...))
+			if cpus.String() != tc.expected {
+				t.Errorf("expected preferred CPUs %q, got %q", tc.expected, cpus.String())
+			}
+		})
+	}
+}
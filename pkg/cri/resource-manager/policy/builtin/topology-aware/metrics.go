@@ -0,0 +1,215 @@
+// Copyright 2026 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package topologyaware
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	policyapi "github.com/intel/cri-resource-manager/pkg/cri/resource-manager/policy"
+)
+
+// Prometheus Metric descriptor indices and descriptor table
+const (
+	numaLocalDesc = iota
+	preferredMemoryDesc
+	pushedUpDesc
+)
+
+var descriptors = []*prometheus.Desc{
+	numaLocalDesc: prometheus.NewDesc(
+		"topology_aware_placement_numa_local_ratio",
+		"Fraction of containers with CPU and memory from the same NUMA-level pool",
+		nil, nil,
+	),
+	preferredMemoryDesc: prometheus.NewDesc(
+		"topology_aware_placement_preferred_memory_ratio",
+		"Fraction of containers with all memory allocated from their preferred memory type",
+		nil, nil,
+	),
+	pushedUpDesc: prometheus.NewDesc(
+		"topology_aware_placement_pushed_up_grants",
+		"Number of times grants have been moved up in the pool tree to fit memory",
+		nil, nil,
+	),
+}
+
+// Prometheus Metric descriptor indices and descriptor table for pool budgets
+const (
+	activeCPUsDesc = iota
+	powerDesc
+)
+
+var budgetDescriptors = []*prometheus.Desc{
+	activeCPUsDesc: prometheus.NewDesc(
+		"topology_aware_pool_active_cpus",
+		"Number of CPUs activated by exclusive and shared allocations in a pool with a budget",
+		[]string{"pool"}, nil,
+	),
+	powerDesc: prometheus.NewDesc(
+		"topology_aware_pool_power_watts",
+		"Power consumption of the CPU packages of a pool with a power budget",
+		[]string{"pool"}, nil,
+	),
+}
+
+// Metrics defines the placement quality metrics of the policy.
+type Metrics struct {
+	Containers      int                // number of containers with a grant
+	NumaLocal       int                // containers with CPU and memory in the same leaf pool
+	PreferredMemory int                // containers with memory only of their preferred type
+	PushedUp        uint64             // grants moved up in the tree so far
+	ActiveCPUs      map[string]int     // active CPUs of pools with a budget
+	Power           map[string]float64 // power consumption of pools with a power budget
+}
+
+// metricsSnapshot is the latest policy metrics, updated by the policy
+// and read by the metrics collector.
+type metricsSnapshot struct {
+	sync.Mutex
+	metrics *Metrics
+}
+
+// DescribeMetrics generates policy-specific prometheus metrics data descriptors.
+func (p *policy) DescribeMetrics() []*prometheus.Desc {
+	return append(append([]*prometheus.Desc{}, descriptors...), budgetDescriptors...)
+}
+
+// PollMetrics provides policy metrics for monitoring.
+func (p *policy) PollMetrics() policyapi.Metrics {
+	if p.snapshot == nil {
+		return &Metrics{}
+	}
+	p.snapshot.Lock()
+	defer p.snapshot.Unlock()
+	if p.snapshot.metrics == nil {
+		return &Metrics{}
+	}
+	return p.snapshot.metrics
+}
+
+// updateMetrics takes a new snapshot of policy metrics. It must be
+// called when allocations change, as the metrics collector cannot
+// access the policy state directly.
+func (p *policy) updateMetrics() {
+	if p.snapshot == nil {
+		return
+	}
+	m := &Metrics{
+		PushedUp:   p.pushedUpGrants,
+		ActiveCPUs: map[string]int{},
+		Power:      map[string]float64{},
+	}
+	for _, g := range p.allocations.grants {
+		m.Containers++
+		if isNumaLocal(g) {
+			m.NumaLocal++
+		}
+		if hasPreferredMemory(g) {
+			m.PreferredMemory++
+		}
+	}
+	for _, pool := range p.pools {
+		name := pool.Name()
+		_, limited := opt.MaxActiveCPUs[name]
+		_, budgeted := opt.PowerBudget[name]
+		if limited || budgeted {
+			m.ActiveCPUs[name] = p.activeCPUs(pool)
+		}
+		if budgeted {
+			if watts, ok := p.lastPoolPower(pool); ok {
+				m.Power[name] = watts
+			}
+		}
+	}
+	p.snapshot.Lock()
+	p.snapshot.metrics = m
+	p.snapshot.Unlock()
+}
+
+// CollectMetrics generates prometheus metrics from cached/polled policy-specific metrics data.
+func (p *policy) CollectMetrics(m policyapi.Metrics) ([]prometheus.Metric, error) {
+	metrics, ok := m.(*Metrics)
+	if !ok {
+		return nil, policyError("type mismatch in topology-aware metrics")
+	}
+	collected := []prometheus.Metric{
+		prometheus.MustNewConstMetric(
+			descriptors[numaLocalDesc],
+			prometheus.GaugeValue,
+			ratio(metrics.NumaLocal, metrics.Containers)),
+		prometheus.MustNewConstMetric(
+			descriptors[preferredMemoryDesc],
+			prometheus.GaugeValue,
+			ratio(metrics.PreferredMemory, metrics.Containers)),
+		prometheus.MustNewConstMetric(
+			descriptors[pushedUpDesc],
+			prometheus.CounterValue,
+			float64(metrics.PushedUp)),
+	}
+	for pool, count := range metrics.ActiveCPUs {
+		collected = append(collected, prometheus.MustNewConstMetric(
+			budgetDescriptors[activeCPUsDesc],
+			prometheus.GaugeValue,
+			float64(count), pool))
+	}
+	for pool, watts := range metrics.Power {
+		collected = append(collected, prometheus.MustNewConstMetric(
+			budgetDescriptors[powerDesc],
+			prometheus.GaugeValue,
+			watts, pool))
+	}
+	return collected, nil
+}
+
+// isNumaLocal checks if the grant has its CPU and memory from the same leaf pool.
+func isNumaLocal(g Grant) bool {
+	cpuNode, memNode := g.GetCPUNode(), g.GetMemoryNode()
+	return cpuNode.IsLeafNode() && cpuNode.IsSameNode(memNode)
+}
+
+// hasPreferredMemory checks if the grant has memory only of the first type
+// in its memory spillover order. Grants without memory are considered to
+// have preferred memory.
+func hasPreferredMemory(g Grant) bool {
+	order := defaultMemorySpillover
+	if g.ColdStart() <= 0 {
+		order = memorySpilloverPreference(g.GetContainer())
+	}
+
+	preferred := memoryUnspec
+	for _, memType := range order {
+		if g.MemoryType()&memType != 0 {
+			preferred = memType
+			break
+		}
+	}
+
+	for memType, amount := range g.MemLimit() {
+		if memType != preferred && amount > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// ratio returns part/total, or 1 if total is 0.
+func ratio(part, total int) float64 {
+	if total == 0 {
+		return 1
+	}
+	return float64(part) / float64(total)
+}
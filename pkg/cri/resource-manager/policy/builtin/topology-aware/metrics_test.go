@@ -0,0 +1,80 @@
+// Copyright 2026 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package topologyaware
+
+import (
+	"testing"
+)
+
+func TestPlacementMetrics(t *testing.T) {
+	policy := &policy{
+		allocations: allocations{
+			grants: make(map[string]Grant),
+		},
+		pushedUpGrants: 3,
+		snapshot:       &metricsSnapshot{},
+	}
+	policy.allocations.policy = policy
+	root := policy.NewVirtualNode("root", nilnode)
+	leaf := policy.NewVirtualNode("leaf", root)
+	root.(*virtualnode).id = 0
+	leaf.(*virtualnode).id = 1
+
+	addGrant := func(id string, cpuNode, memNode Node, memType memoryType, allocated memoryMap) {
+		policy.allocations.grants[id] = &grant{
+			container:    &mockContainer{name: id, returnValueForGetCacheID: id},
+			node:         cpuNode,
+			memoryNode:   memNode,
+			memType:      memType,
+			allocatedMem: allocated,
+		}
+	}
+
+	addGrant("local", leaf, leaf, memoryDRAM, memoryMap{memoryDRAM: 1024})
+	addGrant("pushed-up", leaf, root, memoryDRAM, memoryMap{memoryDRAM: 1024})
+	addGrant("root", root, root, memoryDRAM|memoryPMEM, memoryMap{memoryPMEM: 1024})
+	addGrant("spilled", leaf, leaf, memoryDRAM|memoryPMEM, memoryMap{memoryPMEM: 1024, memoryDRAM: 1024})
+
+	if m, ok := policy.PollMetrics().(*Metrics); !ok || m.Containers != 0 {
+		t.Errorf("expected empty metrics before the first snapshot, got %v", policy.PollMetrics())
+	}
+	policy.updateMetrics()
+
+	m, ok := policy.PollMetrics().(*Metrics)
+	if !ok {
+		t.Fatalf("unexpected metrics type %T", policy.PollMetrics())
+	}
+
+	if m.Containers != 4 {
+		t.Errorf("expected 4 containers, got %d", m.Containers)
+	}
+	if m.NumaLocal != 2 {
+		t.Errorf("expected 2 NUMA-local containers, got %d", m.NumaLocal)
+	}
+	if m.PreferredMemory != 3 {
+		t.Errorf("expected 3 containers with preferred memory, got %d", m.PreferredMemory)
+	}
+	if m.PushedUp != 3 {
+		t.Errorf("expected 3 pushed up grants, got %d", m.PushedUp)
+	}
+
+	metrics, err := policy.CollectMetrics(m)
+	if err != nil {
+		t.Fatalf("failed to collect metrics: %v", err)
+	}
+	if len(metrics) != len(descriptors) {
+		t.Errorf("expected %d metrics, got %d", len(descriptors), len(metrics))
+	}
+}
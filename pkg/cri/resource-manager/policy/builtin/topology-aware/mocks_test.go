@@ -21,6 +21,7 @@ import (
 	"github.com/intel/cri-resource-manager/pkg/apis/resmgr"
 	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/cache"
 	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/config"
+	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/kubernetes"
 	system "github.com/intel/cri-resource-manager/pkg/sysfs"
 	"github.com/intel/cri-resource-manager/pkg/topology"
 	"github.com/intel/cri-resource-manager/pkg/utils/cpuset"
@@ -36,6 +37,10 @@ type mockSystemNode struct {
 	memTotal uint64
 	memType  system.MemoryType
 	distance []int
+	cpus     cpuset.CPUSet
+	// hugepages in bytes, free ones in pages of any size
+	hugePages     uint64
+	freeHugePages uint64
 }
 
 func (fake *mockSystemNode) MemoryInfo() (*system.MemInfo, error) {
@@ -62,8 +67,16 @@ func (fake *mockSystemNode) HasNormalMemory() bool {
 	return true
 }
 
+func (fake *mockSystemNode) FreeHugePages(uint64) (uint64, error) {
+	return fake.freeHugePages, nil
+}
+
+func (fake *mockSystemNode) TotalHugePages() (uint64, error) {
+	return fake.hugePages, nil
+}
+
 func (fake *mockSystemNode) CPUSet() cpuset.CPUSet {
-	return cpuset.New()
+	return cpuset.New(fake.cpus.UnsortedList()...)
 }
 
 func (fake *mockSystemNode) Distance() []int {
@@ -114,6 +127,7 @@ type mockCPU struct {
 	id       idset.ID
 	node     mockSystemNode
 	pkg      mockCPUPackage
+	threads  cpuset.CPUSet
 }
 
 func (c *mockCPU) BaseFrequency() uint64 {
@@ -138,7 +152,7 @@ func (c *mockCPU) CoreID() idset.ID {
 	return c.id
 }
 func (c *mockCPU) ThreadCPUSet() cpuset.CPUSet {
-	return cpuset.New()
+	return c.threads
 }
 func (c *mockCPU) FrequencyRange() system.CPUFreq {
 	return system.CPUFreq{}
@@ -163,6 +177,7 @@ type mockSystem struct {
 	cpuCount     int
 	packageCount int
 	socketCount  int
+	threads      map[idset.ID]cpuset.CPUSet
 }
 
 func (fake *mockSystem) Node(id idset.ID) system.Node {
@@ -174,8 +189,12 @@ func (fake *mockSystem) Node(id idset.ID) system.Node {
 	return &mockSystemNode{}
 }
 
-func (fake *mockSystem) CPU(idset.ID) system.CPU {
-	return &mockCPU{}
+func (fake *mockSystem) CPU(id idset.ID) system.CPU {
+	threads, ok := fake.threads[id]
+	if !ok {
+		threads = cpuset.New()
+	}
+	return &mockCPU{threads: threads}
 }
 func (fake *mockSystem) CPUCount() int {
 	if fake.cpuCount == 0 {
@@ -260,6 +279,8 @@ type mockContainer struct {
 	cpuset                                cpuset.CPUSet
 	returnValueForQOSClass                v1.PodQOSClass
 	pod                                   cache.Pod
+	state                                 cache.ContainerState
+	tags                                  map[string]string
 }
 
 func (m *mockContainer) PrettyName() string {
@@ -275,7 +296,10 @@ func (m *mockContainer) GetID() string {
 	return m.returnValueForGetID
 }
 func (m *mockContainer) GetPodID() string {
-	panic("unimplemented")
+	if m.pod == nil {
+		panic("unimplemented")
+	}
+	return m.pod.GetID()
 }
 func (m *mockContainer) GetCacheID() string {
 	if len(m.returnValueForGetCacheID) == 0 {
@@ -294,8 +318,29 @@ func (m *mockContainer) UpdateState(cache.ContainerState) {
 	panic("unimplemented")
 }
 func (m *mockContainer) GetState() cache.ContainerState {
+	return m.state
+}
+func (m *mockContainer) GetCreatedAt() time.Time {
+	panic("unimplemented")
+}
+func (m *mockContainer) GetRestartCount() int {
 	panic("unimplemented")
 }
+func (m *mockContainer) IsManaged() bool {
+	return true
+}
+func (m *mockContainer) GetSecurityContext() cache.SecurityContext {
+	panic("unimplemented")
+}
+func (m *mockContainer) IsPrivileged() bool {
+	return false
+}
+func (m *mockContainer) IsHostNetwork() bool {
+	return false
+}
+func (m *mockContainer) HasCapability(string) bool {
+	return false
+}
 func (m *mockContainer) GetQOSClass() v1.PodQOSClass {
 	if len(m.returnValueForQOSClass) == 0 {
 		return v1.PodQOSGuaranteed
@@ -460,6 +505,14 @@ func (m *mockContainer) SetCpusetCpus(string) {
 }
 func (m *mockContainer) SetCpusetMems(string) {
 }
+func (m *mockContainer) SetHugepageLimit(string, uint64) {
+}
+func (m *mockContainer) SetUnifiedResource(string, string) {
+	panic("unimplemented")
+}
+func (m *mockContainer) GetUnifiedResource(string) (string, bool) {
+	panic("unimplemented")
+}
 func (m *mockContainer) UpdateCriCreateRequest(*criv1.CreateContainerRequest) error {
 	panic("unimplemented")
 }
@@ -481,6 +534,12 @@ func (m *mockContainer) SetBlockIOClass(string) {
 func (m *mockContainer) GetBlockIOClass() string {
 	panic("unimplemented")
 }
+func (m *mockContainer) SetNetClass(string) {
+	panic("unimplemented")
+}
+func (m *mockContainer) GetNetClass() string {
+	panic("unimplemented")
+}
 func (m *mockContainer) SetToptierLimit(int64) {
 	panic("unimplemented")
 }
@@ -520,11 +579,17 @@ func (m *mockContainer) HasPending(string) bool {
 func (m *mockContainer) ClearPending(string) {
 	panic("unimplemented")
 }
-func (m *mockContainer) GetTag(string) (string, bool) {
-	panic("unimplemented")
+func (m *mockContainer) GetTag(key string) (string, bool) {
+	value, ok := m.tags[key]
+	return value, ok
 }
-func (m *mockContainer) SetTag(string, string) (string, bool) {
-	panic("unimplemented")
+func (m *mockContainer) SetTag(key string, value string) (string, bool) {
+	if m.tags == nil {
+		m.tags = make(map[string]string)
+	}
+	old, ok := m.tags[key]
+	m.tags[key] = value
+	return old, ok
 }
 func (m *mockContainer) DeleteTag(string) (string, bool) {
 	panic("unimplemented")
@@ -553,19 +618,23 @@ type mockPod struct {
 	coldStartTimeout                   time.Duration
 	coldStartContainerName             string
 	annotations                        map[string]string
+	labels                             map[string]string
+	resources                          cache.PodResourceRequirements
+	containers                         []cache.Container
+	initContainers                     []cache.Container
 }
 
 func (m *mockPod) GetInitContainers() []cache.Container {
-	panic("unimplemented")
+	return m.initContainers
 }
 func (m *mockPod) GetContainers() []cache.Container {
-	panic("unimplemented")
+	return m.containers
 }
 func (m *mockPod) GetContainer(string) (cache.Container, bool) {
 	panic("unimplemented")
 }
 func (m *mockPod) GetID() string {
-	panic("unimplemented")
+	return m.name
 }
 func (m *mockPod) GetUID() string {
 	panic("unimplemented")
@@ -579,14 +648,18 @@ func (m *mockPod) GetNamespace() string {
 func (m *mockPod) GetState() cache.PodState {
 	panic("unimplemented")
 }
+func (m *mockPod) GetCreatedAt() time.Time {
+	panic("unimplemented")
+}
 func (m *mockPod) GetQOSClass() v1.PodQOSClass {
 	return m.returnValueFotGetQOSClass
 }
 func (m *mockPod) GetLabelKeys() []string {
 	panic("unimplemented")
 }
-func (m *mockPod) GetLabel(string) (string, bool) {
-	panic("unimplemented")
+func (m *mockPod) GetLabel(key string) (string, bool) {
+	v, ok := m.labels[key]
+	return v, ok
 }
 func (m *mockPod) GetResmgrLabelKeys() []string {
 	panic("unimplemented")
@@ -597,8 +670,9 @@ func (m *mockPod) GetResmgrLabel(string) (string, bool) {
 func (m *mockPod) GetAnnotationKeys() []string {
 	panic("unimplemented")
 }
-func (m *mockPod) GetAnnotation(string) (string, bool) {
-	panic("unimplemented")
+func (m *mockPod) GetAnnotation(key string) (string, bool) {
+	v, ok := m.annotations[key]
+	return v, ok
 }
 func (m *mockPod) GetAnnotationObject(string, interface{}, func([]byte, interface{}) error) (bool, error) {
 	panic("unimplemented")
@@ -610,6 +684,9 @@ func (m *mockPod) GetResmgrAnnotation(key string) (string, bool) {
 	if key == keyColdStartPreference && len(m.coldStartContainerName) > 0 {
 		return m.coldStartContainerName + ": { duration: " + m.coldStartTimeout.String() + " }", true
 	}
+	if v, ok := m.annotations[kubernetes.ResmgrKey(key)]; ok {
+		return v, true
+	}
 	return m.returnValue1FotGetResmgrAnnotation, m.returnValue2FotGetResmgrAnnotation
 }
 func (m *mockPod) GetResmgrAnnotationObject(string, interface{}, func([]byte, interface{}) error) (bool, error) {
@@ -629,7 +706,7 @@ func (m *mockPod) GetCgroupParentDir() string {
 	panic("unimplemented")
 }
 func (m *mockPod) GetPodResourceRequirements() cache.PodResourceRequirements {
-	panic("unimplemented")
+	return m.resources
 }
 func (m *mockPod) GetContainerAffinity(string) ([]*cache.Affinity, error) {
 	panic("unimplemented")
@@ -640,7 +717,13 @@ func (m *mockPod) ScopeExpression() *resmgr.Expression {
 func (m *mockPod) String() string {
 	return "mockPod"
 }
-func (m *mockPod) Eval(string) interface{} {
+func (m *mockPod) Eval(key string) interface{} {
+	switch key {
+	case resmgr.KeyName:
+		return m.name
+	case resmgr.KeyLabels:
+		return m.labels
+	}
 	panic("unimplemented")
 }
 func (m *mockPod) GetProcesses(bool) ([]string, error) {
@@ -651,6 +734,7 @@ func (m *mockPod) GetTasks(bool) ([]string, error) {
 }
 
 type mockCache struct {
+	returnValueForGetContainers    []cache.Container
 	returnValueForGetPolicyEntry   bool
 	returnValue1ForLookupContainer cache.Container
 	returnValue2ForLookupContainer bool
@@ -680,15 +764,24 @@ func (m *mockCache) LookupContainer(string) (cache.Container, bool) {
 func (m *mockCache) LookupContainerByCgroup(path string) (cache.Container, bool) {
 	panic("unimplemented")
 }
+func (m *mockCache) GetContainersSnapshot() []cache.Container {
+	panic("unimplemented")
+}
+func (m *mockCache) SelfCheck() []error {
+	panic("unimplemented")
+}
 func (m *mockCache) GetPendingContainers() []cache.Container {
 	panic("unimplemented")
 }
 func (m *mockCache) GetPods() []cache.Pod {
 	panic("unimplemented")
 }
-func (m *mockCache) GetContainers() []cache.Container {
+func (m *mockCache) GetPodsByNamespace(string) []cache.Pod {
 	panic("unimplemented")
 }
+func (m *mockCache) GetContainers() []cache.Container {
+	return m.returnValueForGetContainers
+}
 func (m *mockCache) GetContainerCacheIds() []string {
 	panic("unimplemented")
 }
@@ -720,6 +813,11 @@ func (m *mockCache) SetPolicyEntry(string, interface{}) {
 func (m *mockCache) GetPolicyEntry(string, interface{}) bool {
 	return m.returnValueForGetPolicyEntry
 }
+func (m *mockCache) SetControllerEntry(string, string, interface{}) {
+}
+func (m *mockCache) GetControllerEntry(string, string, interface{}) bool {
+	return false
+}
 func (m *mockCache) SetConfig(*config.RawConfig) error {
 	panic("unimplemented")
 }
@@ -729,6 +827,12 @@ func (m *mockCache) GetConfig() *config.RawConfig {
 func (m *mockCache) ResetConfig() error {
 	panic("unimplemented")
 }
+func (m *mockCache) AddConfigNotify(string, cache.ConfigNotifyFn) error {
+	panic("unimplemented")
+}
+func (m *mockCache) DeleteConfigNotify(string) {
+	panic("unimplemented")
+}
 func (m *mockCache) SetAdjustment(*config.Adjustment) (bool, map[string]error) {
 	panic("unimplemented")
 }
@@ -418,24 +418,25 @@ func (n *node) discoverSupply(assignedNUMANodes []idset.ID) Supply {
 			if err != nil {
 				log.Fatal("%s: failed to get memory info for NUMA node #%d", n.Name(), nodeID)
 			}
+			memTotal := n.policy.normalMemory(nodeID, meminfo.MemTotal)
 
 			switch node.GetMemoryType() {
 			case system.MemoryTypeDRAM:
 				n.mem.Add(nodeID)
-				mmap.AddDRAM(meminfo.MemTotal)
+				mmap.AddDRAM(memTotal)
 				shortCPUs := cpuset.ShortCPUSet(nodeCPUs)
 				log.Debug("  + assigned DRAM NUMA node #%d (cpuset: %s, DRAM %.2fM)",
-					nodeID, shortCPUs, float64(meminfo.MemTotal)/float64(1024*1024))
+					nodeID, shortCPUs, float64(memTotal)/float64(1024*1024))
 			case system.MemoryTypePMEM:
 				n.pMem.Add(nodeID)
-				mmap.AddPMEM(meminfo.MemTotal)
+				mmap.AddPMEM(memTotal)
 				log.Debug("  + assigned PMEM NUMA node #%d (DRAM %.2fM)", nodeID,
-					float64(meminfo.MemTotal)/float64(1024*1024))
+					float64(memTotal)/float64(1024*1024))
 			case system.MemoryTypeHBM:
 				n.hbm.Add(nodeID)
-				mmap.AddHBM(meminfo.MemTotal)
+				mmap.AddHBM(memTotal)
 				log.Debug("  + assigned HBMEM NUMA node #%d (DRAM %.2fM)",
-					nodeID, float64(meminfo.MemTotal)/float64(1024*1024))
+					nodeID, float64(memTotal)/float64(1024*1024))
 			default:
 				log.Fatal("NUMA node #%d with unknown memory type %v", node.GetMemoryType())
 			}
@@ -503,7 +504,7 @@ func (n *node) assignNUMANodes(ids []idset.ID) {
 			log.Error("%s: failed to get memory info for NUMA node #%d",
 				n.Name(), numaNodeID)
 		} else {
-			memTotal = meminfo.MemTotal
+			memTotal = n.policy.normalMemory(numaNodeID, meminfo.MemTotal)
 		}
 		switch numaNode.GetMemoryType() {
 		case system.MemoryTypeDRAM:
@@ -0,0 +1,170 @@
+// Copyright 2026 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package topologyaware
+
+import (
+	"github.com/intel/cri-resource-manager/pkg/cgroups"
+	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/cache"
+	cpucontrol "github.com/intel/cri-resource-manager/pkg/cri/resource-manager/control/cpu"
+	"github.com/intel/cri-resource-manager/pkg/utils/cpuset"
+)
+
+// updatePodPartition isolates the exclusive CPUs of the pod of a container in
+// a cgroup v2 cpuset partition, if this is enabled by configuration. Since the
+// CPUs of a partition must not be used by its siblings, the partition is the
+// pod cgroup and it is only created for pods with nothing but exclusive CPUs.
+// Other pods fall back to no partition. This is re-applied whenever the grants
+// of the pod change.
+func (p *policy) updatePodPartition(c cache.Container) {
+	if !opt.CpusetPartition || !opt.PinCPU {
+		return
+	}
+	pod, ok := c.GetPod()
+	if !ok {
+		return
+	}
+	if cgroups.DetectSystemCgroupVersion() != 2 {
+		p.partitionFallback(pod, "cpuset partitions need cgroup v2")
+		return
+	}
+	dir := pod.GetCgroupParentDir()
+	if dir == "" {
+		p.partitionFallback(pod, "failed to determine pod cgroup directory")
+		return
+	}
+	group := cgroups.Cpuset.Group(dir)
+
+	cpus, reason := p.podPartitionCPUs(pod)
+	if cpus.IsEmpty() && reason == "" {
+		// nothing to isolate, for instance all containers are gone
+		delete(p.noPartition, pod.GetID())
+		trimCpusetExclusive(pod, cpuset.New())
+		if _, err := cpucontrol.ClearPartition(group); err != nil {
+			log.Warn("pod %s: failed to remove cpuset partition: %v", pod.GetName(), err)
+		}
+		return
+	}
+	if reason == "" {
+		trimCpusetExclusive(pod, cpus)
+		changed, err := cpucontrol.WritePartition(group, cpus, cpucontrol.PartitionRoot)
+		if err == nil {
+			delete(p.noPartition, pod.GetID())
+			if changed {
+				log.Info("pod %s: exclusive CPUs %s isolated in a cpuset partition",
+					pod.GetName(), cpus)
+			}
+			return
+		}
+		reason = err.Error()
+	}
+
+	trimCpusetExclusive(pod, cpuset.New())
+	reverted, err := cpucontrol.ClearPartition(group)
+	if err != nil {
+		log.Warn("pod %s: failed to remove cpuset partition: %v", pod.GetName(), err)
+	}
+	if reverted {
+		log.Info("pod %s: cpuset partition removed", pod.GetName())
+	}
+	p.partitionFallback(pod, reason)
+}
+
+// partitionFallback logs why a pod is not isolated in a cpuset partition,
+// once for each reason.
+func (p *policy) partitionFallback(pod cache.Pod, reason string) {
+	if p.noPartition[pod.GetID()] == reason {
+		return
+	}
+	p.noPartition[pod.GetID()] = reason
+	log.Info("pod %s: not isolated in a cpuset partition: %s", pod.GetName(), reason)
+}
+
+// podPartitionCPUs returns the exclusive CPUs of a pod to isolate in a cpuset
+// partition, or the reason why the pod can't be isolated. Pods without any
+// CPUs allocated have nothing to isolate.
+func (p *policy) podPartitionCPUs(pod cache.Pod) (cpuset.CPUSet, string) {
+	cpus := cpuset.New()
+	for _, c := range pod.GetContainers() {
+		id := c.GetCacheID()
+		if _, ok := p.prePinned[id]; ok {
+			return cpuset.New(), c.PrettyName() + " is pinned by someone else"
+		}
+		g, ok := p.allocations.grants[id]
+		if !ok {
+			// released, or not allocated yet
+			continue
+		}
+		if g.CPUType() != cpuNormal || g.SharedPortion() > 0 || g.ExclusiveCPUs().IsEmpty() {
+			return cpuset.New(), c.PrettyName() + " has shared CPUs"
+		}
+		cpus = cpus.Union(g.ExclusiveCPUs())
+	}
+	return cpus, ""
+}
+
+// updateCpusetExclusive announces the exclusive CPUs of a container in its
+// cgroup, if this is enabled by configuration and supported by the kernel.
+// The kernel only accepts CPUs which are exclusive in the parent cgroup, too,
+// for instance in a pod isolated in a cpuset partition. Otherwise this is
+// quietly skipped. This is re-applied whenever the grant of the container
+// changes.
+func (p *policy) updateCpusetExclusive(c cache.Container) {
+	if !opt.CpusetExclusive || !opt.PinCPU {
+		return
+	}
+
+	dir := c.GetCgroupDir()
+	if dir == "" {
+		log.Debug("%s: failed to determine cgroup directory, not setting exclusive CPUs",
+			c.PrettyName())
+		return
+	}
+
+	cpus := cpuset.New()
+	if g, ok := p.allocations.grants[c.GetCacheID()]; ok {
+		cpus = g.ExclusiveCPUs().Union(g.IsolatedCPUs())
+	}
+
+	changed, err := cpucontrol.WriteExclusiveCpus(cgroups.Cpuset.Group(dir), cpus)
+	switch {
+	case err != nil:
+		log.Debug("%s: not announcing exclusive CPUs %s in cgroup: %v", c.PrettyName(), cpus, err)
+	case changed && !cpus.IsEmpty():
+		log.Info("%s: exclusive CPUs %s announced in cgroup", c.PrettyName(), cpus)
+	}
+}
+
+// trimCpusetExclusive clears the exclusive CPUs of the containers of a pod
+// which are not among the given CPUs, before the exclusive CPUs of the pod
+// are reduced to them.
+func trimCpusetExclusive(pod cache.Pod, cpus cpuset.CPUSet) {
+	for _, c := range pod.GetContainers() {
+		dir := c.GetCgroupDir()
+		if dir == "" {
+			continue
+		}
+		group := cgroups.Cpuset.Group(dir)
+		current, err := group.Read(cgroups.CpusetExclusive)
+		if err != nil || current == "" {
+			continue
+		}
+		if cset, err := cpuset.Parse(current); err == nil && cset.Intersection(cpus).Equals(cset) {
+			continue
+		}
+		if _, err := cpucontrol.WriteExclusiveCpus(group, cpuset.New()); err != nil {
+			log.Warn("%s: failed to clear exclusive CPUs: %v", c.PrettyName(), err)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+// Copyright 2026 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package topologyaware
+
+import (
+	"testing"
+
+	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/cache"
+	"github.com/intel/cri-resource-manager/pkg/utils/cpuset"
+)
+
+func TestPodPartitionCPUs(t *testing.T) {
+	p := &policy{prePinned: map[string]Grant{}}
+	p.allocations = p.newAllocations()
+	node := p.NewVirtualNode("root", nilnode)
+
+	newContainer := func(id string, exclusive cpuset.CPUSet, portion int) cache.Container {
+		c := &mockContainer{name: id, returnValueForGetCacheID: id}
+		if exclusive.Size() > 0 || portion > 0 {
+			p.allocations.grants[id] = newGrant(node, c, cpuNormal, exclusive, portion, 0, nil, 0)
+		}
+		return c
+	}
+
+	for _, tc := range []struct {
+		name       string
+		containers []cache.Container
+		expected   string
+		fallback   bool
+	}{
+		{
+			name: "exclusive CPUs only",
+			containers: []cache.Container{
+				newContainer("exclusive1", cpuset.New(2, 3), 0),
+				newContainer("exclusive2", cpuset.New(4), 0),
+				newContainer("released", cpuset.New(), 0),
+			},
+			expected: "2-4",
+		},
+		{
+			name: "mixed CPUs",
+			containers: []cache.Container{
+				newContainer("exclusive3", cpuset.New(5), 0),
+				newContainer("mixed", cpuset.New(6), 200),
+			},
+			fallback: true,
+		},
+		{
+			name: "shared CPUs",
+			containers: []cache.Container{
+				newContainer("exclusive4", cpuset.New(7), 0),
+				newContainer("shared", cpuset.New(), 500),
+			},
+			fallback: true,
+		},
+		{
+			name:       "no CPUs",
+			containers: []cache.Container{newContainer("none", cpuset.New(), 0)},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cpus, reason := p.podPartitionCPUs(&mockPod{containers: tc.containers})
+			if (reason != "") != tc.fallback {
+				t.Errorf("expected fallback %v, got reason %q", tc.fallback, reason)
+			}
+			if cpus.String() != tc.expected {
+				t.Errorf("expected partition CPUs %q, got %q", tc.expected, cpus)
+			}
+		})
+	}
+}
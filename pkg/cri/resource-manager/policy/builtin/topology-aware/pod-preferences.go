@@ -43,6 +43,10 @@ const (
 	keyColdStartPreference = "cold-start"
 	// annotation key for reserved pools
 	keyReservedCPUsPreference = "prefer-reserved-cpus"
+	// annotation key for pod priority, set by the webhook from the pod spec
+	keyPodPriority = "priority"
+	// annotation key for SMT anti-affinity groups of exclusive CPUs
+	keySMTAntiAffinity = "smt-anti-affinity"
 
 	// effective annotation key for isolated CPU preference
 	preferIsolatedCPUsKey = keyIsolationPreference + "." + kubernetes.ResmgrKeyNamespace
@@ -54,6 +58,12 @@ const (
 	preferColdStartKey = keyColdStartPreference + "." + kubernetes.ResmgrKeyNamespace
 	// annotation key for reserved pools
 	preferReservedCPUsKey = keyReservedCPUsPreference + "." + kubernetes.ResmgrKeyNamespace
+	// effective annotation key for SMT anti-affinity groups
+	smtAntiAffinityKey = keySMTAntiAffinity + "." + kubernetes.ResmgrKeyNamespace
+
+	// labels set by the Job controller on the pods it creates
+	jobNameLabel       = "batch.kubernetes.io/job-name"
+	legacyJobNameLabel = "job-name"
 )
 
 // cpuClass is a type of CPU to allocate
@@ -151,6 +161,13 @@ func sharedCPUsPreference(pod cache.Pod, container cache.Container) (bool, bool)
 	return preference, true
 }
 
+// smtAntiAffinityGroup returns the SMT anti-affinity group of the container, if any.
+// The exclusive CPUs of containers in the same group never share physical cores.
+func smtAntiAffinityGroup(pod cache.Pod, container cache.Container) string {
+	group, _ := pod.GetEffectiveAnnotation(smtAntiAffinityKey, container.GetName())
+	return group
+}
+
 // memoryTypePreference returns what type of memory should be allocated for the container.
 //
 // If the effective annotations are not found, this function falls back to
@@ -343,6 +360,28 @@ func checkReservedPoolNamespaces(namespace string) bool {
 	return false
 }
 
+// checkIsolatedPoolNamespaces checks if isolated CPUs can be allocated to
+// containers in the given namespace. The second return value tells whether
+// isolated CPUs are restricted to any namespaces at all.
+func checkIsolatedPoolNamespaces(namespace string) (bool, bool) {
+	if len(opt.IsolatedPoolNamespaces) == 0 {
+		return true, false
+	}
+
+	for _, str := range opt.IsolatedPoolNamespaces {
+		ret, err := filepath.Match(str, namespace)
+		if err != nil {
+			continue
+		}
+
+		if ret {
+			return true, true
+		}
+	}
+
+	return false, true
+}
+
 func checkReservedCPUsAnnotations(c cache.Container) (bool, bool) {
 	hintSetting, ok := c.GetEffectiveAnnotation(preferReservedCPUsKey)
 	if !ok {
@@ -359,6 +398,44 @@ func checkReservedCPUsAnnotations(c cache.Container) (bool, bool) {
 	return preference, true
 }
 
+// sharedOnlyPod checks if the containers of a pod should only get shared CPUs,
+// either because the pod is selected by SharedOnlyPods or because it is a
+// presumably short-lived pod of a Job and SharedOnlyJobPods is enabled.
+func sharedOnlyPod(pod cache.Pod) bool {
+	if opt.SharedOnlyJobPods {
+		if _, ok := pod.GetLabel(jobNameLabel); ok {
+			return true
+		}
+		if _, ok := pod.GetLabel(legacyJobNameLabel); ok {
+			return true
+		}
+	}
+	for _, expr := range opt.SharedOnlyPods {
+		if expr.Evaluate(pod) {
+			return true
+		}
+	}
+	return false
+}
+
+// podPriority returns the priority of the pod of the container, or 0 if not known.
+func podPriority(c cache.Container) int32 {
+	pod, ok := c.GetPod()
+	if !ok {
+		return 0
+	}
+	value, ok := pod.GetResmgrAnnotation(keyPodPriority)
+	if !ok {
+		return 0
+	}
+	priority, err := strconv.ParseInt(value, 10, 32)
+	if err != nil {
+		log.Error("failed to parse pod priority %s = '%s': %v", keyPodPriority, value, err)
+		return 0
+	}
+	return int32(priority)
+}
+
 // cpuAllocationPreferences figures out the amount and kind of CPU to allocate.
 // Returned values:
 // 1. full: number of full CPUs
@@ -411,6 +488,14 @@ func cpuAllocationPreferences(pod cache.Pod, container cache.Container) (int, in
 	//            - otherwise (no shared annotation):
 	//              => exclusive cores, prefer isolated only if explicitly annotated (**)
 	//
+	//   - containers of pods selected by SharedOnlyPods, or of Job pods with
+	//     SharedOnlyJobPods enabled, get shared cores, skipping exclusive
+	//     allocation for short-lived workloads.
+	//
+	//   - Burstable and Guaranteed requests with a fractional part at or above
+	//     the configured per-QoS class round-up threshold are rounded up to full
+	//     exclusive cores, unless shared cores are explicitly preferred.
+	//
 	//   - Rationale for isolation defaults:
 	//     *)
 	//        In the single core case, a workload does not need to do anything extra to
@@ -429,6 +514,12 @@ func cpuAllocationPreferences(pod cache.Pod, container cache.Container) (int, in
 	request := container.GetResourceRequirements().Requests[corev1.ResourceCPU]
 	qosClass := pod.GetQOSClass()
 	fraction := int(request.MilliValue())
+	if podCPUPoolPreference(pod) {
+		podRequest, _, _ := podResourceRequirements(pod)
+		log.Debug("%s: using pod total CPU request %dm for shared pod grant",
+			container.PrettyName(), podRequest)
+		fraction = int(podRequest)
+	}
 
 	// easy cases: kube-system namespace, Burstable or BestEffort QoS class containers
 	preferReserved, explicitReservation := checkReservedCPUsAnnotations(container)
@@ -437,7 +528,15 @@ func cpuAllocationPreferences(pod cache.Pod, container cache.Container) (int, in
 		return 0, fraction, false, cpuReserved
 	case checkReservedPoolNamespaces(namespace) && !explicitReservation:
 		return 0, fraction, false, cpuReserved
+	case qosClass != corev1.PodQOSBestEffort && sharedOnlyPod(pod):
+		log.Debug("%s: shared-only pod, skipping exclusive allocation", container.PrettyName())
+		return 0, fraction, false, cpuNormal
 	case qosClass == corev1.PodQOSBurstable:
+		if cores, ok := roundUpExclusiveCPU(qosClass, fraction); ok {
+			if preferShared, explicitShared := sharedCPUsPreference(pod, container); !preferShared || !explicitShared {
+				return cores, 0, false, cpuNormal
+			}
+		}
 		return 0, fraction, false, cpuNormal
 	case qosClass == corev1.PodQOSBestEffort:
 		return 0, 0, false, cpuNormal
@@ -449,6 +548,23 @@ func cpuAllocationPreferences(pod cache.Pod, container cache.Container) (int, in
 	preferIsolated, explicitIsolated := isolatedCPUsPreference(pod, container)
 	preferShared, explicitShared := sharedCPUsPreference(pod, container)
 
+	if allowed, restricted := checkIsolatedPoolNamespaces(namespace); restricted {
+		switch {
+		case !allowed:
+			preferIsolated = false
+		case !explicitIsolated:
+			preferIsolated, explicitIsolated = true, true
+		}
+	}
+
+	if !(preferShared && explicitShared) {
+		if rounded, ok := roundUpExclusiveCPU(qosClass, 1000*cores+fraction); ok {
+			log.Debug("%s: rounding CPU request %dm up to %d exclusive CPUs",
+				container.PrettyName(), 1000*cores+fraction, rounded)
+			cores, fraction = rounded, 0
+		}
+	}
+
 	switch {
 	// sub-core CPU request
 	case cores == 0:
@@ -484,6 +600,21 @@ func cpuAllocationPreferences(pod cache.Pod, container cache.Container) (int, in
 	}
 }
 
+// roundUpExclusiveCPU checks if a milli-CPU request of the given QoS class
+// should be rounded up to full exclusive CPUs. If so, it returns the number
+// of CPUs to allocate.
+func roundUpExclusiveCPU(qosClass corev1.PodQOSClass, request int) (int, bool) {
+	threshold, ok := opt.ExclusiveCPURoundUp[qosClass]
+	if !ok || threshold.MilliValue() <= 0 || threshold.MilliValue() >= 1000 {
+		return 0, false
+	}
+	fraction := request % 1000
+	if fraction == 0 || int64(fraction) < threshold.MilliValue() {
+		return 0, false
+	}
+	return request/1000 + 1, true
+}
+
 // podMemoryTypePreference returns what type of memory should be allocated for the container.
 func podMemoryTypePreference(pod cache.Pod, c cache.Container) memoryType {
 	key := keyMemoryTypePreference
@@ -535,6 +666,9 @@ func memoryAllocationPreference(pod cache.Pod, c cache.Container) (uint64, uint6
 	if memLim, ok := resources.Limits[corev1.ResourceMemory]; ok {
 		lim = uint64(memLim.Value())
 	}
+	if podCPUPoolPreference(pod) {
+		_, req, lim = podResourceRequirements(pod)
+	}
 
 	return req, lim, mtype
 }
@@ -576,6 +710,47 @@ func parseMemoryType(value string) (memoryType, error) {
 	return memoryType(mtype), nil
 }
 
+// defaultMemorySpillover is the default order of memory types to allocate from.
+var defaultMemorySpillover = []memoryType{memoryPMEM, memoryDRAM, memoryHBM}
+
+// parseMemorySpillover parses a memory spillover order, like "dram,hbm,pmem".
+// Memory types left out are appended in the default order.
+func parseMemorySpillover(value string) ([]memoryType, error) {
+	order := []memoryType{}
+	seen := memoryUnspec
+	for _, name := range strings.Split(value, ",") {
+		t, ok := memoryNamedTypes[strings.ToLower(strings.TrimSpace(name))]
+		if !ok || t == memoryAll {
+			return nil, policyError("invalid memory type '%s' in spillover order '%s'", name, value)
+		}
+		if seen&t != 0 {
+			return nil, policyError("duplicate memory type '%s' in spillover order '%s'", name, value)
+		}
+		seen |= t
+		order = append(order, t)
+	}
+	for _, t := range defaultMemorySpillover {
+		if seen&t == 0 {
+			order = append(order, t)
+		}
+	}
+	return order, nil
+}
+
+// memorySpilloverPreference returns the order of memory types to allocate from for the container.
+func memorySpilloverPreference(container cache.Container) []memoryType {
+	value, ok := opt.MemorySpilloverOrder[container.GetQOSClass()]
+	if !ok {
+		return defaultMemorySpillover
+	}
+	order, err := parseMemorySpillover(value)
+	if err != nil {
+		log.Error("%s: %v", container.PrettyName(), err)
+		return defaultMemorySpillover
+	}
+	return order
+}
+
 // MarshalJSON is the JSON marshaller for memoryType.
 func (t memoryType) MarshalJSON() ([]byte, error) {
 	value := t.String()
@@ -21,6 +21,9 @@ import (
 	v1 "k8s.io/api/core/v1"
 	resapi "k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/intel/cri-resource-manager/pkg/apis/resmgr"
+	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/cache"
 )
 
 func TestPodIsolationPreference(t *testing.T) {
@@ -290,6 +293,10 @@ func TestCpuAllocationPreferences(t *testing.T) {
 		expectedCpuType        cpuClass
 		disabled               bool
 		reservedPoolNamespaces []string
+		isolatedPoolNamespaces []string
+		roundUp                map[corev1.PodQOSClass]resapi.Quantity
+		sharedOnlyPods         []*resmgr.Expression
+		sharedOnlyJobPods      bool
 	}{
 		{
 			name:     "cpuAllocationPreferences() should handle nil container arg gracefully",
@@ -1029,6 +1036,234 @@ func TestCpuAllocationPreferences(t *testing.T) {
 			expectedFraction: 0,
 			expectedCpuType:  cpuReserved,
 		},
+		{
+			name: "round up guaranteed fraction above threshold",
+			container: &mockContainer{
+				returnValueForGetResourceRequirements: v1.ResourceRequirements{
+					Requests: v1.ResourceList{
+						corev1.ResourceCPU: resapi.MustParse("950m"),
+					},
+				},
+			},
+			pod: &mockPod{
+				returnValueFotGetQOSClass: corev1.PodQOSGuaranteed,
+			},
+			preferIsolated:  true,
+			roundUp:         map[corev1.PodQOSClass]resapi.Quantity{corev1.PodQOSGuaranteed: resapi.MustParse("900m")},
+			expectedFull:    1,
+			expectedIsolate: true,
+		},
+		{
+			name: "round up guaranteed fraction at threshold for multiple cores",
+			container: &mockContainer{
+				returnValueForGetResourceRequirements: v1.ResourceRequirements{
+					Requests: v1.ResourceList{
+						corev1.ResourceCPU: resapi.MustParse("2900m"),
+					},
+				},
+			},
+			pod: &mockPod{
+				returnValueFotGetQOSClass: corev1.PodQOSGuaranteed,
+			},
+			roundUp:      map[corev1.PodQOSClass]resapi.Quantity{corev1.PodQOSGuaranteed: resapi.MustParse("900m")},
+			expectedFull: 3,
+		},
+		{
+			name: "don't round up guaranteed fraction below threshold",
+			container: &mockContainer{
+				returnValueForGetResourceRequirements: v1.ResourceRequirements{
+					Requests: v1.ResourceList{
+						corev1.ResourceCPU: resapi.MustParse("850m"),
+					},
+				},
+			},
+			pod: &mockPod{
+				returnValueFotGetQOSClass: corev1.PodQOSGuaranteed,
+			},
+			roundUp:          map[corev1.PodQOSClass]resapi.Quantity{corev1.PodQOSGuaranteed: resapi.MustParse("900m")},
+			expectedFraction: 850,
+		},
+		{
+			name: "don't round up guaranteed fraction with explicit shared preference",
+			container: &mockContainer{
+				name: "testcontainer",
+				returnValueForGetResourceRequirements: v1.ResourceRequirements{
+					Requests: v1.ResourceList{
+						corev1.ResourceCPU: resapi.MustParse("950m"),
+					},
+				},
+			},
+			pod: &mockPod{
+				returnValueFotGetQOSClass: corev1.PodQOSGuaranteed,
+				annotations: map[string]string{
+					preferSharedCPUsKey + "/container.testcontainer": "true",
+				},
+			},
+			roundUp:          map[corev1.PodQOSClass]resapi.Quantity{corev1.PodQOSGuaranteed: resapi.MustParse("900m")},
+			expectedFraction: 950,
+		},
+		{
+			name: "round up burstable fraction only if configured for burstable",
+			container: &mockContainer{
+				returnValueForGetResourceRequirements: v1.ResourceRequirements{
+					Requests: v1.ResourceList{
+						corev1.ResourceCPU: resapi.MustParse("1950m"),
+					},
+				},
+			},
+			pod: &mockPod{
+				returnValueFotGetQOSClass: corev1.PodQOSBurstable,
+			},
+			roundUp:          map[corev1.PodQOSClass]resapi.Quantity{corev1.PodQOSGuaranteed: resapi.MustParse("900m")},
+			expectedFraction: 1950,
+		},
+		{
+			name: "round up burstable fraction above threshold",
+			container: &mockContainer{
+				returnValueForGetResourceRequirements: v1.ResourceRequirements{
+					Requests: v1.ResourceList{
+						corev1.ResourceCPU: resapi.MustParse("1950m"),
+					},
+				},
+			},
+			pod: &mockPod{
+				returnValueFotGetQOSClass: corev1.PodQOSBurstable,
+			},
+			roundUp:      map[corev1.PodQOSClass]resapi.Quantity{corev1.PodQOSBurstable: resapi.MustParse("900m")},
+			expectedFull: 2,
+		},
+		{
+			name: "use pod total request for shared pod CPU pool",
+			container: &mockContainer{
+				returnValueForGetResourceRequirements: v1.ResourceRequirements{
+					Requests: v1.ResourceList{
+						corev1.ResourceCPU: resapi.MustParse("1"),
+					},
+				},
+			},
+			pod: &mockPod{
+				returnValueFotGetQOSClass: corev1.PodQOSGuaranteed,
+				annotations: map[string]string{
+					podCPUPoolKey: podCPUPoolShared,
+				},
+				resources: cache.PodResourceRequirements{
+					Containers: map[string]v1.ResourceRequirements{
+						"c0": {Requests: v1.ResourceList{corev1.ResourceCPU: resapi.MustParse("1")}},
+						"c1": {Requests: v1.ResourceList{corev1.ResourceCPU: resapi.MustParse("2")}},
+					},
+				},
+			},
+			expectedFull: 3,
+		},
+		{
+			name: "prefer isolated CPUs in an isolated pool namespace",
+			container: &mockContainer{
+				namespace: "rt",
+				returnValueForGetResourceRequirements: v1.ResourceRequirements{
+					Requests: v1.ResourceList{
+						corev1.ResourceCPU: resapi.MustParse("2"),
+					},
+				},
+			},
+			pod: &mockPod{
+				returnValueFotGetQOSClass: corev1.PodQOSGuaranteed,
+			},
+			isolatedPoolNamespaces: []string{"rt*"},
+			expectedFull:           2,
+			expectedIsolate:        true,
+		},
+		{
+			name: "no isolated CPUs outside isolated pool namespaces",
+			container: &mockContainer{
+				namespace: "default",
+				returnValueForGetResourceRequirements: v1.ResourceRequirements{
+					Requests: v1.ResourceList{
+						corev1.ResourceCPU: resapi.MustParse("1"),
+					},
+				},
+			},
+			pod: &mockPod{
+				returnValueFotGetQOSClass: corev1.PodQOSGuaranteed,
+			},
+			preferIsolated:         true,
+			isolatedPoolNamespaces: []string{"rt*"},
+			expectedFull:           1,
+		},
+		{
+			name: "shared-only pod selected by label",
+			container: &mockContainer{
+				returnValueForGetResourceRequirements: v1.ResourceRequirements{
+					Requests: v1.ResourceList{
+						corev1.ResourceCPU: resapi.MustParse("2"),
+					},
+				},
+			},
+			pod: &mockPod{
+				returnValueFotGetQOSClass: corev1.PodQOSGuaranteed,
+				labels:                    map[string]string{"app": "ci-runner"},
+			},
+			sharedOnlyPods: []*resmgr.Expression{
+				{
+					Key:    "labels/app",
+					Op:     resmgr.Equals,
+					Values: []string{"ci-runner"},
+				},
+			},
+			expectedFraction: 2000,
+		},
+		{
+			name: "pod not selected as shared-only",
+			container: &mockContainer{
+				returnValueForGetResourceRequirements: v1.ResourceRequirements{
+					Requests: v1.ResourceList{
+						corev1.ResourceCPU: resapi.MustParse("2"),
+					},
+				},
+			},
+			pod: &mockPod{
+				returnValueFotGetQOSClass: corev1.PodQOSGuaranteed,
+				labels:                    map[string]string{"app": "database"},
+			},
+			sharedOnlyPods: []*resmgr.Expression{
+				{
+					Key:    "labels/app",
+					Op:     resmgr.Equals,
+					Values: []string{"ci-runner"},
+				},
+			},
+			expectedFull: 2,
+		},
+		{
+			name: "shared-only job pod",
+			container: &mockContainer{
+				returnValueForGetResourceRequirements: v1.ResourceRequirements{
+					Requests: v1.ResourceList{
+						corev1.ResourceCPU: resapi.MustParse("1"),
+					},
+				},
+			},
+			pod: &mockPod{
+				returnValueFotGetQOSClass: corev1.PodQOSGuaranteed,
+				labels:                    map[string]string{"batch.kubernetes.io/job-name": "pi"},
+			},
+			sharedOnlyJobPods: true,
+			expectedFraction:  1000,
+		},
+		{
+			name: "job pod with shared-only job pods disabled",
+			container: &mockContainer{
+				returnValueForGetResourceRequirements: v1.ResourceRequirements{
+					Requests: v1.ResourceList{
+						corev1.ResourceCPU: resapi.MustParse("1"),
+					},
+				},
+			},
+			pod: &mockPod{
+				returnValueFotGetQOSClass: corev1.PodQOSGuaranteed,
+				labels:                    map[string]string{"job-name": "pi"},
+			},
+			expectedFull: 1,
+		},
 	}
 
 	for _, tc := range tcases {
@@ -1038,6 +1273,9 @@ func TestCpuAllocationPreferences(t *testing.T) {
 			}
 			opt.PreferIsolated, opt.PreferShared = tc.preferIsolated, tc.preferShared
 			opt.ReservedPoolNamespaces = tc.reservedPoolNamespaces
+			opt.IsolatedPoolNamespaces = tc.isolatedPoolNamespaces
+			opt.ExclusiveCPURoundUp = tc.roundUp
+			opt.SharedOnlyPods, opt.SharedOnlyJobPods = tc.sharedOnlyPods, tc.sharedOnlyJobPods
 			full, fraction, isolate, cpuType := cpuAllocationPreferences(tc.pod, tc.container)
 			if full != tc.expectedFull || fraction != tc.expectedFraction ||
 				isolate != tc.expectedIsolate || cpuType != tc.expectedCpuType {
@@ -1048,3 +1286,61 @@ func TestCpuAllocationPreferences(t *testing.T) {
 		})
 	}
 }
+
+func TestParseMemorySpillover(t *testing.T) {
+	tcases := []struct {
+		name     string
+		value    string
+		expected []memoryType
+		fails    bool
+	}{
+		{
+			name:     "full order",
+			value:    "dram,hbm,pmem",
+			expected: []memoryType{memoryDRAM, memoryHBM, memoryPMEM},
+		},
+		{
+			name:     "missing types appended in default order",
+			value:    "HBM",
+			expected: []memoryType{memoryHBM, memoryPMEM, memoryDRAM},
+		},
+		{
+			name:  "unknown type",
+			value: "dram,nvme",
+			fails: true,
+		},
+		{
+			name:  "mixed is not a single type",
+			value: "mixed",
+			fails: true,
+		},
+		{
+			name:  "duplicate type",
+			value: "dram,pmem,dram",
+			fails: true,
+		},
+	}
+	for _, tc := range tcases {
+		t.Run(tc.name, func(t *testing.T) {
+			order, err := parseMemorySpillover(tc.value)
+			if tc.fails {
+				if err == nil {
+					t.Errorf("expected error for %q, got order %v", tc.value, order)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error for %q: %v", tc.value, err)
+			}
+			if len(order) != len(tc.expected) {
+				t.Fatalf("expected order %v, got %v", tc.expected, order)
+			}
+			for i := range order {
+				if order[i] != tc.expected[i] {
+					t.Errorf("expected order %v, got %v", tc.expected, order)
+					break
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,301 @@
+// Copyright 2026 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package topologyaware
+
+import (
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/cache"
+)
+
+const (
+	// annotation key for pod-level CPU pooling
+	podCPUPoolKey = "pod-cpu-pool.topology-aware.resource-manager"
+	// annotation value for sharing a single grant among all containers of a pod
+	podCPUPoolShared = "shared"
+)
+
+// podPool tracks the containers of a pod sharing a single grant.
+type podPool struct {
+	owner   string              // cache ID of the container the grant was allocated for
+	members map[string]struct{} // cache IDs of all containers using the grant
+}
+
+// podCPUPoolPreference returns whether all containers of the pod should share a single grant.
+func podCPUPoolPreference(pod cache.Pod) bool {
+	value, ok := pod.GetAnnotation(podCPUPoolKey)
+	if !ok {
+		return false
+	}
+	if value != podCPUPoolShared {
+		log.Error("invalid pod CPU pool annotation (%q, %q), ignoring it", podCPUPoolKey, value)
+		return false
+	}
+	return true
+}
+
+// podResourceRequirements returns the total CPU request (in milli-CPU), memory
+// request and memory limit of all the (non-init) containers of the pod.
+func podResourceRequirements(pod cache.Pod) (int64, uint64, uint64) {
+	resources := []corev1.ResourceRequirements{}
+	if reqs := pod.GetPodResourceRequirements(); len(reqs.Containers) > 0 {
+		for _, r := range reqs.Containers {
+			resources = append(resources, r)
+		}
+	} else {
+		for _, c := range pod.GetContainers() {
+			resources = append(resources, c.GetResourceRequirements())
+		}
+	}
+
+	cpu, memReq, memLim := int64(0), uint64(0), uint64(0)
+	for _, r := range resources {
+		if q, ok := r.Requests[corev1.ResourceCPU]; ok {
+			cpu += q.MilliValue()
+		}
+		if q, ok := r.Requests[corev1.ResourceMemory]; ok {
+			memReq += uint64(q.Value())
+		}
+		if q, ok := r.Limits[corev1.ResourceMemory]; ok {
+			memLim += uint64(q.Value())
+		}
+	}
+	return cpu, memReq, memLim
+}
+
+// sharedPodPool returns the pod ID of the container, if the container belongs
+// to a pod whose containers share a single grant. Init containers run before
+// the others and get grants of their own.
+func sharedPodPool(c cache.Container) (string, bool) {
+	pod, ok := c.GetPod()
+	if !ok || !podCPUPoolPreference(pod) {
+		return "", false
+	}
+	for _, ic := range pod.GetInitContainers() {
+		if ic.GetCacheID() == c.GetCacheID() {
+			return "", false
+		}
+	}
+	return pod.GetID(), true
+}
+
+// joinPodPool assigns the container to the shared grant of its pod, if the
+// pod already has one. It returns true if the container was assigned.
+func (p *policy) joinPodPool(c cache.Container) bool {
+	podID, ok := sharedPodPool(c)
+	if !ok {
+		return false
+	}
+	pp, ok := p.podPools[podID]
+	if !ok {
+		if pp, ok = p.restorePodPool(podID); !ok {
+			return false
+		}
+	}
+	grant, ok := p.allocations.grants[pp.owner]
+	if !ok {
+		delete(p.podPools, podID)
+		return false
+	}
+
+	log.Debug("* %s joins the shared grant %s of its pod", c.PrettyName(), grant)
+	pp.members[c.GetCacheID()] = struct{}{}
+	p.applyGrant(grant)
+	p.saveAllocations()
+
+	return true
+}
+
+// restorePodPool looks for an existing grant allocated for a container of the
+// pod and records it as the shared grant of the pod. This is only needed if the
+// pod pools were not found in the cache.
+func (p *policy) restorePodPool(podID string) (*podPool, bool) {
+	for id, g := range p.allocations.grants {
+		if g.GetContainer().GetPodID() != podID {
+			continue
+		}
+		if _, ok := sharedPodPool(g.GetContainer()); !ok {
+			continue
+		}
+		pp := &podPool{
+			owner:   id,
+			members: map[string]struct{}{id: {}},
+		}
+		p.podPools[podID] = pp
+		return pp, true
+	}
+	return nil, false
+}
+
+// createPodPool records a newly allocated grant as the shared grant of the
+// container's pod, if the pod asked for one.
+func (p *policy) createPodPool(c cache.Container) {
+	podID, ok := sharedPodPool(c)
+	if !ok {
+		return
+	}
+	id := c.GetCacheID()
+	p.podPools[podID] = &podPool{
+		owner:   id,
+		members: map[string]struct{}{id: {}},
+	}
+	p.saveAllocations()
+}
+
+// leavePodPool removes the container from the shared grant of its pod. It
+// returns true if the container was fully taken care of, so that there is no
+// grant of its own to release. If the container owned the grant which is still
+// in use by others, the grant is handed over to one of the remaining members,
+// or reallocated for it if that fails.
+func (p *policy) leavePodPool(c cache.Container) bool {
+	podID, ok := sharedPodPool(c)
+	if !ok {
+		return false
+	}
+	pp, ok := p.podPools[podID]
+	if !ok {
+		return false
+	}
+
+	id := c.GetCacheID()
+	delete(pp.members, id)
+	if pp.owner != id {
+		log.Debug("* %s leaves the shared grant of its pod", c.PrettyName())
+		p.saveAllocations()
+		return true
+	}
+
+	delete(p.podPools, podID)
+	oldGrant, found := p.releasePool(c)
+	if !found {
+		return true
+	}
+
+	members := make([]string, 0, len(pp.members))
+	for member := range pp.members {
+		members = append(members, member)
+	}
+	sort.Strings(members)
+
+	for _, member := range members {
+		owner, ok := p.cache.LookupContainer(member)
+		if !ok {
+			continue
+		}
+		grant, err := p.handOverGrant(oldGrant, owner)
+		if err != nil {
+			log.Warn("failed to hand over shared grant of pod to %s, reallocating: %v",
+				owner.PrettyName(), err)
+			grant, err = p.allocatePool(owner, oldGrant.GetCPUNode().Name())
+		}
+		if err != nil {
+			log.Error("failed to reallocate shared grant of pod for %s: %v",
+				owner.PrettyName(), err)
+			continue
+		}
+		pp.owner = member
+		p.podPools[podID] = pp
+		p.applyGrant(grant)
+		p.updateSharedAllocations(&grant)
+		return true
+	}
+
+	if len(members) > 0 {
+		log.Error("no container of the pod of %s could take over its shared grant, "+
+			"containers %v are left without resources", c.PrettyName(), members)
+	}
+	p.updateSharedAllocations(&oldGrant)
+	p.saveAllocations()
+
+	return true
+}
+
+// handOverGrant reserves the resources of a released shared grant of a pod
+// for another container of the pod, which becomes the owner of the grant.
+func (p *policy) handOverGrant(old Grant, c cache.Container) (Grant, error) {
+	grant := newGrant(old.GetCPUNode(), c, old.CPUType(), old.ExclusiveCPUs(), old.CPUPortion(),
+		old.MemoryType(), old.MemLimit(), 0)
+	supply := grant.GetCPUNode().FreeSupply()
+	if err := supply.Reserve(grant); err != nil {
+		return nil, err
+	}
+	if err := supply.ReserveMemory(grant); err != nil {
+		supply.ReleaseCPU(grant)
+		return nil, err
+	}
+	p.allocations.grants[c.GetCacheID()] = grant
+	p.saveAllocations()
+	return grant, nil
+}
+
+// podPoolOwners returns the owners of the shared pod grants, by member.
+func (p *policy) podPoolOwners() map[string]string {
+	owners := map[string]string{}
+	for _, pp := range p.podPools {
+		for member := range pp.members {
+			owners[member] = pp.owner
+		}
+	}
+	return owners
+}
+
+// restorePodPools restores the shared pod grants from their owners by member.
+func (p *policy) restorePodPools(owners map[string]string) {
+	p.podPools = make(map[string]*podPool)
+	for member, owner := range owners {
+		c, ok := p.cache.LookupContainer(owner)
+		if !ok {
+			continue
+		}
+		if _, ok := p.cache.LookupContainer(member); !ok {
+			continue
+		}
+		podID := c.GetPodID()
+		pp, ok := p.podPools[podID]
+		if !ok {
+			pp = &podPool{
+				owner:   owner,
+				members: map[string]struct{}{},
+			}
+			p.podPools[podID] = pp
+		}
+		pp.members[member] = struct{}{}
+	}
+}
+
+// grantContainers returns all containers using the grant.
+func (p *policy) grantContainers(g Grant) []cache.Container {
+	owner := g.GetContainer()
+	containers := []cache.Container{owner}
+	podID, ok := sharedPodPool(owner)
+	if !ok {
+		return containers
+	}
+	pp, ok := p.podPools[podID]
+	if !ok || pp.owner != owner.GetCacheID() {
+		return containers
+	}
+	for id := range pp.members {
+		if id == pp.owner {
+			continue
+		}
+		if c, ok := p.cache.LookupContainer(id); ok {
+			containers = append(containers, c)
+		}
+	}
+	return containers
+}
@@ -0,0 +1,79 @@
+// Copyright 2026 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package topologyaware
+
+import (
+	"testing"
+
+	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/cache"
+)
+
+func TestSharedPodPool(t *testing.T) {
+	pod := &mockPod{
+		name:        "pod",
+		annotations: map[string]string{podCPUPoolKey: podCPUPoolShared},
+	}
+	init := &mockContainer{name: "init", returnValueForGetCacheID: "init", pod: pod}
+	main := &mockContainer{name: "main", returnValueForGetCacheID: "main", pod: pod}
+	pod.initContainers = []cache.Container{init}
+	pod.containers = []cache.Container{main}
+
+	if podID, ok := sharedPodPool(main); !ok || podID != "pod" {
+		t.Errorf("expected container to share the grant of pod %q, got %q (%v)", "pod", podID, ok)
+	}
+	if _, ok := sharedPodPool(init); ok {
+		t.Errorf("expected init container not to share the grant of its pod")
+	}
+}
+
+func TestRestorePodPools(t *testing.T) {
+	pod := &mockPod{
+		name:        "pod",
+		annotations: map[string]string{podCPUPoolKey: podCPUPoolShared},
+	}
+	owner := &mockContainer{name: "owner", returnValueForGetCacheID: "owner", pod: pod}
+	p := &policy{
+		cache: &mockCache{
+			returnValue1ForLookupContainer: owner,
+			returnValue2ForLookupContainer: true,
+		},
+		podPools: map[string]*podPool{
+			"pod": {
+				owner:   "owner",
+				members: map[string]struct{}{"owner": {}, "member1": {}, "member2": {}},
+			},
+		},
+	}
+
+	owners := p.podPoolOwners()
+	if len(owners) != 3 {
+		t.Fatalf("expected 3 pod pool members, got %v", owners)
+	}
+	for member, o := range owners {
+		if o != "owner" {
+			t.Errorf("expected owner %q of %s, got %q", "owner", member, o)
+		}
+	}
+
+	p.restorePodPools(owners)
+	pp, ok := p.podPools["pod"]
+	if !ok {
+		t.Fatalf("failed to restore pod pool")
+	}
+	if pp.owner != "owner" || len(pp.members) != 3 {
+		t.Errorf("expected pod pool owned by %q with 3 members, got %q with %v",
+			"owner", pp.owner, pp.members)
+	}
+}
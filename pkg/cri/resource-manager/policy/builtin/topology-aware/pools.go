@@ -42,6 +42,8 @@ func (p *policy) buildPoolsByTopology() error {
 	//   of its parent (a die or a socket pool node). Resources for each
 	//   such node will get discovered by and assigned to the would be
 	//   parent which is now a leaf (die or socket) node in the tree.
+	//   The PoolLevels option can override this, forcing die and NUMA
+	//   node pools to be either always or never created.
 	//
 	//   The PMEM memory of (omitted) PMEM-only nodes is assigned
 	//   to one of the closest normal (DRAM) NUMA nodes. This right
@@ -57,6 +59,11 @@ func (p *policy) buildPoolsByTopology() error {
 	log.Debug("building topology pool tree...")
 
 	p.nodes = make(map[string]Node)
+	p.poolLevels = map[string]poolLevelMode{
+		diePoolLevel:  poolLevel(diePoolLevel),
+		numaPoolLevel: poolLevel(numaPoolLevel),
+	}
+	p.hugePageNodes = dedicatedHugePageNodes()
 
 	// create a virtual root node, if we have a multi-socket system
 	if p.sys.SocketCount() > 1 {
@@ -85,11 +92,16 @@ func (p *policy) buildPoolsByTopology() error {
 		sockets[socketID] = socket
 	}
 
-	// create dies for every socket, but only if we have more than one die in the socket
+	// create dies for every socket, by default only if we have more than one die in the socket
+	dieLevel, numaLevel := p.poolLevels[diePoolLevel], p.poolLevels[numaPoolLevel]
 	numaDies := map[idset.ID]Node{} // created die Nodes per NUMA node id
 	for socketID, socket := range sockets {
 		dieIDs := p.sys.Package(socketID).DieIDs()
-		if len(dieIDs) < 2 {
+		if dieLevel == neverPoolLevel {
+			log.Debug("      - omitted die pools of %q (disabled by configuration)", socket.Name())
+			continue
+		}
+		if dieLevel == autoPoolLevel && len(dieIDs) < 2 {
 			log.Debug("      - omitted pool %q (die count: %d)", socket.Name()+"/die #0",
 				len(dieIDs))
 			continue
@@ -133,24 +145,18 @@ func (p *policy) buildPoolsByTopology() error {
 		//   any closest PMEM-only NUMA node that the original one would have received.
 		//
 
-		if die, ok := numaDies[numaNodeID]; ok {
-			if p.parentNumaNodeCountWithCPUs(numaSysNode) < 2 {
-				numaSurrogates[numaNodeID] = die
-				log.Debug("        - omitted pool \"NUMA node #%d\": using surrogate %q",
-					numaNodeID, numaSurrogates[numaNodeID].Name())
-				continue
-			}
-			numaNode = p.NewNumaNode(numaNodeID, die)
-		} else {
-			socket := sockets[p.sys.Node(numaNodeID).PackageID()]
-			if p.parentNumaNodeCountWithCPUs(numaSysNode) < 2 {
-				numaSurrogates[numaNodeID] = socket
-				log.Debug("        - omitted pool \"NUMA node #%d\": using surrogate %q",
-					numaNodeID, numaSurrogates[numaNodeID].Name())
-				continue
-			}
-			numaNode = p.NewNumaNode(numaNodeID, socket)
+		parent, haveDie := numaDies[numaNodeID]
+		if !haveDie {
+			parent = sockets[numaSysNode.PackageID()]
 		}
+		if numaLevel == neverPoolLevel ||
+			(numaLevel == autoPoolLevel && p.parentNumaNodeCountWithCPUs(numaSysNode, haveDie) < 2) {
+			numaSurrogates[numaNodeID] = parent
+			log.Debug("        - omitted pool \"NUMA node #%d\": using surrogate %q",
+				numaNodeID, numaSurrogates[numaNodeID].Name())
+			continue
+		}
+		numaNode = p.NewNumaNode(numaNodeID, parent)
 
 		p.nodes[numaNode.Name()] = numaNode
 		numaSurrogates[numaNodeID] = numaNode
@@ -196,11 +202,15 @@ func (p *policy) buildPoolsByTopology() error {
 }
 
 // parentNumaNodeCountWithCPUs returns the number of CPU-ful NUMA nodes in the parent die/socket.
-func (p *policy) parentNumaNodeCountWithCPUs(numaNode system.Node) int {
+func (p *policy) parentNumaNodeCountWithCPUs(numaNode system.Node, inDie bool) int {
 	socketID := numaNode.PackageID()
 	socket := p.sys.Package(socketID)
+	nodeIDs := socket.NodeIDs()
+	if inDie {
+		nodeIDs = socket.DieNodeIDs(numaNode.DieID())
+	}
 	count := 0
-	for _, nodeID := range socket.DieNodeIDs(numaNode.DieID()) {
+	for _, nodeID := range nodeIDs {
 		node := p.sys.Node(nodeID)
 		if !node.CPUSet().IsEmpty() {
 			count++
@@ -358,6 +368,11 @@ func (p *policy) allocatePool(container cache.Container, poolHint string) (Grant
 		}
 
 		scores, pools := p.sortPoolsByScore(request, affinity)
+		if len(pools) == 0 && p.evictLowerPriority(request, affinity) {
+			scores, pools = p.sortPoolsByScore(request, affinity)
+		}
+		pools = p.preferHugePageNodes(container, pools)
+		pools = p.balancePools(request, pools)
 
 		if log.DebugEnabled() {
 			log.Debug("* node fitting for %s", request)
@@ -368,6 +383,10 @@ func (p *policy) allocatePool(container cache.Container, poolHint string) (Grant
 		}
 
 		if len(pools) == 0 {
+			if _, reason := p.filterPools(request); reason != "" {
+				return nil, policyError("no suitable pool found for container %s, %s",
+					container.PrettyName(), reason)
+			}
 			return nil, policyError("no suitable pool found for container %s",
 				container.PrettyName())
 		}
@@ -575,6 +594,7 @@ func (p *policy) allocatePool(container cache.Container, poolHint string) (Grant
 				if changed {
 					log.Debug("* moved container %s upward to node %s to guarantee memory",
 						oldGrant.GetContainer().PrettyName(), oldGrant.GetMemoryNode().Name())
+					p.pushedUpGrants++
 					break
 				}
 			}
@@ -592,11 +612,11 @@ func (p *policy) allocatePool(container cache.Container, poolHint string) (Grant
 func (p *policy) applyGrant(grant Grant) {
 	log.Debug("* applying grant %s", grant)
 
-	container := grant.GetContainer()
+	containers := p.grantContainers(grant)
 	cpuType := grant.CPUType()
 	exclusive := grant.ExclusiveCPUs()
 	reserved := grant.ReservedCPUs()
-	shared := grant.SharedCPUs()
+	shared := p.lendReservedCPUs(grant.GetCPUNode(), grant.SharedCPUs())
 	cpuPortion := grant.SharedPortion()
 
 	cpus := ""
@@ -623,10 +643,7 @@ func (p *policy) applyGrant(grant Grant) {
 		return
 	}
 
-	mems := ""
-	if opt.PinMemory {
-		mems = grant.Memset().String()
-	}
+	mems := grantMems(grant)
 
 	if opt.PinCPU {
 		if cpus != "" {
@@ -634,7 +651,9 @@ func (p *policy) applyGrant(grant Grant) {
 		} else {
 			log.Debug("  => not pinning CPUs, allocated cpuset is empty...")
 		}
-		container.SetCpusetCpus(cpus)
+		for _, container := range containers {
+			p.setCpusetCpus(container, cpus)
+		}
 
 		// Notes:
 		//     It is extremely important to ensure that the exclusive subset of mixed
@@ -656,13 +675,23 @@ func (p *policy) applyGrant(grant Grant) {
 		//     processes in the same pool. Also the 'data' process should run fine, since
 		//     it does not need to compete for CPU with any other processes in the system
 		//     as long as that allocation is genuinely system-wide exclusive.
-		container.SetCPUShares(int64(cache.MilliCPUToShares(int64(cpuPortion))))
+		for _, container := range containers {
+			p.setCPUShares(container, int64(cache.MilliCPUToShares(int64(cpuPortion))))
+		}
+		for _, container := range containers {
+			p.updatePodPartition(container)
+			p.updateCpusetExclusive(container)
+		}
 	}
 
 	if mems != "" {
 		log.Debug("  => pinning to memory %s", mems)
-		container.SetCpusetMems(mems)
-		p.setDemotionPreferences(container, grant)
+		for _, container := range containers {
+			p.setCpusetMems(container, mems)
+			if opt.PinMemory {
+				p.setDemotionPreferences(container, grant)
+			}
+		}
 	} else {
 		log.Debug("  => not pinning memory, memory set is empty...")
 	}
@@ -691,11 +720,15 @@ func (p *policy) releasePool(container cache.Container) (Grant, bool) {
 
 // Update shared allocations effected by agrant.
 func (p *policy) updateSharedAllocations(grant *Grant) {
+	lendingChanged := p.updateReservedLending()
 	if grant != nil {
 		log.Debug("* updating shared allocations affected by %s", (*grant).String())
 		if (*grant).CPUType() == cpuReserved {
-			log.Debug("  this grant uses reserved CPUs, does not affect shared allocations")
-			return
+			if !lendingChanged {
+				log.Debug("  this grant uses reserved CPUs, does not affect shared allocations")
+				return
+			}
+			log.Debug("  lending of idle reserved CPUs changed to %v", p.lendingReserved)
 		}
 	} else {
 		log.Debug("* updating shared allocations")
@@ -719,19 +752,54 @@ func (p *policy) updateSharedAllocations(grant *Grant) {
 		}
 
 		if opt.PinCPU {
-			shared := other.GetCPUNode().FreeSupply().SharableCPUs()
+			shared := p.lendReservedCPUs(other.GetCPUNode(), other.GetCPUNode().FreeSupply().SharableCPUs())
 			exclusive := other.ExclusiveCPUs()
 			if exclusive.IsEmpty() {
 				log.Debug("  => updating %s with shared CPUs of %s: %s...",
 					other, other.GetCPUNode().Name(), shared.String())
-				other.GetContainer().SetCpusetCpus(shared.String())
+				for _, c := range p.grantContainers(other) {
+					p.setCpusetCpus(c, shared.String())
+				}
 			} else {
 				log.Debug("  => updating %s with exclusive+shared CPUs of %s: %s+%s...",
 					other, other.GetCPUNode().Name(), exclusive.String(), shared.String())
-				other.GetContainer().SetCpusetCpus(exclusive.Union(shared).String())
+				for _, c := range p.grantContainers(other) {
+					p.setCpusetCpus(c, exclusive.Union(shared).String())
+				}
+			}
+		}
+	}
+}
+
+// updateReservedLending updates whether idle reserved CPUs are lent to shared
+// allocations, based on the current utilization of reserved CPUs. It returns
+// true if lending was turned on or off.
+func (p *policy) updateReservedLending() bool {
+	lend := false
+	if threshold := opt.ReservedCPUSharingThreshold; threshold > 0 {
+		capacity := 1000 * p.root.GetSupply().ReservedCPUs().Size()
+		granted := 0
+		for _, g := range p.allocations.grants {
+			if g.CPUType() == cpuReserved {
+				granted += g.ReservedPortion()
 			}
 		}
+		lend = capacity > 0 && 100*granted < threshold*capacity
+		log.Debug("* reserved CPU utilization %d/%d mCPU, lending to shared allocations: %v",
+			granted, capacity, lend)
+	}
+	changed := lend != p.lendingReserved
+	p.lendingReserved = lend
+	return changed
+}
+
+// lendReservedCPUs extends the given shared CPUs of a node with the reserved
+// CPUs of the node, if idle reserved CPUs are currently lent out.
+func (p *policy) lendReservedCPUs(node Node, shared cpuset.CPUSet) cpuset.CPUSet {
+	if !p.lendingReserved {
+		return shared
 	}
+	return shared.Union(node.GetSupply().ReservedCPUs())
 }
 
 // setDemotionPreferences sets the dynamic demotion preferences a container.
@@ -744,22 +812,57 @@ func (p *policy) setDemotionPreferences(c cache.Container, g Grant) {
 		return
 	}
 
+	// Demote along the memory tiers of the node, from the fastest to the slowest.
 	memType := g.GetMemoryNode().GetMemoryType()
-	if memType&memoryDRAM == 0 || memType&memoryPMEM == 0 {
+	tiers := []idset.IDSet{}
+	for _, kind := range []memoryType{memoryHBM, memoryDRAM, memoryPMEM} {
+		if memType&kind == 0 {
+			continue
+		}
+		if nodes := g.GetMemoryNode().GetMemset(kind); nodes.Size() > 0 {
+			tiers = append(tiers, nodes)
+		}
+	}
+	if len(tiers) < 2 {
 		c.SetPageMigration(nil)
 		return
 	}
 
-	dram := g.GetMemoryNode().GetMemset(memoryDRAM)
-	pmem := g.GetMemoryNode().GetMemset(memoryPMEM)
+	log.Debug("%s: eligible for demotion along NUMA node tiers %v", c.PrettyName(), tiers)
 
-	log.Debug("%s: eligible for demotion from %s to %s NUMA node(s)",
-		c.PrettyName(), dram, pmem)
+	pm := &cache.PageMigrate{
+		SourceNodes: tiers[0],
+		TargetNodes: tiers[1],
+	}
+	if len(tiers) > 2 {
+		pm.Tiers = tiers
+	}
+	c.SetPageMigration(pm)
+}
 
-	c.SetPageMigration(&cache.PageMigrate{
-		SourceNodes: dram,
-		TargetNodes: pmem,
-	})
+// filterPools filters out pools which can't take the request. If no pool
+// is left, it returns the reason of the filter which removed the last ones,
+// or "" if no pool has sufficient memory for the request.
+func (p *policy) filterPools(req Request) ([]Node, string) {
+	// Filter out pools which don't have enough uncompressible resources
+	// (memory) to satisfy the request.
+	pools := p.filterInsufficientResources(req, p.pools)
+	for _, filter := range []struct {
+		filter func(Request, []Node) []Node
+		reason string
+	}{
+		{p.filterFullPools, "all eligible pools are at their maximum container count"},
+		{p.filterSMTAntiAffinity, "no eligible pool has enough CPUs outside SMT anti-affine CPUs"},
+		{p.filterOverBudgetPools, "all eligible pools are over their active CPU or power budget"},
+	} {
+		if len(pools) == 0 {
+			break
+		}
+		if pools = filter.filter(req, pools); len(pools) == 0 {
+			return pools, filter.reason
+		}
+	}
+	return pools, ""
 }
 
 func (p *policy) filterInsufficientResources(req Request, originals []Node) []Node {
@@ -771,6 +874,11 @@ func (p *policy) filterInsufficientResources(req Request, originals []Node) []No
 		// it's DRAM, amount of PMEM should not be considered and so on. How to find this out in a live
 		// system?
 
+		if _, _, err := memsetWithFallback(node, initialMemoryType(req.MemoryType(), req.ColdStart())); err != nil {
+			log.Debug("%s: filtered out %s: %v", req.GetContainer().PrettyName(), node.Name(), err)
+			continue
+		}
+
 		supply := node.FreeSupply()
 		reqMemType := req.MemoryType()
 
@@ -782,7 +890,7 @@ func (p *policy) filterInsufficientResources(req Request, originals []Node) []No
 
 		required := req.MemAmountToAllocate()
 
-		for _, memType := range []memoryType{memoryPMEM, memoryDRAM, memoryHBM} {
+		for _, memType := range req.MemorySpillover() {
 			if reqMemType&memType != 0 {
 				extra := supply.ExtraMemoryReservation(memType)
 				free := supply.MemoryLimit()[memType]
@@ -811,6 +919,105 @@ func (p *policy) filterInsufficientResources(req Request, originals []Node) []No
 	return sufficient
 }
 
+// filterSMTAntiAffinity filters out pools which can't provide the exclusive CPUs
+// of the request from cores disjoint with its SMT anti-affine CPUs.
+func (p *policy) filterSMTAntiAffinity(req Request, originals []Node) []Node {
+	if req.FullCPUs() == 0 {
+		return originals
+	}
+	avoid := p.smtAntiAffineCPUs(req.GetContainer())
+	if avoid.IsEmpty() {
+		return originals
+	}
+
+	// Like AllocateCPU, take all full CPUs either from the isolated or
+	// from the sharable CPUs, never from both.
+	full := req.FullCPUs()
+	isolatedOK, _ := checkIsolatedPoolNamespaces(req.GetContainer().GetNamespace())
+	available := make([]Node, 0, len(originals))
+	for _, node := range originals {
+		supply := node.FreeSupply()
+		isolated := supply.IsolatedCPUs().Difference(avoid)
+		sharable := supply.SharableCPUs().Difference(avoid)
+		if req.Isolate() && isolatedOK && isolated.Size() >= full {
+			available = append(available, node)
+			continue
+		}
+		if supply.AllocatableSharedCPU(true) > 1000*full && sharable.Size() >= full {
+			available = append(available, node)
+			continue
+		}
+		log.Debug("%s: filtered out %s with %d isolated, %d sharable CPUs outside SMT anti-affine CPUs %s",
+			req.GetContainer().PrettyName(), node.Name(), isolated.Size(), sharable.Size(), avoid)
+	}
+	return available
+}
+
+// filterOverBudgetPools filters out pools which can't give the request exclusive
+// CPUs without exceeding their active CPU or power budget.
+func (p *policy) filterOverBudgetPools(req Request, originals []Node) []Node {
+	if req.FullCPUs() == 0 || (len(opt.MaxActiveCPUs) == 0 && len(opt.PowerBudget) == 0) {
+		return originals
+	}
+
+	available := make([]Node, 0, len(originals))
+	for _, node := range originals {
+		if reason, over := p.overBudget(req, node); over {
+			log.Debug("%s: filtered out %s, over budget: %s",
+				req.GetContainer().PrettyName(), node.Name(), reason)
+			continue
+		}
+		available = append(available, node)
+	}
+	return available
+}
+
+// filterFullPools filters out pools which already have their maximum number of containers.
+func (p *policy) filterFullPools(req Request, originals []Node) []Node {
+	if opt.MaxContainersPerPool <= 0 && len(opt.MaxContainersByPool) == 0 {
+		return originals
+	}
+
+	available := make([]Node, 0, len(originals))
+	for _, node := range originals {
+		if limit := p.maxContainers(node); limit > 0 {
+			if count := p.containerCount(node); count >= limit {
+				log.Debug("%s: filtered out %s with %d containers (max. %d)",
+					req.GetContainer().PrettyName(), node.Name(), count, limit)
+				continue
+			}
+		}
+		available = append(available, node)
+	}
+	return available
+}
+
+// maxContainers returns the maximum number of containers allowed in a pool, 0 for no limit.
+// The global limit does not apply to the root pool, which holds all containers.
+func (p *policy) maxContainers(node Node) int {
+	if limit, ok := opt.MaxContainersByPool[node.Name()]; ok {
+		return limit
+	}
+	if node.Parent().IsNil() {
+		return 0
+	}
+	return opt.MaxContainersPerPool
+}
+
+// containerCount returns the number of containers allocated to a pool or any of its children.
+func (p *policy) containerCount(node Node) int {
+	count := 0
+	for _, g := range p.allocations.grants {
+		for n := g.GetCPUNode(); !n.IsNil(); n = n.Parent() {
+			if n.NodeID() == node.NodeID() {
+				count += len(p.grantContainers(g))
+				break
+			}
+		}
+	}
+	return count
+}
+
 // Score pools against the request and sort them by score.
 func (p *policy) sortPoolsByScore(req Request, aff map[int]int32) (map[int]Score, []Node) {
 	scores := make(map[int]Score, p.nodeCnt)
@@ -820,9 +1027,7 @@ func (p *policy) sortPoolsByScore(req Request, aff map[int]int32) (map[int]Score
 		return nil
 	})
 
-	// Filter out pools which don't have enough uncompressible resources
-	// (memory) to satisfy the request.
-	filteredPools := p.filterInsufficientResources(req, p.pools)
+	filteredPools, _ := p.filterPools(req)
 
 	sort.Slice(filteredPools, func(i, j int) bool {
 		return p.compareScores(req, filteredPools, scores, aff, i, j)
@@ -843,6 +1048,7 @@ func (p *policy) compareScores(request Request, pools []Node, scores map[int]Sco
 	isolated2, reserved2, shared2 := score2.IsolatedCapacity(), score2.ReservedCapacity(), score2.SharedCapacity()
 	a1 := affinityScore(affinity, node1)
 	a2 := affinityScore(affinity, node2)
+	preference := localityPreference()
 
 	log.Debug("comparing scores for %s and %s", node1.Name(), node2.Name())
 	log.Debug("  %s: %s, affinity score %f", node1.Name(), score1.String(), a1)
@@ -856,6 +1062,11 @@ func (p *policy) compareScores(request Request, pools []Node, scores map[int]Sco
 	// 1) - insufficient isolated, reserved or shared capacity loses
 	// 2) - if we have affinity, the higher affinity score wins
 	// 3) - if only one node matches the memory type request, it wins
+	//     - for HBM requests, a node with insufficient HBM bandwidth loses
+	//     - with memory locality preferred, this is done before 2) and then
+	//       among nodes at the same depth more free memory of the type wins
+	//     - with CPU locality preferred, this is done after 5) and in case of
+	//       a tie in 4) before resorting to the id
 	// 4) - if we have topology hints
 	//       * better hint score wins
 	//       * for a tie, prefer the lower node then the smaller id
@@ -894,6 +1105,13 @@ func (p *policy) compareScores(request Request, pools []Node, scores map[int]Sco
 
 	log.Debug("  - isolated/reserved/shared insufficiency is a TIE")
 
+	// 3) matching memory type wins, if memory locality is preferred
+	if preference == preferMemoryLocality {
+		if wins, decided := p.compareMemoryScores(request, node1, node2, score1, score2); decided {
+			return wins
+		}
+	}
+
 	// 2) higher affinity score wins
 	if a1 > a2 {
 		log.Debug("  => %s loses on affinity", node2.Name())
@@ -907,17 +1125,10 @@ func (p *policy) compareScores(request Request, pools []Node, scores map[int]Sco
 	log.Debug("  - affinity is a TIE")
 
 	// 3) matching memory type wins
-	if reqType := request.MemoryType(); reqType != memoryUnspec {
-		if node1.HasMemoryType(reqType) && !node2.HasMemoryType(reqType) {
-			log.Debug("  => %s WINS on memory type", node1.Name())
-			return true
-		}
-		if !node1.HasMemoryType(reqType) && node2.HasMemoryType(reqType) {
-			log.Debug("  => %s WINS on memory type", node2.Name())
-			return false
+	if preference == preferBalancedLocality {
+		if wins, decided := p.compareMemoryScores(request, node1, node2, score1, score2); decided {
+			return wins
 		}
-
-		log.Debug("  - memory type is a TIE")
 	}
 
 	// 4) better topology hint score wins
@@ -962,6 +1173,13 @@ func (p *policy) compareScores(request Request, pools []Node, scores map[int]Sco
 				return false
 			}
 
+			// 3) matching memory type wins, if CPU locality is preferred
+			if preference == preferCPULocality {
+				if wins, decided := p.compareMemoryScores(request, node1, node2, score1, score2); decided {
+					return wins
+				}
+			}
+
 			log.Debug("  => %s WINS based on equal hint socres, lower id",
 				map[bool]string{true: node1.Name(), false: node2.Name()}[id1 < id2])
 
@@ -981,6 +1199,13 @@ func (p *policy) compareScores(request Request, pools []Node, scores map[int]Sco
 
 	log.Debug("  - depth is a TIE")
 
+	// 3) matching memory type wins, if CPU locality is preferred
+	if preference == preferCPULocality {
+		if wins, decided := p.compareMemoryScores(request, node1, node2, score1, score2); decided {
+			return wins
+		}
+	}
+
 	if request.CPUType() == cpuReserved {
 		// 6) if requesting reserved CPUs, more reserved
 		//    capacity per colocated container wins. Reserved
@@ -1057,6 +1282,73 @@ func (p *policy) compareScores(request Request, pools []Node, scores map[int]Sco
 	return id1 < id2
 }
 
+// compareMemoryScores compares the nodes by their memory. It returns whether node1
+// wins and whether the comparison was decisive.
+func (p *policy) compareMemoryScores(request Request, node1, node2 Node, score1, score2 Score) (bool, bool) {
+	reqType := request.MemoryType()
+	if reqType == memoryUnspec {
+		return false, false
+	}
+
+	if node1.HasMemoryType(reqType) && !node2.HasMemoryType(reqType) {
+		log.Debug("  => %s WINS on memory type", node1.Name())
+		return true, true
+	}
+	if !node1.HasMemoryType(reqType) && node2.HasMemoryType(reqType) {
+		log.Debug("  => %s WINS on memory type", node2.Name())
+		return false, true
+	}
+
+	log.Debug("  - memory type is a TIE")
+
+	bw1, hbm1 := score1.HBMBandwidth()
+	bw2, hbm2 := score2.HBMBandwidth()
+	if hbm1 && hbm2 {
+		if bw1 >= 0 && bw2 < 0 {
+			log.Debug("  => %s loses, insufficient HBM bandwidth", node2.Name())
+			return true, true
+		}
+		if bw1 < 0 && bw2 >= 0 {
+			log.Debug("  => %s loses, insufficient HBM bandwidth", node1.Name())
+			return false, true
+		}
+
+		log.Debug("  - HBM bandwidth is a TIE")
+	}
+
+	if localityPreference() != preferMemoryLocality || node1.RootDistance() != node2.RootDistance() {
+		return false, false
+	}
+
+	free1, free2 := freeMemoryOfType(score1.Supply(), reqType), freeMemoryOfType(score2.Supply(), reqType)
+	if free1 > free2 {
+		log.Debug("  => %s WINS on more free memory", node1.Name())
+		return true, true
+	}
+	if free2 > free1 {
+		log.Debug("  => %s WINS on more free memory", node2.Name())
+		return false, true
+	}
+
+	log.Debug("  - free memory is a TIE")
+
+	return false, false
+}
+
+// freeMemoryOfType returns the amount of free memory of the given types in the supply.
+func freeMemoryOfType(supply Supply, memType memoryType) uint64 {
+	free := uint64(0)
+	for _, t := range []memoryType{memoryPMEM, memoryDRAM, memoryHBM} {
+		if memType&t == 0 {
+			continue
+		}
+		if limit, extra := supply.MemoryLimit()[t], supply.ExtraMemoryReservation(t); limit > extra {
+			free += limit - extra
+		}
+	}
+	return free
+}
+
 // affinityScore calculate the 'goodness' of the affinity for a node.
 func affinityScore(affinities map[int]int32, node Node) float64 {
 	Q := 0.75
@@ -29,6 +29,7 @@ import (
 	system "github.com/intel/cri-resource-manager/pkg/sysfs"
 	"github.com/intel/cri-resource-manager/pkg/utils"
 	"github.com/intel/cri-resource-manager/pkg/utils/cpuset"
+	idset "github.com/intel/goresctrl/pkg/utils"
 )
 
 func findNodeWithID(id int, nodes []Node) Node {
@@ -429,6 +430,89 @@ func TestPoolCreation(t *testing.T) {
 	}
 }
 
+func TestPoolLevels(t *testing.T) {
+	dir, err := os.MkdirTemp("", "cri-resource-manager-test-sysfs-")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+
+	err = utils.UncompressTbz2(path.Join("testdata", "sysfs.tar.bz2"), dir)
+	if err != nil {
+		panic(err)
+	}
+
+	tcases := []struct {
+		path          string
+		name          string
+		levels        map[string]poolLevelMode
+		expectedPools int
+		expectedDepth int
+	}{
+		{
+			path:          path.Join(dir, "sysfs", "server", "sys"),
+			name:          "default pool levels",
+			expectedPools: 7,
+			expectedDepth: 2,
+		},
+		{
+			path:          path.Join(dir, "sysfs", "server", "sys"),
+			name:          "no NUMA node pools",
+			levels:        map[string]poolLevelMode{numaPoolLevel: neverPoolLevel},
+			expectedPools: 3,
+			expectedDepth: 1,
+		},
+		{
+			path:          path.Join(dir, "sysfs", "server", "sys"),
+			name:          "forced die pools",
+			levels:        map[string]poolLevelMode{diePoolLevel: alwaysPoolLevel},
+			expectedPools: 9,
+			expectedDepth: 3,
+		},
+		{
+			path:          path.Join(dir, "sysfs", "desktop", "sys"),
+			name:          "forced NUMA node pools",
+			levels:        map[string]poolLevelMode{numaPoolLevel: alwaysPoolLevel},
+			expectedPools: 2,
+			expectedDepth: 1,
+		},
+	}
+	for _, tc := range tcases {
+		t.Run(tc.name, func(t *testing.T) {
+			sys, err := system.DiscoverSystemAt(tc.path)
+			if err != nil {
+				panic(err)
+			}
+
+			opt.PoolLevels = tc.levels
+			defer func() { opt.PoolLevels = nil }()
+
+			reserved, _ := resapi.ParseQuantity("750m")
+			policyOptions := &policyapi.BackendOptions{
+				Cache:  &mockCache{},
+				System: sys,
+				Reserved: policyapi.ConstraintSet{
+					policyapi.DomainCPU: reserved,
+				},
+			}
+
+			policy := CreateTopologyAwarePolicy(policyOptions).(*policy)
+
+			if len(policy.pools) != tc.expectedPools {
+				t.Errorf("expected %d pools, got %d: %v", tc.expectedPools, len(policy.pools), policy.pools)
+			}
+			if policy.depth != tc.expectedDepth {
+				t.Errorf("expected pool tree depth %d, got %d", tc.expectedDepth, policy.depth)
+			}
+			supply := policy.root.GetSupply()
+			cpus := supply.SharableCPUs().Union(supply.IsolatedCPUs()).Union(supply.ReservedCPUs())
+			if cpus.Size() != sys.CPUSet().Size() {
+				t.Errorf("expected %d CPUs in root pool, got %d", sys.CPUSet().Size(), cpus.Size())
+			}
+		})
+	}
+}
+
 func TestWorkloadPlacement(t *testing.T) {
 
 	// Do some workloads (containers) and see how they are placed in the
@@ -959,3 +1043,87 @@ func TestAffinities(t *testing.T) {
 		})
 	}
 }
+
+func TestMemsetWithFallback(t *testing.T) {
+	dramOnly := &numanode{node: node{name: "dram-only", mem: idset.NewIDSet(0), pMem: idset.NewIDSet(), hbm: idset.NewIDSet()}}
+	pmemOnly := &numanode{node: node{name: "pmem-only", mem: idset.NewIDSet(), pMem: idset.NewIDSet(2), hbm: idset.NewIDSet()}}
+	dramPmem := &numanode{node: node{name: "dram-pmem", mem: idset.NewIDSet(0), pMem: idset.NewIDSet(2), hbm: idset.NewIDSet()}}
+
+	tcases := []struct {
+		name             string
+		node             Node
+		memType          memoryType
+		fallbacks        map[string]memoryFallback
+		expectedMems     string
+		expectedFallback bool
+		expectError      bool
+	}{
+		{
+			name:         "requested type available",
+			node:         dramPmem,
+			memType:      memoryPMEM,
+			expectedMems: "2",
+		},
+		{
+			name:             "HBM falls back to nearest DRAM by default",
+			node:             dramPmem,
+			memType:          memoryHBM,
+			expectedMems:     "0",
+			expectedFallback: true,
+		},
+		{
+			name:             "DRAM falls back to nearest PMEM without HBM",
+			node:             pmemOnly,
+			memType:          memoryDRAM,
+			expectedMems:     "2",
+			expectedFallback: true,
+		},
+		{
+			name:             "HBM falls back to any memory",
+			node:             dramPmem,
+			memType:          memoryHBM,
+			fallbacks:        map[string]memoryFallback{"hbm": anyMemoryFallback},
+			expectedMems:     "0,2",
+			expectedFallback: true,
+		},
+		{
+			name:        "PMEM fails without fallback",
+			node:        dramOnly,
+			memType:     memoryPMEM,
+			fallbacks:   map[string]memoryFallback{"pmem": failMemoryFallback},
+			expectError: true,
+		},
+		{
+			name:         "fallback of other types does not matter",
+			node:         dramOnly,
+			memType:      memoryDRAM,
+			fallbacks:    map[string]memoryFallback{"pmem": failMemoryFallback},
+			expectedMems: "0",
+		},
+		{
+			name:         "unspecified memory type prefers DRAM",
+			node:         dramPmem,
+			memType:      memoryUnspec,
+			expectedMems: "0",
+		},
+	}
+	for _, tc := range tcases {
+		t.Run(tc.name, func(t *testing.T) {
+			opt.MemoryTypeFallback = tc.fallbacks
+			defer func() { opt.MemoryTypeFallback = nil }()
+			mems, fallback, err := memsetWithFallback(tc.node, tc.memType)
+			if tc.expectError {
+				if err == nil {
+					t.Errorf("expected an error, got memory nodes %s", mems)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if mems.String() != tc.expectedMems || fallback != tc.expectedFallback {
+				t.Errorf("expected (%s, %v), got (%s, %v)", tc.expectedMems, tc.expectedFallback, mems, fallback)
+			}
+		})
+	}
+}
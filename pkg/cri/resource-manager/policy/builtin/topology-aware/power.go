@@ -0,0 +1,204 @@
+// Copyright 2026 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package topologyaware
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/intel/cri-resource-manager/pkg/utils/cpuset"
+	idset "github.com/intel/goresctrl/pkg/utils"
+)
+
+// raplDir is the sysfs directory to discover RAPL power zones from.
+var raplDir = "/sys/class/powercap"
+
+const (
+	// raplPackagePrefix is the name prefix of package-level RAPL zones.
+	raplPackagePrefix = "package-"
+	// raplSampleInterval is the minimum interval between RAPL energy samples.
+	raplSampleInterval = time.Second
+)
+
+// raplZone estimates the power consumption of a CPU package from its RAPL energy counter.
+type raplZone struct {
+	dir    string    // sysfs directory of the zone
+	max    uint64    // energy counter range, in µJ
+	energy uint64    // last energy counter reading, in µJ
+	sample time.Time // time of the last reading
+	watts  float64   // power estimate, or -1 if not available yet
+}
+
+// discoverRAPLZones returns the package-level RAPL zones by package ID.
+func discoverRAPLZones(dir string) (map[idset.ID]*raplZone, error) {
+	dirs, err := filepath.Glob(filepath.Join(dir, "intel-rapl:*"))
+	if err != nil {
+		return nil, err
+	}
+	zones := map[idset.ID]*raplZone{}
+	for _, zdir := range dirs {
+		// skip subzones (intel-rapl:<pkg>:<zone>)
+		if strings.Count(filepath.Base(zdir), ":") != 1 {
+			continue
+		}
+		name, err := readSysfsEntry(filepath.Join(zdir, "name"))
+		if err != nil || !strings.HasPrefix(name, raplPackagePrefix) {
+			continue
+		}
+		id, err := strconv.Atoi(strings.TrimPrefix(name, raplPackagePrefix))
+		if err != nil {
+			continue
+		}
+		entry, err := readSysfsEntry(filepath.Join(zdir, "max_energy_range_uj"))
+		if err != nil {
+			return nil, err
+		}
+		max, err := strconv.ParseUint(entry, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		zones[idset.ID(id)] = &raplZone{dir: zdir, max: max, watts: -1}
+	}
+	return zones, nil
+}
+
+// update samples the energy counter of the zone, updating the power estimate
+// if enough time has passed since the previous sample.
+func (z *raplZone) update(now time.Time) error {
+	if !z.sample.IsZero() && now.Sub(z.sample) < raplSampleInterval {
+		return nil
+	}
+	entry, err := readSysfsEntry(filepath.Join(z.dir, "energy_uj"))
+	if err != nil {
+		return err
+	}
+	energy, err := strconv.ParseUint(entry, 10, 64)
+	if err != nil {
+		return err
+	}
+	if !z.sample.IsZero() {
+		used := energy - z.energy
+		if energy < z.energy {
+			used = z.max - z.energy + energy
+		}
+		z.watts = float64(used) / 1e6 / now.Sub(z.sample).Seconds()
+	}
+	z.energy, z.sample = energy, now
+	return nil
+}
+
+// updateRAPL discovers the RAPL zones of CPU packages, if power budgets are set.
+func (p *policy) updateRAPL() error {
+	if len(opt.PowerBudget) == 0 {
+		p.rapl = nil
+		return nil
+	}
+	if p.rapl != nil {
+		return nil
+	}
+	zones, err := discoverRAPLZones(raplDir)
+	if err != nil {
+		return policyError("failed to discover RAPL power zones: %v", err)
+	}
+	if len(zones) == 0 {
+		log.Warn("no RAPL power zones found, power budgets won't be enforced")
+	}
+	p.rapl = zones
+	return nil
+}
+
+// poolPower samples and returns the power consumption of the CPU packages
+// of a pool, or false if no estimate is available for all of them.
+func (p *policy) poolPower(node Node) (float64, bool) {
+	return p.sumPoolPower(node, true)
+}
+
+// lastPoolPower returns the latest power estimate of the CPU packages of a
+// pool without sampling the energy counters.
+func (p *policy) lastPoolPower(node Node) (float64, bool) {
+	return p.sumPoolPower(node, false)
+}
+
+// sumPoolPower sums up the power estimates of the CPU packages of a pool,
+// optionally sampling the energy counters first.
+func (p *policy) sumPoolPower(node Node, sample bool) (float64, bool) {
+	if len(p.rapl) == 0 {
+		return 0, false
+	}
+	supply := node.GetSupply()
+	cpus := supply.IsolatedCPUs().Union(supply.ReservedCPUs()).Union(supply.SharableCPUs())
+	packages := map[idset.ID]struct{}{}
+	for _, id := range cpus.List() {
+		packages[p.sys.CPU(id).PackageID()] = struct{}{}
+	}
+
+	now, total, ok := time.Now(), 0.0, len(packages) > 0
+	for id := range packages {
+		zone, found := p.rapl[id]
+		if !found {
+			return 0, false
+		}
+		if sample {
+			if err := zone.update(now); err != nil {
+				log.Error("failed to read RAPL energy of package #%d: %v", id, err)
+				return 0, false
+			}
+		}
+		if zone.watts < 0 {
+			ok = false
+			continue
+		}
+		total += zone.watts
+	}
+	return total, ok
+}
+
+// activeCPUs returns the number of CPUs activated by exclusive and shared
+// grants in a pool or any of its children, with shared portions rounded up.
+func (p *policy) activeCPUs(node Node) int {
+	exclusive, shared := cpuset.New(), 0
+	for _, g := range p.allocations.grants {
+		for n := g.GetCPUNode(); !n.IsNil(); n = n.Parent() {
+			if n.NodeID() == node.NodeID() {
+				exclusive = exclusive.Union(g.ExclusiveCPUs())
+				shared += g.SharedPortion()
+				break
+			}
+		}
+	}
+	return exclusive.Size() + (shared+999)/1000
+}
+
+// overBudget checks if giving the request exclusive CPUs from the pool would
+// exceed the active CPU or power budget of the pool or any of its parents.
+func (p *policy) overBudget(req Request, node Node) (string, bool) {
+	need := req.FullCPUs() + (req.CPUFraction()+999)/1000
+	for n := node; !n.IsNil(); n = n.Parent() {
+		if limit, ok := opt.MaxActiveCPUs[n.Name()]; ok {
+			if active := p.activeCPUs(n); active+need > limit {
+				return fmt.Sprintf("%s with %d active CPUs (max. %d)", n.Name(), active, limit), true
+			}
+		}
+		if budget, ok := opt.PowerBudget[n.Name()]; ok {
+			if watts, ok := p.poolPower(n); ok && watts >= budget {
+				return fmt.Sprintf("%s at %.1f W (budget %.1f W)", n.Name(), watts, budget), true
+			}
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,120 @@
+// Copyright 2026 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package topologyaware
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/intel/cri-resource-manager/pkg/utils/cpuset"
+)
+
+func TestRAPLZones(t *testing.T) {
+	dir := t.TempDir()
+	writeZone := func(zone string, entries map[string]string) {
+		zdir := filepath.Join(dir, zone)
+		if err := os.MkdirAll(zdir, 0755); err != nil {
+			t.Fatalf("failed to create %s: %v", zdir, err)
+		}
+		for name, value := range entries {
+			if err := os.WriteFile(filepath.Join(zdir, name), []byte(value+"\n"), 0644); err != nil {
+				t.Fatalf("failed to write %s: %v", name, err)
+			}
+		}
+	}
+	writeZone("intel-rapl:0", map[string]string{"name": "package-0", "max_energy_range_uj": "1000000000", "energy_uj": "999000000"})
+	writeZone("intel-rapl:0:0", map[string]string{"name": "dram", "max_energy_range_uj": "1000", "energy_uj": "0"})
+	writeZone("intel-rapl:1", map[string]string{"name": "package-1", "max_energy_range_uj": "1000000000", "energy_uj": "0"})
+	writeZone("intel-rapl-mmio:0", map[string]string{"name": "package-0", "max_energy_range_uj": "1", "energy_uj": "0"})
+
+	zones, err := discoverRAPLZones(dir)
+	if err != nil {
+		t.Fatalf("failed to discover RAPL zones: %v", err)
+	}
+	if len(zones) != 2 || zones[0] == nil || zones[1] == nil {
+		t.Fatalf("expected RAPL zones for packages 0 and 1, got %v", zones)
+	}
+
+	zone, now := zones[0], time.Now()
+	if err := zone.update(now); err != nil {
+		t.Fatalf("failed to sample RAPL zone: %v", err)
+	}
+	if zone.watts >= 0 {
+		t.Errorf("expected no power estimate after a single sample, got %f", zone.watts)
+	}
+
+	// counter wraps around from 999 J to 1 J
+	writeZone("intel-rapl:0", map[string]string{"energy_uj": "1000000"})
+	if err := zone.update(now.Add(raplSampleInterval / 2)); err != nil {
+		t.Fatalf("failed to sample RAPL zone: %v", err)
+	}
+	if zone.watts >= 0 {
+		t.Errorf("expected no new sample within sampling interval, got %f W", zone.watts)
+	}
+	if err := zone.update(now.Add(2 * time.Second)); err != nil {
+		t.Fatalf("failed to sample RAPL zone: %v", err)
+	}
+	if zone.watts != 1 {
+		t.Errorf("expected power estimate of 1 W, got %f W", zone.watts)
+	}
+}
+
+func TestActiveCPUBudget(t *testing.T) {
+	p := &policy{}
+	p.allocations = p.newAllocations()
+	root := p.NewVirtualNode("root", nilnode)
+	socket0 := p.NewVirtualNode("socket #0", root)
+	socket1 := p.NewVirtualNode("socket #1", root)
+	root.(*virtualnode).id = 0
+	socket0.(*virtualnode).id = 1
+	socket1.(*virtualnode).id = 2
+
+	addGrant := func(id string, node Node, exclusive cpuset.CPUSet, portion int) {
+		c := &mockContainer{name: id, returnValueForGetCacheID: id}
+		p.allocations.grants[id] = newGrant(node, c, cpuNormal, exclusive, portion, 0, nil, 0)
+	}
+	addGrant("exclusive", socket0, cpuset.New(0, 1), 0)
+	addGrant("shared1", socket0, cpuset.New(), 500)
+	addGrant("shared2", socket0, cpuset.New(), 700)
+	addGrant("other", socket1, cpuset.New(8), 200)
+
+	for node, expected := range map[Node]int{root: 5, socket0: 4, socket1: 2} {
+		if active := p.activeCPUs(node); active != expected {
+			t.Errorf("expected %d active CPUs in %s, got %d", expected, node.Name(), active)
+		}
+	}
+
+	defer func(saved map[string]int) { opt.MaxActiveCPUs = saved }(opt.MaxActiveCPUs)
+	opt.MaxActiveCPUs = map[string]int{"socket #0": 6, "root": 8}
+
+	for _, tc := range []struct {
+		name string
+		node Node
+		req  *request
+		over bool
+	}{
+		{name: "fits socket #0", node: socket0, req: &request{full: 2}},
+		{name: "exceeds socket #0", node: socket0, req: &request{full: 2, fraction: 100}, over: true},
+		{name: "fits socket #1", node: socket1, req: &request{full: 3}},
+		{name: "exceeds root", node: socket1, req: &request{full: 4}, over: true},
+		{name: "exceeds root", node: root, req: &request{full: 4}, over: true},
+	} {
+		if reason, over := p.overBudget(tc.req, tc.node); over != tc.over {
+			t.Errorf("%s: expected over budget %v, got %v (%s)", tc.name, tc.over, over, reason)
+		}
+	}
+}
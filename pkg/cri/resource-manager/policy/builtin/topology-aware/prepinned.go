@@ -0,0 +1,171 @@
+// Copyright 2026 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package topologyaware
+
+import (
+	"sort"
+
+	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/cache"
+	"github.com/intel/cri-resource-manager/pkg/utils/cpuset"
+)
+
+const (
+	// prePinnedTag tags containers pinned by someone else with their cpuset.
+	prePinnedTag = "topology-aware.pre-pinned"
+)
+
+// isPrePinned checks if the container has been pinned to CPUs by someone
+// else, for instance by the kubelet static CPU manager, and should be left
+// alone. Containers created with a cpuset already set are tagged, so that
+// they are recognized as such later, even after their reallocation.
+func (p *policy) isPrePinned(container cache.Container) bool {
+	if prePinnedContainers() != respectPrePinned {
+		return false
+	}
+	if _, ok := container.GetTag(prePinnedTag); ok {
+		return true
+	}
+	if container.GetState() != cache.ContainerStateCreating {
+		return false
+	}
+	cpus := container.GetCpusetCpus()
+	if cpus == "" {
+		return false
+	}
+	container.SetTag(prePinnedTag, cpus)
+	return true
+}
+
+// prePinnedCPUs returns the CPUs a pre-pinned container is pinned to.
+func prePinnedCPUs(container cache.Container) (cpuset.CPUSet, error) {
+	cpus, _ := container.GetTag(prePinnedTag)
+	cset, err := cpuset.Parse(cpus)
+	if err != nil {
+		return cpuset.New(), policyError("%s: invalid pre-pinned cpuset %q: %v",
+			container.PrettyName(), cpus, err)
+	}
+	return cset, nil
+}
+
+// holdPrePinnedCPUs holds the free CPUs a pre-pinned container is pinned to
+// out of allocation, so that they are not used for any grants. CPUs already
+// held for other pre-pinned containers, for instance the shared cpuset of the
+// kubelet static CPU manager, stay held by them.
+func (p *policy) holdPrePinnedCPUs(container cache.Container) error {
+	cset, err := prePinnedCPUs(container)
+	if err != nil {
+		return err
+	}
+
+	supply := p.root.FreeSupply()
+	held := cset.Intersection(supply.SharableCPUs().Union(supply.IsolatedCPUs()))
+	grant := newGrant(p.root, container, cpuNormal, held, 0, 0, nil, 0)
+	if err := supply.Reserve(grant); err != nil {
+		return policyError("%s: failed to hold pre-pinned CPUs %s: %v",
+			container.PrettyName(), held, err)
+	}
+	p.prePinned[container.GetCacheID()] = grant
+
+	log.Info("%s: pre-pinned to CPUs %s, leaving it alone (holding CPUs %s)",
+		container.PrettyName(), cset, held)
+
+	p.updateSharedAllocations(nil)
+
+	return nil
+}
+
+// releasePrePinnedCPUs releases the CPUs held for a pre-pinned container.
+// It returns false if the container is not a pre-pinned one.
+func (p *policy) releasePrePinnedCPUs(container cache.Container) bool {
+	id := container.GetCacheID()
+	grant, ok := p.prePinned[id]
+	if !ok {
+		return false
+	}
+	delete(p.prePinned, id)
+
+	log.Debug("* releasing CPUs %s held for pre-pinned %s",
+		grant.ExclusiveCPUs(), container.PrettyName())
+	p.releaseCPUs(grant)
+	p.handOverPrePinnedCPUs(grant.ExclusiveCPUs())
+	p.updateSharedAllocations(nil)
+
+	return true
+}
+
+// handOverPrePinnedCPUs passes released CPUs on to the other pre-pinned
+// containers still pinned to them, so that a CPU stays held as long as any
+// pre-pinned container runs on it.
+func (p *policy) handOverPrePinnedCPUs(released cpuset.CPUSet) {
+	ids := make([]string, 0, len(p.prePinned))
+	for id := range p.prePinned {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		if released.IsEmpty() {
+			return
+		}
+		grant := p.prePinned[id]
+		container := grant.GetContainer()
+		cset, err := prePinnedCPUs(container)
+		if err != nil {
+			continue
+		}
+		taken := released.Intersection(cset)
+		if taken.IsEmpty() {
+			continue
+		}
+
+		supply := p.root.FreeSupply()
+		p.releaseCPUs(grant)
+		held := grant.ExclusiveCPUs().Union(taken)
+		extended := newGrant(p.root, container, cpuNormal, held, 0, 0, nil, 0)
+		if err := supply.Reserve(extended); err != nil {
+			log.Warn("%s: failed to hold pre-pinned CPUs %s: %v",
+				container.PrettyName(), held, err)
+			if err := supply.Reserve(grant); err != nil {
+				log.Error("%s: failed to hold pre-pinned CPUs %s again: %v",
+					container.PrettyName(), grant.ExclusiveCPUs(), err)
+				delete(p.prePinned, id)
+			}
+			continue
+		}
+		p.prePinned[id] = extended
+		released = released.Difference(taken)
+
+		log.Debug("* pre-pinned %s now holds CPUs %s", container.PrettyName(), held)
+	}
+}
+
+// restorePrePinned holds the CPUs of all known pre-pinned containers again,
+// for instance after a restart or after the pools have been rebuilt.
+func (p *policy) restorePrePinned() {
+	if prePinnedContainers() != respectPrePinned {
+		return
+	}
+	for _, c := range p.cache.GetContainers() {
+		if _, ok := p.prePinned[c.GetCacheID()]; ok {
+			continue
+		}
+		if _, ok := c.GetTag(prePinnedTag); !ok {
+			continue
+		}
+		if err := p.holdPrePinnedCPUs(c); err != nil {
+			log.Warn("%v", err)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+// Copyright 2026 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package topologyaware
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	resapi "k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/cache"
+	policyapi "github.com/intel/cri-resource-manager/pkg/cri/resource-manager/policy"
+	system "github.com/intel/cri-resource-manager/pkg/sysfs"
+	"github.com/intel/cri-resource-manager/pkg/utils"
+	"github.com/intel/cri-resource-manager/pkg/utils/cpuset"
+)
+
+func TestPrePinnedContainers(t *testing.T) {
+	dir, err := os.MkdirTemp("", "cri-resource-manager-test-sysfs-")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+
+	err = utils.UncompressTbz2(path.Join("testdata", "sysfs.tar.bz2"), dir)
+	if err != nil {
+		panic(err)
+	}
+
+	sys, err := system.DiscoverSystemAt(path.Join(dir, "sysfs", "desktop", "sys"))
+	if err != nil {
+		panic(err)
+	}
+
+	reserved, _ := resapi.ParseQuantity("750m")
+	policyOptions := &policyapi.BackendOptions{
+		Cache:  &mockCache{},
+		System: sys,
+		Reserved: policyapi.ConstraintSet{
+			policyapi.DomainCPU: reserved,
+		},
+	}
+	p := CreateTopologyAwarePolicy(policyOptions).(*policy)
+
+	sharable := p.root.FreeSupply().SharableCPUs().List()
+	pinned := cpuset.New(sharable[len(sharable)-2:]...)
+
+	tcases := []struct {
+		name      string
+		mode      prePinnedMode
+		container *mockContainer
+		expected  bool
+	}{
+		{
+			name: "overwrite pre-pinned",
+			mode: overwritePrePinned,
+			container: &mockContainer{
+				name:   "pre-pinned",
+				state:  cache.ContainerStateCreating,
+				cpuset: pinned,
+			},
+		},
+		{
+			name: "respect unpinned",
+			mode: respectPrePinned,
+			container: &mockContainer{
+				name:   "unpinned",
+				state:  cache.ContainerStateCreating,
+				cpuset: cpuset.New(),
+			},
+		},
+		{
+			name: "respect pinned running container",
+			mode: respectPrePinned,
+			container: &mockContainer{
+				name:   "running",
+				state:  cache.ContainerStateRunning,
+				cpuset: pinned,
+			},
+		},
+		{
+			name: "respect pre-pinned",
+			mode: respectPrePinned,
+			container: &mockContainer{
+				name:   "pre-pinned",
+				state:  cache.ContainerStateCreating,
+				cpuset: pinned,
+			},
+			expected: true,
+		},
+	}
+	defer func() { opt.PrePinnedContainers = "" }()
+	for _, tc := range tcases {
+		t.Run(tc.name, func(t *testing.T) {
+			opt.PrePinnedContainers = tc.mode
+			c := tc.container
+			if p.isPrePinned(c) != tc.expected {
+				t.Fatalf("expected pre-pinned %v for %s", tc.expected, c.PrettyName())
+			}
+			if !tc.expected {
+				return
+			}
+
+			// the tag keeps the container pre-pinned once its cpuset changes
+			c.cpuset = cpuset.New()
+			c.state = cache.ContainerStateRunning
+			if !p.isPrePinned(c) {
+				t.Fatalf("%s not recognized as pre-pinned by its tag", c.PrettyName())
+			}
+
+			if err := p.holdPrePinnedCPUs(c); err != nil {
+				t.Fatalf("failed to hold pre-pinned CPUs: %v", err)
+			}
+			for _, pool := range p.pools {
+				if free := pool.FreeSupply().SharableCPUs(); !free.Intersection(pinned).IsEmpty() {
+					t.Errorf("pool %s: pre-pinned CPUs %s still free (%s)", pool.Name(), pinned, free)
+				}
+			}
+
+			if !p.releasePrePinnedCPUs(c) {
+				t.Fatalf("failed to release pre-pinned CPUs of %s", c.PrettyName())
+			}
+			if free := p.root.FreeSupply().SharableCPUs(); !free.Intersection(pinned).Equals(pinned) {
+				t.Errorf("pre-pinned CPUs %s not released (free %s)", pinned, free)
+			}
+			if p.releasePrePinnedCPUs(c) {
+				t.Errorf("pre-pinned CPUs of %s released twice", c.PrettyName())
+			}
+		})
+	}
+
+	t.Run("shared pre-pinned cpuset", func(t *testing.T) {
+		opt.PrePinnedContainers = respectPrePinned
+		containers := []*mockContainer{}
+		for _, name := range []string{"first", "second", "third"} {
+			c := &mockContainer{
+				name:                     name,
+				returnValueForGetCacheID: name,
+				state:                    cache.ContainerStateCreating,
+				cpuset:                   pinned,
+			}
+			if !p.isPrePinned(c) {
+				t.Fatalf("expected %s to be pre-pinned", c.PrettyName())
+			}
+			if err := p.holdPrePinnedCPUs(c); err != nil {
+				t.Fatalf("failed to hold pre-pinned CPUs: %v", err)
+			}
+			containers = append(containers, c)
+		}
+		for i, c := range containers {
+			if !p.releasePrePinnedCPUs(c) {
+				t.Fatalf("failed to release pre-pinned CPUs of %s", c.PrettyName())
+			}
+			free := p.root.FreeSupply().SharableCPUs().Intersection(pinned)
+			if i < len(containers)-1 && !free.IsEmpty() {
+				t.Errorf("CPUs %s released while still used by pre-pinned containers", free)
+			}
+			if i == len(containers)-1 && !free.Equals(pinned) {
+				t.Errorf("pre-pinned CPUs %s not released (free %s)", pinned, free)
+			}
+		}
+	})
+}
@@ -58,6 +58,8 @@ type Supply interface {
 	GetScore(Request) Score
 	// AllocatableSharedCPU calculates the allocatable amount of shared CPU of this supply.
 	AllocatableSharedCPU(...bool) int
+	// AllocatableReservedCPU calculates the allocatable amount of reserved CPU of this supply.
+	AllocatableReservedCPU() int
 	// Allocate allocates CPU capacity from this supply and returns it as a grant.
 	Allocate(Request) (Grant, error)
 	// ReleaseCPU releases a previously allocated CPU grant from this supply.
@@ -74,6 +76,8 @@ type Supply interface {
 	ReleaseExtraMemoryReservation(Grant)
 	// MemoryLimit returns the amount of various memory types belonging to this grant.
 	MemoryLimit() memoryMap
+	// HBMBandwidth returns the HBM bandwidth capacity and the amount of it in use.
+	HBMBandwidth() (uint64, uint64)
 
 	// Reserve accounts for CPU grants after reloading cached allocations.
 	Reserve(Grant) error
@@ -109,6 +113,8 @@ type Request interface {
 	MemAmountToAllocate() uint64
 	// ColdStart returns the cold start timeout.
 	ColdStart() time.Duration
+	// MemorySpillover returns the order of memory types to allocate from.
+	MemorySpillover() []memoryType
 }
 
 // Grant represents CPU and memory capacity allocated to a container from a node.
@@ -157,6 +163,8 @@ type Grant interface {
 	// MemLimit returns the amount of memory that the container is
 	// allowed to use.
 	MemLimit() memoryMap
+	// HBMBandwidth returns the estimated HBM bandwidth used by the grant.
+	HBMBandwidth() uint64
 	// String returns a printable representation of this grant.
 	String() string
 	// Release releases the grant from all the Supplys it uses.
@@ -195,6 +203,7 @@ type Score interface {
 	SharedCapacity() int
 	Colocated() int
 	HintScores() map[string]float64
+	HBMBandwidth() (int64, bool)
 
 	String() string
 }
@@ -224,9 +233,10 @@ type request struct {
 	isolate   bool            // prefer isolated exclusive CPUs
 	cpuType   cpuClass        // preferred CPU type (normal, reserved)
 
-	memReq  uint64     // memory request
-	memLim  uint64     // memory limit
-	memType memoryType // requested types of memory
+	memReq   uint64       // memory request
+	memLim   uint64       // memory limit
+	memType  memoryType   // requested types of memory
+	memOrder []memoryType // order of memory types to allocate from
 
 	// coldStart tells the timeout (in milliseconds) how long to wait until
 	// a DRAM memory controller should be added to a container asking for a
@@ -264,6 +274,8 @@ type score struct {
 	shared    int                // remaining shared capacity
 	colocated int                // number of colocated containers
 	hints     map[string]float64 // hint scores
+	bandwidth int64              // remaining HBM bandwidth
+	hasHBM    bool               // whether HBM bandwidth is accounted for
 }
 
 var _ Score = &score{}
@@ -402,6 +414,38 @@ func (cs *supply) MemoryLimit() memoryMap {
 	return cs.mem
 }
 
+// HBMBandwidth returns the HBM bandwidth capacity of the supply and the
+// estimated amount of it used by grants with memory from this node or
+// any node below it.
+func (cs *supply) HBMBandwidth() (uint64, uint64) {
+	perNode := opt.HBMBandwidth.Value()
+	if perNode <= 0 {
+		return 0, 0
+	}
+
+	capacity := uint64(cs.node.GetMemset(memoryHBM).Size()) * uint64(perNode)
+	used := uint64(0)
+	for _, g := range cs.node.Policy().allocations.grants {
+		for n := g.GetMemoryNode(); !n.IsNil(); n = n.Parent() {
+			if n.IsSameNode(cs.node) {
+				used += g.HBMBandwidth()
+				break
+			}
+		}
+	}
+
+	return capacity, used
+}
+
+// hbmBandwidthEstimate estimates the HBM bandwidth needed by an amount of milli-CPU.
+func hbmBandwidthEstimate(milliCPU int) uint64 {
+	perCPU := opt.HBMBandwidthPerCPU.Value()
+	if perCPU <= 0 || milliCPU <= 0 {
+		return 0
+	}
+	return uint64(milliCPU) * uint64(perCPU) / 1000
+}
+
 // Cumulate more CPU to supply.
 func (cs *supply) Cumulate(more Supply) {
 	mcs := more.(*supply)
@@ -466,7 +510,8 @@ func (cs *supply) allocateMemory(r Request) (memoryMap, error) {
 
 	//
 	// Notes:
-	//   We try to allocate PMEM, then DRAM, and finally HBM, honoring
+	//   We try to allocate memory types in the spillover order of the
+	//   request, by default PMEM, then DRAM, and finally HBM, honoring
 	//   the types allowed by the request. We don't need to care about
 	//   extra memory reservations for this node as all the nodes with
 	//   insufficient memory have been filtered out before allocation.
@@ -476,7 +521,7 @@ func (cs *supply) allocateMemory(r Request) (memoryMap, error) {
 	//   if that check fails.
 	//
 
-	for _, memType := range []memoryType{memoryPMEM, memoryDRAM, memoryHBM} {
+	for _, memType := range r.MemorySpillover() {
 		if remaining > 0 && (reqType&memType) != 0 {
 			available := cs.mem[memType]
 
@@ -529,6 +574,10 @@ func (cs *supply) allocateMemory(r Request) (memoryMap, error) {
 
 // Allocate allocates a grant from the supply.
 func (cs *supply) Allocate(r Request) (Grant, error) {
+	if _, _, err := memsetWithFallback(cs.node, initialMemoryType(r.MemoryType(), r.ColdStart())); err != nil {
+		return nil, err
+	}
+
 	grant, err := cs.AllocateCPU(r)
 	if err != nil {
 		return nil, err
@@ -572,24 +621,34 @@ func (cs *supply) AllocateCPU(r Request) (Grant, error) {
 		cpuType = cpuNormal
 	}
 
+	avoid := cpuset.New()
+	if full > 0 {
+		avoid = cs.node.Policy().smtAntiAffineCPUs(cr.GetContainer())
+	}
+
 	// allocate isolated exclusive CPUs or slice them off the sharable set
 	switch {
-	case full > 0 && cs.isolated.Size() >= full && cr.isolate:
-		exclusive, err = cs.takeCPUs(&cs.isolated, nil, full)
+	case full > 0 && cs.isolated.Difference(avoid).Size() >= full && cr.isolate && cr.isolatedAllowed():
+		exclusive, err = cs.takeCPUsAvoiding(&cs.isolated, full, cs.node.GetMemset(cr.memType), avoid)
 		if err != nil {
 			return nil, policyError("internal error: "+
 				"%s: can't take %d exclusive isolated CPUs from %s: %v",
 				cs.node.Name(), full, cs.isolated, err)
 		}
 
-	case full > 0 && cs.AllocatableSharedCPU() > 1000*full:
-		exclusive, err = cs.takeCPUs(&cs.sharable, nil, full)
+	case full > 0 && cs.AllocatableSharedCPU() > 1000*full && cs.sharable.Difference(avoid).Size() >= full:
+		exclusive, err = cs.takeCPUsAvoiding(&cs.sharable, full, cs.node.GetMemset(cr.memType), avoid)
 		if err != nil {
 			return nil, policyError("internal error: "+
 				"%s: can't take %d exclusive CPUs from %s: %v",
 				cs.node.Name(), full, cs.sharable, err)
 		}
 
+	case full > 0 && !avoid.IsEmpty():
+		return nil, policyError("%s: can't take %d exclusive CPUs for %s from cores "+
+			"disjoint with its SMT anti-affine CPUs %s", cs.node.Name(), full,
+			cr.GetContainer().PrettyName(), avoid)
+
 	case full > 0:
 		return nil, policyError("internal error: "+
 			"%s: can't slice %d exclusive CPUs from %s, %dm available",
@@ -764,10 +823,33 @@ func (cs *supply) ReserveMemory(g Grant) error {
 }
 
 // takeCPUs takes up to cnt CPUs from a given CPU set to another.
-func (cs *supply) takeCPUs(from, to *cpuset.CPUSet, cnt int) (cpuset.CPUSet, error) {
-	cset, err := cs.node.Policy().cpuAllocator.AllocateCpus(from, cnt, cpuallocator.PriorityHigh)
-	if err != nil {
-		return cset, err
+// CPUs handling a lot of interrupts are avoided if enough other CPUs are available.
+func (cs *supply) takeCPUs(from, to *cpuset.CPUSet, cnt int, mems idset.IDSet) (cpuset.CPUSet, error) {
+	var (
+		cset cpuset.CPUSet
+		err  error
+	)
+
+	p := cs.node.Policy()
+	preferred := from.Difference(p.irqCPUs)
+	if preferred.Size() < cnt {
+		preferred = from.Clone()
+	}
+	if llc := p.llcPreferredCPUs(preferred, cnt, mems); !llc.IsEmpty() {
+		preferred = llc
+	}
+
+	if preferred.Size() < from.Size() {
+		cset, err = p.cpuAllocator.AllocateCpus(&preferred, cnt, cpuallocator.PriorityHigh)
+		if err != nil {
+			return cset, err
+		}
+		*from = from.Difference(cset)
+	} else {
+		cset, err = p.cpuAllocator.AllocateCpus(from, cnt, cpuallocator.PriorityHigh)
+		if err != nil {
+			return cset, err
+		}
 	}
 
 	if to != nil {
@@ -777,6 +859,22 @@ func (cs *supply) takeCPUs(from, to *cpuset.CPUSet, cnt int) (cpuset.CPUSet, err
 	return cset, err
 }
 
+// takeCPUsAvoiding takes cnt CPUs from a given CPU set, never taking any of the avoided CPUs.
+func (cs *supply) takeCPUsAvoiding(from *cpuset.CPUSet, cnt int, mems idset.IDSet, avoid cpuset.CPUSet) (cpuset.CPUSet, error) {
+	if avoid.IsEmpty() {
+		return cs.takeCPUs(from, nil, cnt, mems)
+	}
+
+	allowed := from.Difference(avoid)
+	cset, err := cs.takeCPUs(&allowed, nil, cnt, mems)
+	if err != nil {
+		return cset, err
+	}
+	*from = from.Difference(cset)
+
+	return cset, nil
+}
+
 // DumpCapacity returns a printable representation of the supply's resource capacity.
 func (cs *supply) DumpCapacity() string {
 	cpu, mem, sep := "", cs.mem.String(), ""
@@ -974,6 +1072,12 @@ func newRequest(container cache.Container) Request {
 		}
 	}
 
+	// Cold start needs PMEM to be allocated first.
+	memOrder := defaultMemorySpillover
+	if coldStart == 0 {
+		memOrder = memorySpilloverPreference(container)
+	}
+
 	return &request{
 		container: container,
 		full:      full,
@@ -984,6 +1088,7 @@ func newRequest(container cache.Container) Request {
 		memLim:    lim,
 		memType:   mtype,
 		coldStart: coldStart,
+		memOrder:  memOrder,
 	}
 }
 
@@ -1039,6 +1144,12 @@ func (cr *request) Isolate() bool {
 	return cr.isolate
 }
 
+// isolatedAllowed returns whether isolated CPUs can be given to this request.
+func (cr *request) isolatedAllowed() bool {
+	allowed, _ := checkIsolatedPoolNamespaces(cr.container.GetNamespace())
+	return allowed
+}
+
 // MemAmountToAllocate retuns how much memory we need to reserve for a request.
 func (cr *request) MemAmountToAllocate() uint64 {
 	var amount uint64 = 0
@@ -1073,6 +1184,14 @@ func (cr *request) ColdStart() time.Duration {
 	return cr.coldStart
 }
 
+// MemorySpillover returns the order of memory types to allocate from.
+func (cr *request) MemorySpillover() []memoryType {
+	if len(cr.memOrder) == 0 {
+		return defaultMemorySpillover
+	}
+	return cr.memOrder
+}
+
 // Score collects data for scoring this supply wrt. the given request.
 func (cs *supply) GetScore(req Request) Score {
 	score := &score{
@@ -1107,6 +1226,15 @@ func (cs *supply) GetScore(req Request) Score {
 		score.shared -= part
 	}
 
+	// calculate remaining HBM bandwidth, if HBM is requested explicitly
+	if reqType := cr.MemoryType(); reqType != memoryUnspec && reqType&memoryHBM != 0 {
+		if capacity, used := cs.HBMBandwidth(); capacity > 0 {
+			score.hasHBM = true
+			score.bandwidth = int64(capacity) - int64(used) -
+				int64(hbmBandwidthEstimate(1000*full+cr.fraction))
+		}
+	}
+
 	// calculate colocation score
 	for _, grant := range cs.node.Policy().allocations.grants {
 		if cr.CPUType() == grant.CPUType() && grant.GetCPUNode().NodeID() == cs.node.NodeID() {
@@ -1208,7 +1336,16 @@ func (score *score) HintScores() map[string]float64 {
 	return score.hints
 }
 
+// HBMBandwidth returns the remaining HBM bandwidth, if it is accounted for.
+func (score *score) HBMBandwidth() (int64, bool) {
+	return score.bandwidth, score.hasHBM
+}
+
 func (score *score) String() string {
+	if score.hasHBM {
+		return fmt.Sprintf("<CPU score: node %s, isolated:%d, reserved:%d, shared:%d, colocated:%d, HBM bandwidth:%d, hints: %v>",
+			score.supply.GetNode().Name(), score.isolated, score.reserved, score.shared, score.colocated, score.bandwidth, score.hints)
+	}
 	return fmt.Sprintf("<CPU score: node %s, isolated:%d, reserved:%d, shared:%d, colocated:%d, hints: %v>",
 		score.supply.GetNode().Name(), score.isolated, score.reserved, score.shared, score.colocated, score.hints)
 }
@@ -1236,16 +1373,15 @@ func (cg *grant) SetCPUPortion(fraction int) {
 
 // SetMemoryAllocation sets the memory allocation for the grant.
 func (cg *grant) SetMemoryAllocation(mt memoryType, allocated memoryMap, coldstart time.Duration) {
-	initial := memoryPMEM
-	if coldstart <= 0 {
-		initial = mt
-	}
-	mems := cg.node.GetMemset(initial)
-	if mems.Size() == 0 {
-		mems = cg.node.GetMemset(memoryDRAM)
-		if mems.Size() == 0 {
-			mems = cg.node.GetMemset(memoryAll)
-		}
+	initial := initialMemoryType(mt, coldstart)
+	mems, fallback, err := memsetWithFallback(cg.node, initial)
+	switch {
+	case err != nil:
+		log.Warn("%s: %v, using all memory", cg.container.PrettyName(), err)
+		mems = cg.node.GetMemset(memoryAll)
+	case fallback:
+		log.Info("%s: no %s memory in %s, falling back (%s) to memory nodes %s",
+			cg.container.PrettyName(), initial, cg.node.Name(), memoryTypeFallback(initial), mems)
 	}
 	mems = mems.Clone()
 
@@ -1255,6 +1391,49 @@ func (cg *grant) SetMemoryAllocation(mt memoryType, allocated memoryMap, coldsta
 	cg.coldStart = coldstart
 }
 
+// initialMemoryType returns the type of memory to initially allocate.
+func initialMemoryType(mt memoryType, coldstart time.Duration) memoryType {
+	if coldstart > 0 {
+		return memoryPMEM
+	}
+	return mt
+}
+
+// memsetWithFallback returns the memory nodes of the given type in a pool.
+// If the pool has no memory of the type, the configured fallback decides
+// whether another type of memory is used instead. The second return value
+// tells if a fallback was used.
+func memsetWithFallback(n Node, mt memoryType) (idset.IDSet, bool, error) {
+	if mt == memoryUnspec {
+		if mems := n.GetMemset(memoryDRAM); mems.Size() > 0 {
+			return mems, false, nil
+		}
+		return n.GetMemset(memoryAll), false, nil
+	}
+
+	if mems := n.GetMemset(mt); mems.Size() > 0 {
+		return mems, false, nil
+	}
+
+	switch memoryTypeFallback(mt) {
+	case failMemoryFallback:
+		return idset.NewIDSet(), false, policyError("%s: no %s memory and fallback disabled", n.Name(), mt)
+	case anyMemoryFallback:
+		return n.GetMemset(memoryAll), true, nil
+	}
+
+	for _, other := range []memoryType{memoryDRAM, memoryHBM, memoryPMEM} {
+		if mt&other != 0 {
+			continue
+		}
+		if mems := n.GetMemset(other); mems.Size() > 0 {
+			return mems, true, nil
+		}
+	}
+
+	return idset.NewIDSet(), true, nil
+}
+
 // Clone creates a copy of this grant.
 func (cg *grant) Clone() Grant {
 	return &grant{
@@ -1367,6 +1546,14 @@ func (cg *grant) MemLimit() memoryMap {
 	return cg.allocatedMem
 }
 
+// HBMBandwidth returns the estimated HBM bandwidth used by the grant.
+func (cg *grant) HBMBandwidth() uint64 {
+	if cg.allocatedMem[memoryHBM] == 0 {
+		return 0
+	}
+	return hbmBandwidthEstimate(1000*cg.exclusive.Size() + cg.cpuPortion)
+}
+
 // String returns a printable representation of the CPU grant.
 func (cg *grant) String() string {
 	var cpuType, isolated, exclusive, reserved, shared string
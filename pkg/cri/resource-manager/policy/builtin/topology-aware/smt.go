@@ -0,0 +1,55 @@
+// Copyright 2026 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package topologyaware
+
+import (
+	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/cache"
+	"github.com/intel/cri-resource-manager/pkg/utils/cpuset"
+)
+
+// smtAntiAffineCPUs returns the CPUs the exclusive CPUs of the container must
+// not be taken from: the exclusive CPUs of the other containers in the same SMT
+// anti-affinity group, together with their SMT siblings.
+func (p *policy) smtAntiAffineCPUs(container cache.Container) cpuset.CPUSet {
+	pod, ok := container.GetPod()
+	if !ok {
+		return cpuset.New()
+	}
+	group := smtAntiAffinityGroup(pod, container)
+	if group == "" {
+		return cpuset.New()
+	}
+
+	cpus := cpuset.New()
+	for id, grant := range p.allocations.grants {
+		if id == container.GetCacheID() || grant.ExclusiveCPUs().IsEmpty() {
+			continue
+		}
+		other := grant.GetContainer()
+		if otherPod, ok := other.GetPod(); !ok || smtAntiAffinityGroup(otherPod, other) != group {
+			continue
+		}
+		cpus = cpus.Union(grant.ExclusiveCPUs())
+	}
+	if cpus.IsEmpty() {
+		return cpus
+	}
+
+	cpus = p.withSMTSiblings(cpus)
+	log.Debug("%s: SMT anti-affinity group %q, avoiding CPUs %s",
+		container.PrettyName(), group, cpus)
+
+	return cpus
+}
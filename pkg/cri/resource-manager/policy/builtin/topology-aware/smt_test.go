@@ -0,0 +1,133 @@
+// Copyright 2026 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package topologyaware
+
+import (
+	"testing"
+
+	"github.com/intel/cri-resource-manager/pkg/utils/cpuset"
+	idset "github.com/intel/goresctrl/pkg/utils"
+)
+
+func TestSMTAntiAffineCPUs(t *testing.T) {
+	threads := map[idset.ID]cpuset.CPUSet{}
+	for cpu := 0; cpu < 4; cpu++ {
+		// CPUs n and n+4 are SMT siblings
+		threads[idset.ID(cpu)] = cpuset.New(cpu, cpu+4)
+		threads[idset.ID(cpu+4)] = cpuset.New(cpu, cpu+4)
+	}
+
+	inGroup := func(name, group string) *mockContainer {
+		return &mockContainer{
+			name:                     name,
+			returnValueForGetCacheID: name,
+			pod: &mockPod{
+				annotations: map[string]string{
+					smtAntiAffinityKey + "/container." + name: group,
+				},
+			},
+		}
+	}
+
+	secure1 := inGroup("secure1", "secure")
+	secure2 := inGroup("secure2", "secure")
+	secure3 := inGroup("secure3", "secure")
+	other := inGroup("other", "other")
+	plain := &mockContainer{name: "plain", returnValueForGetCacheID: "plain", pod: &mockPod{}}
+
+	p := &policy{
+		sys:     &mockSystem{threads: threads},
+		allowed: cpuset.New(0, 1, 2, 3, 4, 5, 6, 7),
+	}
+	p.allocations = p.newAllocations()
+	p.allocations.grants["secure1"] = newGrant(&node{}, secure1, cpuNormal, cpuset.New(1), 0, 0, nil, 0)
+	p.allocations.grants["secure2"] = newGrant(&node{}, secure2, cpuNormal, cpuset.New(2, 3), 0, 0, nil, 0)
+	p.allocations.grants["other"] = newGrant(&node{}, other, cpuNormal, cpuset.New(0), 0, 0, nil, 0)
+
+	for _, tc := range []struct {
+		container *mockContainer
+		expected  string
+	}{
+		{container: secure1, expected: "2-3,6-7"},
+		{container: secure2, expected: "1,5"},
+		{container: secure3, expected: "1-3,5-7"},
+		{container: other, expected: ""},
+		{container: plain, expected: ""},
+	} {
+		if cpus := p.smtAntiAffineCPUs(tc.container); cpus.String() != tc.expected {
+			t.Errorf("%s: expected anti-affine CPUs %q, got %q", tc.container.name, tc.expected, cpus)
+		}
+	}
+}
+
+func TestFilterSMTAntiAffinity(t *testing.T) {
+	threads := map[idset.ID]cpuset.CPUSet{}
+	for cpu := 0; cpu < 4; cpu++ {
+		threads[idset.ID(cpu)] = cpuset.New(cpu, cpu+4)
+		threads[idset.ID(cpu+4)] = cpuset.New(cpu, cpu+4)
+	}
+	inGroup := func(name string) *mockContainer {
+		return &mockContainer{
+			name:                     name,
+			returnValueForGetCacheID: name,
+			pod: &mockPod{
+				annotations: map[string]string{
+					smtAntiAffinityKey + "/container." + name: "secure",
+				},
+			},
+		}
+	}
+	p := &policy{
+		sys:     &mockSystem{threads: threads},
+		allowed: cpuset.New(0, 1, 2, 3, 4, 5, 6, 7),
+	}
+	p.allocations = p.newAllocations()
+	p.allocations.grants["secure1"] = newGrant(&node{}, inGroup("secure1"), cpuNormal, cpuset.New(1), 0, 0, nil, 0)
+
+	newPool := func(name string, isolated, sharable cpuset.CPUSet) Node {
+		n := &node{name: name, kind: UnknownNode, parent: nilnode}
+		n.noderes = newSupply(n, isolated, cpuset.New(), sharable, 0, 0, nil, nil)
+		n.freeres = newSupply(n, isolated, cpuset.New(), sharable, 0, 0, nil, nil)
+		return n
+	}
+	// CPUs 1 and 5 are avoided. Split pools have enough CPUs outside
+	// them only when counting isolated and sharable CPUs together.
+	split := newPool("split", cpuset.New(1, 2), cpuset.New(5, 6))
+	sharable := newPool("sharable", cpuset.New(), cpuset.New(2, 3, 6, 7))
+	isolated := newPool("isolated", cpuset.New(2, 3), cpuset.New())
+	pools := []Node{split, sharable, isolated}
+
+	for _, tc := range []struct {
+		name     string
+		isolate  bool
+		expected []Node
+	}{
+		{name: "sharable CPUs", expected: []Node{sharable}},
+		{name: "isolated CPUs", isolate: true, expected: []Node{sharable, isolated}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req := &request{container: inGroup("secure2"), full: 2, isolate: tc.isolate}
+			filtered := p.filterSMTAntiAffinity(req, pools)
+			if len(filtered) != len(tc.expected) {
+				t.Fatalf("expected %d pools, got %d", len(tc.expected), len(filtered))
+			}
+			for i, n := range filtered {
+				if n != tc.expected[i] {
+					t.Errorf("expected pool %s at %d, got %s", tc.expected[i].Name(), i, n.Name())
+				}
+			}
+		})
+	}
+}
@@ -16,12 +16,11 @@ package topologyaware
 
 import (
 	"errors"
+	"time"
 
 	v1 "k8s.io/api/core/v1"
 	resapi "k8s.io/apimachinery/pkg/api/resource"
 
-	"github.com/prometheus/client_golang/prometheus"
-
 	"github.com/intel/cri-resource-manager/pkg/config"
 	"github.com/intel/cri-resource-manager/pkg/cpuallocator"
 	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/cache"
@@ -48,6 +47,8 @@ const (
 
 	// ColdStartDone is the event generated for the end of a container cold start period.
 	ColdStartDone = "cold-start-done"
+	// HeldCPUsReleased is the event generated for the end of the grace period of released exclusive CPUs.
+	HeldCPUsReleased = "held-cpus-released"
 )
 
 // allocations is our cache.Cachable for saving resource allocations in the cache.
@@ -58,22 +59,38 @@ type allocations struct {
 
 // policy is our runtime state for this policy.
 type policy struct {
-	options      *policyapi.BackendOptions // options we were created or reconfigured with
-	cache        cache.Cache               // pod/container cache
-	sys          system.System             // system/HW topology info
-	allowed      cpuset.CPUSet             // bounding set of CPUs we're allowed to use
-	reserved     cpuset.CPUSet             // system-/kube-reserved CPUs
-	reserveCnt   int                       // number of CPUs to reserve if given as resource.Quantity
-	isolated     cpuset.CPUSet             // (our allowed set of) isolated CPUs
-	nodes        map[string]Node           // pool nodes by name
-	pools        []Node                    // pre-populated node slice for scoring, etc...
-	root         Node                      // root of our pool/partition tree
-	nodeCnt      int                       // number of pools
-	depth        int                       // tree depth
-	allocations  allocations               // container pool assignments
-	cpuAllocator cpuallocator.CPUAllocator // CPU allocator used by the policy
-	coldstartOff bool                      // coldstart forced off (have movable PMEM zones)
-	isAlias      bool                      // whether started by referencing AliasName
+	options         *policyapi.BackendOptions // options we were created or reconfigured with
+	cache           cache.Cache               // pod/container cache
+	sys             system.System             // system/HW topology info
+	allowed         cpuset.CPUSet             // bounding set of CPUs we're allowed to use
+	reserved        cpuset.CPUSet             // system-/kube-reserved CPUs
+	reserveCnt      int                       // number of CPUs to reserve if given as resource.Quantity
+	isolated        cpuset.CPUSet             // (our allowed set of) isolated CPUs
+	irqCPUs         cpuset.CPUSet             // CPUs exclusive allocations should avoid
+	llcs            []cpuset.CPUSet           // CPUs sharing a last-level cache, if discovered
+	rapl            map[idset.ID]*raplZone    // RAPL power zones of CPU packages, if discovered
+	reserveSMT      bool                      // whether reserved CPUs include their SMT siblings
+	nodes           map[string]Node           // pool nodes by name
+	pools           []Node                    // pre-populated node slice for scoring, etc...
+	root            Node                      // root of our pool/partition tree
+	nodeCnt         int                       // number of pools
+	depth           int                       // tree depth
+	poolLevels      map[string]poolLevelMode  // pool levels the tree was built with
+	hugePageNodes   idset.IDSet               // NUMA nodes with hugepages dedicated to hugepage workloads
+	allocations     allocations               // container pool assignments
+	podPools        map[string]*podPool       // shared grants of pods, by pod ID
+	demoted         map[string]string         // pools of demoted containers before demotion, by cache ID
+	heldGrants      map[string]*heldGrant     // released grants with exclusive CPUs on hold
+	prePinned       map[string]Grant          // CPUs held for containers pinned by someone else
+	noPartition     map[string]string         // why pods are not isolated in cpuset partitions, by pod ID
+	pushedUpGrants  uint64                    // number of times grants have been moved up in the tree
+	snapshot        *metricsSnapshot          // metrics for the collector, updated with allocations
+	lendingReserved bool                      // whether idle reserved CPUs are lent to shared allocations
+	cpuAllocator    cpuallocator.CPUAllocator // CPU allocator used by the policy
+	batch           *updateBatch              // ongoing batch of container updates, if any
+	balancer        *balancer                 // balancer of initial placement during Sync, if any
+	coldstartOff    bool                      // coldstart forced off (have movable PMEM zones)
+	isAlias         bool                      // whether started by referencing AliasName
 }
 
 // Make sure policy implements the policy.Backend interface.
@@ -100,12 +117,20 @@ func createPolicy(opts *policyapi.BackendOptions, isAlias bool) policyapi.Backen
 		options:      opts,
 		cpuAllocator: cpuallocator.NewCPUAllocator(opts.System),
 		isAlias:      isAlias,
+		podPools:     make(map[string]*podPool),
+		demoted:      make(map[string]string),
+		noPartition:  make(map[string]string),
+		snapshot:     &metricsSnapshot{},
 	}
 
 	if isAlias {
 		*opt = *aliasOpt
 	}
 
+	if err := opt.validate(); err != nil {
+		log.Fatal("invalid %s policy configuration: %v", PolicyName, err)
+	}
+
 	if err := p.initialize(); err != nil {
 		log.Fatal("failed to initialize %s policy: %v", PolicyName, err)
 	}
@@ -139,6 +164,8 @@ func (p *policy) Start(add []cache.Container, del []cache.Container) error {
 	// or restart us if they do.
 	p.checkColdstartOff()
 
+	p.restorePrePinned()
+
 	p.root.Dump("<post-start>")
 
 	return p.Sync(add, del)
@@ -150,6 +177,10 @@ func (p *policy) Sync(add []cache.Container, del []cache.Container) error {
 	for _, c := range del {
 		p.ReleaseResources(c)
 	}
+	if len(add) > 0 && initialPlacement() == balancedPlacement {
+		p.balancer = p.newBalancer()
+		defer func() { p.balancer = nil }()
+	}
 	for _, c := range add {
 		p.AllocateResources(c)
 	}
@@ -160,12 +191,33 @@ func (p *policy) Sync(add []cache.Container, del []cache.Container) error {
 // AllocateResources is a resource allocation request for this policy.
 func (p *policy) AllocateResources(container cache.Container) error {
 	log.Debug("allocating resources for %s...", container.PrettyName())
+	defer p.updateMetrics()
+
+	if p.isPrePinned(container) {
+		if err := p.holdPrePinnedCPUs(container); err != nil {
+			log.Warn("%v", err)
+		}
+		return nil
+	}
+
+	if p.joinPodPool(container) {
+		p.root.Dump("<post-alloc>")
+		return nil
+	}
 
 	grant, err := p.allocatePool(container, "")
 	if err != nil {
 		return policyError("failed to allocate resources for %s: %v",
 			container.PrettyName(), err)
 	}
+	if err := p.bindHugePages(grant); err != nil {
+		if released, found := p.releasePool(container); found {
+			p.updateSharedAllocations(&released)
+		}
+		return policyError("failed to allocate resources for %s: %v",
+			container.PrettyName(), err)
+	}
+	p.createPodPool(container)
 	p.applyGrant(grant)
 	p.updateSharedAllocations(&grant)
 
@@ -176,11 +228,36 @@ func (p *policy) AllocateResources(container cache.Container) error {
 
 // ReleaseResources is a resource release request for this policy.
 func (p *policy) ReleaseResources(container cache.Container) error {
+	return p.releaseResources(container, true)
+}
+
+// releaseResources releases the resources of a container. If hold is true,
+// the container is going away and its exclusive CPUs are held out of
+// reallocation for the configured grace period.
+func (p *policy) releaseResources(container cache.Container, hold bool) error {
 	log.Debug("releasing resources of %s...", container.PrettyName())
+	defer p.updateMetrics()
 
-	if grant, found := p.releasePool(container); found {
-		p.updateSharedAllocations(&grant)
+	if p.releasePrePinnedCPUs(container) {
+		return nil
+	}
+
+	if !p.leavePodPool(container) {
+		var grant Grant
+		found := false
+		if hold {
+			grant, found = p.holdExclusiveCPUs(container)
+		}
+		if !found {
+			grant, found = p.releasePool(container)
+		}
+		if found {
+			p.updateSharedAllocations(&grant)
+		}
 	}
+	p.updatePodPartition(container)
+	delete(p.demoted, container.GetCacheID())
+	p.restoreDemoted()
 
 	p.root.Dump("<post-release>")
 
@@ -197,25 +274,31 @@ func (p *policy) UpdateResources(c cache.Container) error {
 func (p *policy) Rebalance() (bool, error) {
 	var errors error
 
-	containers := p.cache.GetContainers()
+	containers := cache.ManagedContainers(p.cache.GetContainers())
 	movable := []cache.Container{}
 
-	for _, c := range containers {
-		if c.GetQOSClass() != v1.PodQOSGuaranteed {
-			p.ReleaseResources(c)
-			movable = append(movable, c)
+	// Collect all pinning changes into a single batch, so that each moved
+	// container gets a single update and unmoved ones get none at all.
+	p.batchUpdates(func() {
+		for _, c := range containers {
+			if c.GetQOSClass() != v1.PodQOSGuaranteed {
+				// The container is re-allocated right away, so its
+				// exclusive CPUs are not held.
+				p.releaseResources(c, false)
+				movable = append(movable, c)
+			}
 		}
-	}
 
-	for _, c := range movable {
-		if err := p.AllocateResources(c); err != nil {
-			if errors == nil {
-				errors = err
-			} else {
-				errors = policyError("%v, %v", errors, err)
+		for _, c := range movable {
+			if err := p.AllocateResources(c); err != nil {
+				if errors == nil {
+					errors = err
+				} else {
+					errors = policyError("%v, %v", errors, err)
+				}
 			}
 		}
-	}
+	})
 
 	return true, errors
 }
@@ -223,6 +306,7 @@ func (p *policy) Rebalance() (bool, error) {
 // HandleEvent handles policy-specific events.
 func (p *policy) HandleEvent(e *events.Policy) (bool, error) {
 	log.Debug("received policy event %s.%s with data %v...", e.Source, e.Type, e.Data)
+	defer p.updateMetrics()
 
 	switch e.Type {
 	case events.ContainerStarted:
@@ -231,6 +315,8 @@ func (p *policy) HandleEvent(e *events.Policy) (bool, error) {
 			return false, policyError("%s event: expecting cache.Container Data, got %T",
 				e.Type, e.Data)
 		}
+		p.updatePodPartition(c)
+		p.updateCpusetExclusive(c)
 		log.Info("triggering coldstart period (if necessary) for %s", c.PrettyName())
 		return false, p.triggerColdStart(c)
 	case ColdStartDone:
@@ -246,6 +332,17 @@ func (p *policy) HandleEvent(e *events.Policy) (bool, error) {
 		}
 		log.Info("finishing coldstart period for %s", c.PrettyName())
 		return p.finishColdStart(c)
+	case HeldCPUsReleased:
+		id, ok := e.Data.(string)
+		if !ok {
+			return false, policyError("%s event: expecting container cache ID Data, got %T",
+				e.Type, e.Data)
+		}
+		if !p.releaseHeldCPUs(id) {
+			return false, nil
+		}
+		p.restoreDemoted()
+		return true, nil
 	}
 	return false, nil
 }
@@ -259,6 +356,7 @@ func (p *policy) Introspect(state *introspect.State) {
 			Name:   node.Name(),
 			CPUs:   cpus.SharableCPUs().Union(cpus.IsolatedCPUs()).String(),
 			Memory: node.GetMemset(memoryAll).String(),
+			Free:   describeFreeCapacity(node.FreeSupply()),
 		}
 		if parent := node.Parent(); !parent.IsNil() {
 			pool.Parent = parent.Name()
@@ -275,33 +373,63 @@ func (p *policy) Introspect(state *introspect.State) {
 
 	assignments := make(map[string]*introspect.Assignment, len(p.allocations.grants))
 	for _, g := range p.allocations.grants {
-		a := &introspect.Assignment{
-			ContainerID:   g.GetContainer().GetID(),
-			CPUShare:      g.SharedPortion(),
-			ExclusiveCPUs: g.ExclusiveCPUs().Union(g.IsolatedCPUs()).String(),
-			Pool:          g.GetCPUNode().Name(),
-		}
-		if g.SharedPortion() > 0 || a.ExclusiveCPUs == "" {
-			a.SharedCPUs = g.SharedCPUs().String()
-		}
+		a := describeGrant(g)
 		assignments[a.ContainerID] = a
 	}
 	state.Assignments = assignments
 }
 
-// DescribeMetrics generates policy-specific prometheus metrics data descriptors.
-func (p *policy) DescribeMetrics() []*prometheus.Desc {
-	return nil
-}
-
-// PollMetrics provides policy metrics for monitoring.
-func (p *policy) PollMetrics() policyapi.Metrics {
-	return nil
+// describeGrant describes a grant for introspection.
+func describeGrant(g Grant) *introspect.Assignment {
+	a := &introspect.Assignment{
+		ContainerID:   g.GetContainer().GetID(),
+		CacheID:       g.GetContainer().GetCacheID(),
+		CPUShare:      g.SharedPortion(),
+		ExclusiveCPUs: g.ExclusiveCPUs().Union(g.IsolatedCPUs()).String(),
+		ReservedCPUs:  g.ReservedCPUs().String(),
+		Memory:        g.Memset().String(),
+		MemoryLimits:  map[string]uint64{},
+		Pool:          g.GetCPUNode().Name(),
+		MemoryPool:    g.GetMemoryNode().Name(),
+	}
+	if g.SharedPortion() > 0 || a.ExclusiveCPUs == "" {
+		a.SharedCPUs = g.SharedCPUs().String()
+	}
+	if g.ReservedPortion() == 0 {
+		a.ReservedCPUs = ""
+	}
+	for _, kind := range []memoryType{memoryDRAM, memoryPMEM, memoryHBM} {
+		if limit := g.MemLimit()[kind]; limit > 0 {
+			a.MemoryLimits[kind.String()] = limit
+		}
+	}
+	return a
 }
 
-// CollectMetrics generates prometheus metrics from cached/polled policy-specific metrics data.
-func (p *policy) CollectMetrics(policyapi.Metrics) ([]prometheus.Metric, error) {
-	return nil, nil
+// describeFreeCapacity describes the free capacity of a supply for introspection.
+func describeFreeCapacity(s Supply) *introspect.FreeCapacity {
+	free := &introspect.FreeCapacity{
+		IsolatedCPUs:        s.IsolatedCPUs().String(),
+		ReservedCPUs:        s.ReservedCPUs().String(),
+		SharableCPUs:        s.SharableCPUs().String(),
+		AllocatableShared:   s.AllocatableSharedCPU(true),
+		AllocatableReserved: s.AllocatableReservedCPU(),
+		Memory:              map[string]uint64{},
+	}
+	// Exclusive CPUs are only sliced off the sharable ones if some shared
+	// capacity remains, see supply.AllocateCPU().
+	if free.AllocatableShared > 0 {
+		free.ExclusiveCPUs = (free.AllocatableShared - 1) / 1000
+	}
+	// Memory reserved for grants of child pools is not free, see
+	// policy.filterInsufficientResources().
+	limit := s.MemoryLimit()
+	for _, kind := range []memoryType{memoryDRAM, memoryPMEM, memoryHBM} {
+		if mem, extra := limit[kind], s.ExtraMemoryReservation(kind); mem > extra {
+			free.Memory[kind.String()] = mem - extra
+		}
+	}
+	return free
 }
 
 // ExportResourceData provides resource data to export for the container.
@@ -365,26 +493,30 @@ func (p *policy) reallocateResources(containers []cache.Container, pools map[str
 
 	cache.SortContainers(containers)
 
-	for _, c := range containers {
-		p.releasePool(c)
-	}
-	for _, c := range containers {
-		log.Debug("reallocating resources for %s...", c.PrettyName())
+	p.batchUpdates(func() {
+		for _, c := range containers {
+			p.releasePool(c)
+		}
+		for _, c := range containers {
+			log.Debug("reallocating resources for %s...", c.PrettyName())
 
-		grant, err := p.allocatePool(c, pools[c.GetCacheID()])
-		if err != nil {
-			errs = append(errs, err)
-		} else {
-			p.applyGrant(grant)
+			grant, err := p.allocatePool(c, pools[c.GetCacheID()])
+			if err != nil {
+				errs = append(errs, err)
+			} else {
+				p.applyGrant(grant)
+			}
 		}
-	}
+
+		if len(errs) == 0 {
+			p.updateSharedAllocations(nil)
+		}
+	})
 
 	if len(errs) > 0 {
 		return errors.Join(errs...)
 	}
 
-	p.updateSharedAllocations(nil)
-
 	p.root.Dump("<post-realloc>")
 
 	return nil
@@ -392,8 +524,17 @@ func (p *policy) reallocateResources(containers []cache.Container, pools map[str
 
 func (p *policy) configNotify(event config.Event, source config.Source) error {
 	policyName := PolicyName
+	// For the alias, validate before copying. Otherwise opt is already
+	// updated and the config package reverts it if we reject it.
+	newOpt := opt
 	if p.isAlias {
 		policyName = AliasName
+		newOpt = aliasOpt
+	}
+	if err := newOpt.validate(); err != nil {
+		return err
+	}
+	if p.isAlias {
 		*opt = *aliasOpt
 	}
 	log.Info("%s configuration %s:", policyName, event)
@@ -402,6 +543,62 @@ func (p *policy) configNotify(event config.Event, source config.Source) error {
 	log.Info("  - prefer isolated CPUs: %v", opt.PreferIsolated)
 	log.Info("  - prefer shared CPUs: %v", opt.PreferShared)
 	log.Info("  - reserved pool namespaces: %v", opt.ReservedPoolNamespaces)
+	log.Info("  - isolated pool namespaces: %v", opt.IsolatedPoolNamespaces)
+	log.Info("  - cpuset partitions for exclusive CPUs: %v", opt.CpusetPartition)
+	log.Info("  - announce exclusive CPUs in cpuset: %v", opt.CpusetExclusive)
+	log.Info("  - reserved CPU sharing threshold: %d%%", opt.ReservedCPUSharingThreshold)
+	log.Info("  - preferred locality: %s", localityPreference())
+	log.Info("  - evict lower-priority containers: %v", opt.PriorityEviction)
+	log.Info("  - reserve SMT siblings of reserved CPUs: %v", opt.ReserveSMTSiblings)
+	log.Info("  - pre-pinned containers: %s", prePinnedContainers())
+	if len(opt.SharedOnlyPods) > 0 || opt.SharedOnlyJobPods {
+		log.Info("  - shared-only pods: %v, job pods: %v", opt.SharedOnlyPods, opt.SharedOnlyJobPods)
+	}
+	if err := p.updateIRQCPUs(); err != nil {
+		return err
+	}
+	if !p.irqCPUs.IsEmpty() {
+		log.Info("  - CPUs to avoid for exclusive allocations: %s", p.irqCPUs)
+	}
+	if err := p.updateLLCs(); err != nil {
+		return err
+	}
+	if opt.PreferLLCLocality {
+		log.Info("  - prefer last-level caches close to memory, %d caches", len(p.llcs))
+	}
+	if len(opt.DedicatedHugePageNodes) > 0 {
+		log.Info("  - NUMA nodes with dedicated hugepages: %v", opt.DedicatedHugePageNodes)
+	}
+	if opt.ExclusiveCPUReleaseDelay > 0 {
+		log.Info("  - exclusive CPU release delay: %v", time.Duration(opt.ExclusiveCPUReleaseDelay))
+	}
+	if opt.MaxContainersPerPool > 0 || len(opt.MaxContainersByPool) > 0 {
+		log.Info("  - max. containers per pool: %d, by pool: %v",
+			opt.MaxContainersPerPool, opt.MaxContainersByPool)
+	}
+	if err := p.updateRAPL(); err != nil {
+		return err
+	}
+	if len(opt.MaxActiveCPUs) > 0 || len(opt.PowerBudget) > 0 {
+		log.Info("  - max. active CPUs by pool: %v, power budget by pool: %v",
+			opt.MaxActiveCPUs, opt.PowerBudget)
+	}
+	if opt.HBMBandwidth.Value() > 0 {
+		log.Info("  - HBM bandwidth per node: %s, per CPU: %s",
+			opt.HBMBandwidth.String(), opt.HBMBandwidthPerCPU.String())
+	}
+	for qos, threshold := range opt.ExclusiveCPURoundUp {
+		log.Info("  - round %s CPU fractions >= %s up to exclusive CPU", qos, threshold.String())
+	}
+
+	for qos, value := range opt.MemorySpilloverOrder {
+		log.Info("  - memory spillover order for %s: %s", qos, value)
+	}
+	for name, fallback := range opt.MemoryTypeFallback {
+		log.Info("  - fallback for missing %s memory: %s", name, fallback)
+	}
+	log.Info("  - pool levels: die %s, numa %s", poolLevel(diePoolLevel), poolLevel(numaPoolLevel))
+	log.Info("  - initial placement: %s", initialPlacement())
 
 	var allowed, reserved cpuset.CPUSet
 	var reinit bool
@@ -415,6 +612,9 @@ func (p *policy) configNotify(event config.Event, source config.Source) error {
 		switch v := cpus.(type) {
 		case cpuset.CPUSet:
 			reserved = v
+			if opt.ReserveSMTSiblings {
+				reserved = p.withSMTSiblings(v)
+			}
 		case resapi.Quantity:
 			reserveCnt := (int(v.MilliValue()) + 999) / 1000
 			if reserveCnt != p.reserveCnt {
@@ -432,6 +632,21 @@ func (p *policy) configNotify(event config.Event, source config.Source) error {
 			reinit = true
 		}
 	}
+	if opt.ReserveSMTSiblings != p.reserveSMT {
+		log.Warn("SMT sibling reservation changed (%v, was %v)",
+			opt.ReserveSMTSiblings, p.reserveSMT)
+		reinit = true
+	}
+	for level, mode := range p.poolLevels {
+		if poolLevel(level) != mode {
+			log.Warn("%s pool level changed (%s, was %s)", level, poolLevel(level), mode)
+			reinit = true
+		}
+	}
+	if nodes := dedicatedHugePageNodes(); nodes.String() != p.hugePageNodes.String() {
+		log.Warn("dedicated hugepage nodes changed (%s, was %s)", nodes, p.hugePageNodes)
+		reinit = true
+	}
 	if !reserved.Equals(p.reserved) {
 		if !(reserved.Size() == 0 && p.reserved.Size() == 0) {
 			log.Warn("reserved cpuset changed (%s, was %s)",
@@ -473,6 +688,8 @@ func (p *policy) configNotify(event config.Event, source config.Source) error {
 			*p = savedPolicy
 			return policyError("failed to reconfigure: %v", err)
 		}
+		p.restorePrePinned()
+		p.updateMetrics()
 
 		p.root.Dump("<post-config>")
 	}
@@ -488,11 +705,26 @@ func (p *policy) initialize() error {
 	p.nodeCnt = 0
 	p.depth = 0
 	p.allocations = p.newAllocations()
+	p.dropHeldCPUs()
+	p.prePinned = make(map[string]Grant)
+	p.llcs = nil
 
 	if err := p.checkConstraints(); err != nil {
 		return err
 	}
 
+	if err := p.updateIRQCPUs(); err != nil {
+		return err
+	}
+
+	if err := p.updateLLCs(); err != nil {
+		return err
+	}
+
+	if err := p.updateRAPL(); err != nil {
+		return err
+	}
+
 	if err := p.buildPoolsByTopology(); err != nil {
 		return err
 	}
@@ -549,10 +781,36 @@ func (p *policy) checkConstraints() error {
 		return policyError("cannot start without CPU reservation")
 	}
 
+	p.reserveSMT = opt.ReserveSMTSiblings
+	if p.reserveSMT {
+		if cset := p.withSMTSiblings(p.reserved); !cset.Equals(p.reserved) {
+			log.Info("extending reserved CPUs %s with SMT siblings to %s", p.reserved, cset)
+			p.reserved = cset
+			p.isolated = p.isolated.Difference(cset)
+		}
+	}
+
 	return nil
 }
 
+// withSMTSiblings returns the given CPUs together with their allowed SMT siblings.
+func (p *policy) withSMTSiblings(cpus cpuset.CPUSet) cpuset.CPUSet {
+	siblings := cpus
+	for _, id := range cpus.List() {
+		siblings = siblings.Union(p.sys.CPU(id).ThreadCPUSet())
+	}
+	return siblings.Intersection(p.allowed)
+}
+
 func (p *policy) restoreCache() error {
+	owners := map[string]string{}
+	if p.cache.GetPolicyEntry(keyPodPools, &owners) {
+		p.restorePodPools(owners)
+	}
+	demoted := map[string]string{}
+	if p.cache.GetPolicyEntry(keyDemoted, &demoted) {
+		p.demoted = demoted
+	}
 	allocations := p.newAllocations()
 	if p.cache.GetPolicyEntry(keyAllocations, &allocations) {
 		if err := p.restoreAllocations(&allocations); err != nil {
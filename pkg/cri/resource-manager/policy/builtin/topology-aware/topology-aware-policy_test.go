@@ -0,0 +1,304 @@
+// Copyright 2026 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package topologyaware
+
+import (
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	resapi "k8s.io/apimachinery/pkg/api/resource"
+
+	config "github.com/intel/cri-resource-manager/pkg/config"
+	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/cache"
+	policyapi "github.com/intel/cri-resource-manager/pkg/cri/resource-manager/policy"
+	system "github.com/intel/cri-resource-manager/pkg/sysfs"
+	"github.com/intel/cri-resource-manager/pkg/utils"
+	"github.com/intel/cri-resource-manager/pkg/utils/cpuset"
+	idset "github.com/intel/goresctrl/pkg/utils"
+)
+
+func TestReserveSMTSiblings(t *testing.T) {
+	// 4 cores with 2 threads each, CPU n and n+4 being siblings.
+	threads := map[idset.ID]cpuset.CPUSet{}
+	for id := 0; id < 8; id++ {
+		threads[id] = cpuset.New(id%4, id%4+4)
+	}
+
+	tcases := []struct {
+		name             string
+		reserveSMT       bool
+		allowed          string
+		reserved         string
+		expectedReserved string
+	}{
+		{
+			name:             "siblings not reserved",
+			allowed:          "0-7",
+			reserved:         "0",
+			expectedReserved: "0",
+		},
+		{
+			name:             "sibling reserved",
+			reserveSMT:       true,
+			allowed:          "0-7",
+			reserved:         "0",
+			expectedReserved: "0,4",
+		},
+		{
+			name:             "full core already reserved",
+			reserveSMT:       true,
+			allowed:          "0-7",
+			reserved:         "1,5",
+			expectedReserved: "1,5",
+		},
+		{
+			name:             "sibling not allowed",
+			reserveSMT:       true,
+			allowed:          "0-3",
+			reserved:         "0-1",
+			expectedReserved: "0-1",
+		},
+	}
+	defer func() { opt.ReserveSMTSiblings = false }()
+	for _, tc := range tcases {
+		t.Run(tc.name, func(t *testing.T) {
+			opt.ReserveSMTSiblings = tc.reserveSMT
+			p := &policy{
+				sys: &mockSystem{threads: threads},
+				options: &policyapi.BackendOptions{
+					Available: policyapi.ConstraintSet{
+						policyapi.DomainCPU: cpuset.MustParse(tc.allowed),
+					},
+					Reserved: policyapi.ConstraintSet{
+						policyapi.DomainCPU: cpuset.MustParse(tc.reserved),
+					},
+				},
+			}
+			if err := p.checkConstraints(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if expected := cpuset.MustParse(tc.expectedReserved); !p.reserved.Equals(expected) {
+				t.Errorf("expected reserved CPUs %s, got %s", expected, p.reserved)
+			}
+		})
+	}
+}
+
+func TestDescribeFreeCapacity(t *testing.T) {
+	dir, err := os.MkdirTemp("", "cri-resource-manager-test-sysfs-")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+
+	err = utils.UncompressTbz2(path.Join("testdata", "sysfs.tar.bz2"), dir)
+	if err != nil {
+		panic(err)
+	}
+
+	sys, err := system.DiscoverSystemAt(path.Join(dir, "sysfs", "desktop", "sys"))
+	if err != nil {
+		panic(err)
+	}
+
+	reserved, _ := resapi.ParseQuantity("750m")
+	policyOptions := &policyapi.BackendOptions{
+		Cache:  &mockCache{},
+		System: sys,
+		Reserved: policyapi.ConstraintSet{
+			policyapi.DomainCPU: reserved,
+		},
+	}
+	p := CreateTopologyAwarePolicy(policyOptions).(*policy)
+
+	supply := p.root.FreeSupply()
+	before := describeFreeCapacity(supply)
+	if before.SharableCPUs != supply.SharableCPUs().String() {
+		t.Errorf("expected sharable CPUs %s, got %s", supply.SharableCPUs(), before.SharableCPUs)
+	}
+	if expected := supply.SharableCPUs().Size() - 1; before.ExclusiveCPUs != expected {
+		t.Errorf("expected %d exclusive CPUs, got %d", expected, before.ExclusiveCPUs)
+	}
+	if before.Memory["DRAM"] == 0 {
+		t.Errorf("expected free DRAM, got %v", before.Memory)
+	}
+
+	exclusive := cpuset.New(supply.SharableCPUs().List()[:2]...)
+	grant := newGrant(p.root, &mockContainer{name: "exclusive"}, cpuNormal, exclusive, 0, 0, nil, 0)
+	if err := supply.Reserve(grant); err != nil {
+		t.Fatalf("failed to reserve exclusive CPUs: %v", err)
+	}
+
+	after := describeFreeCapacity(supply)
+	if after.ExclusiveCPUs != before.ExclusiveCPUs-2 {
+		t.Errorf("expected %d exclusive CPUs after allocation, got %d",
+			before.ExclusiveCPUs-2, after.ExclusiveCPUs)
+	}
+	if after.AllocatableShared != before.AllocatableShared-2000 {
+		t.Errorf("expected %dm allocatable shared CPU after allocation, got %dm",
+			before.AllocatableShared-2000, after.AllocatableShared)
+	}
+
+	extra := uint64(1 << 20)
+	child := newGrant(p.root, &mockContainer{name: "child"}, cpuNormal, cpuset.New(), 0,
+		memoryDRAM, createMemoryMap(extra, 0, 0), 0)
+	supply.SetExtraMemoryReservation(child)
+	if free := describeFreeCapacity(supply).Memory["DRAM"]; free != before.Memory["DRAM"]-extra {
+		t.Errorf("expected %d free DRAM with extra memory reserved, got %d",
+			before.Memory["DRAM"]-extra, free)
+	}
+}
+
+func TestValidateOptions(t *testing.T) {
+	tcases := []struct {
+		name    string
+		opt     options
+		invalid bool
+	}{
+		{
+			name: "defaults",
+			opt:  *defaultOptions().(*options),
+		},
+		{
+			name: "valid locality preference",
+			opt:  options{PreferLocality: preferMemoryLocality},
+		},
+		{
+			name:    "invalid locality preference",
+			opt:     options{PreferLocality: "nearby"},
+			invalid: true,
+		},
+		{
+			name:    "invalid initial placement",
+			opt:     options{InitialPlacement: "scattered"},
+			invalid: true,
+		},
+		{
+			name:    "invalid pool level",
+			opt:     options{PoolLevels: map[string]poolLevelMode{"socket": alwaysPoolLevel}},
+			invalid: true,
+		},
+		{
+			name:    "invalid power budget",
+			opt:     options{PowerBudget: map[string]float64{"socket #0": 0}},
+			invalid: true,
+		},
+	}
+	for _, tc := range tcases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.opt.validate()
+			if tc.invalid && err == nil {
+				t.Errorf("expected options to be rejected")
+			}
+			if !tc.invalid && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestRejectedConfigNotApplied(t *testing.T) {
+	saved, savedAlias := *opt, *aliasOpt
+	defer func() { *opt, *aliasOpt = saved, savedAlias }()
+
+	opt.PreferLocality = preferCPULocality
+	aliasOpt.PreferLocality = "nearby"
+
+	p := &policy{isAlias: true}
+	if err := p.configNotify(config.UpdateEvent, config.ConfigFile); err == nil {
+		t.Fatalf("expected invalid locality preference to be rejected")
+	}
+	if localityPreference() != preferCPULocality {
+		t.Errorf("rejected locality preference %q took effect", localityPreference())
+	}
+}
+
+func TestRebalanceDoesNotHoldCPUs(t *testing.T) {
+	dir, err := os.MkdirTemp("", "cri-resource-manager-test-sysfs-")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+
+	err = utils.UncompressTbz2(path.Join("testdata", "sysfs.tar.bz2"), dir)
+	if err != nil {
+		panic(err)
+	}
+
+	sys, err := system.DiscoverSystemAt(path.Join(dir, "sysfs", "desktop", "sys"))
+	if err != nil {
+		panic(err)
+	}
+
+	burstable := &recordingContainer{
+		mockContainer: mockContainer{
+			name:                     "burstable",
+			returnValueForGetCacheID: "burstable",
+			returnValueForQOSClass:   v1.PodQOSBurstable,
+			returnValueForGetResourceRequirements: v1.ResourceRequirements{
+				Requests: v1.ResourceList{v1.ResourceCPU: resapi.MustParse("2")},
+			},
+			pod: &mockPod{name: "pod", annotations: map[string]string{}},
+		},
+	}
+	reserved, _ := resapi.ParseQuantity("750m")
+	policyOptions := &policyapi.BackendOptions{
+		Cache: &mockCache{
+			returnValueForGetContainers:    []cache.Container{burstable},
+			returnValue1ForLookupContainer: burstable,
+			returnValue2ForLookupContainer: true,
+		},
+		System: sys,
+		Reserved: policyapi.ConstraintSet{
+			policyapi.DomainCPU: reserved,
+		},
+	}
+	p := CreateTopologyAwarePolicy(policyOptions).(*policy)
+
+	defer func(saved config.Duration) { opt.ExclusiveCPUReleaseDelay = saved }(opt.ExclusiveCPUReleaseDelay)
+	opt.ExclusiveCPUReleaseDelay = config.Duration(time.Hour)
+	defer p.dropHeldCPUs()
+
+	reserve := func() {
+		supply := p.root.FreeSupply()
+		exclusive := cpuset.New(supply.SharableCPUs().List()[:2]...)
+		grant := newGrant(p.root, burstable, cpuNormal, exclusive, 0, 0, nil, 0)
+		if err := supply.Reserve(grant); err != nil {
+			t.Fatalf("failed to reserve exclusive CPUs: %v", err)
+		}
+		p.allocations.grants[burstable.GetCacheID()] = grant
+	}
+
+	reserve()
+	if _, err := p.Rebalance(); err != nil {
+		t.Fatalf("failed to rebalance: %v", err)
+	}
+	if len(p.heldGrants) != 0 {
+		t.Errorf("expected no CPUs held for a rebalanced container, got %d held grants", len(p.heldGrants))
+	}
+	if _, ok := p.allocations.grants[burstable.GetCacheID()]; !ok {
+		t.Fatalf("expected rebalanced container to be re-allocated")
+	}
+
+	p.releaseResources(burstable, false)
+	reserve()
+	p.ReleaseResources(burstable)
+	if _, ok := p.heldGrants[burstable.GetCacheID()]; !ok {
+		t.Errorf("expected exclusive CPUs of a removed container to be held")
+	}
+}
@@ -251,26 +251,38 @@ func NewPolicy(cache cache.Cache, o *Options) (Policy, error) {
 // Start starts up policy, preparing it for resving requests.
 func (p *policy) Start(add []cache.Container, del []cache.Container) error {
 	log.Info("starting policy '%s'...", p.active.Name())
-	return p.active.Start(add, del)
+	return p.active.Start(cache.ManagedContainers(add), cache.ManagedContainers(del))
 }
 
 // Sync synchronizes the active policy state.
 func (p *policy) Sync(add []cache.Container, del []cache.Container) error {
-	return p.active.Sync(add, del)
+	return p.active.Sync(cache.ManagedContainers(add), cache.ManagedContainers(del))
 }
 
 // AllocateResources allocates resources for a container.
 func (p *policy) AllocateResources(c cache.Container) error {
+	if !c.IsManaged() {
+		log.Info("skipping resource allocation for unmanaged container %s", c.PrettyName())
+		return nil
+	}
 	return p.active.AllocateResources(c)
 }
 
 // ReleaseResources release resources of a container.
 func (p *policy) ReleaseResources(c cache.Container) error {
+	if !c.IsManaged() {
+		log.Debug("skipping resource release for unmanaged container %s", c.PrettyName())
+		return nil
+	}
 	return p.active.ReleaseResources(c)
 }
 
 // UpdateResources updates resource allocations of a container.
 func (p *policy) UpdateResources(c cache.Container) error {
+	if !c.IsManaged() {
+		log.Debug("skipping resource update for unmanaged container %s", c.PrettyName())
+		return nil
+	}
 	return p.active.UpdateResources(c)
 }
 
@@ -288,6 +300,10 @@ func (p *policy) HandleEvent(e *events.Policy) (bool, error) {
 func (p *policy) ExportResourceData(c cache.Container) {
 	var buf bytes.Buffer
 
+	if !c.IsManaged() {
+		return
+	}
+
 	data := p.active.ExportResourceData(c)
 	keys := []string{}
 	for key := range data {
@@ -310,7 +326,21 @@ func (p *policy) ExportResourceData(c cache.Container) {
 
 // Introspect provides data for external introspection/visualization.
 func (p *policy) Introspect() *introspect.State {
-	pods := p.cache.GetPods()
+	// Describe pods and containers from a detached snapshot of the
+	// cache. Taking the snapshot needs the resource manager lock,
+	// which our callers hold.
+	pods := []cache.Pod{}
+	seen := map[string]struct{}{}
+	for _, c := range p.cache.GetContainersSnapshot() {
+		pod, ok := c.GetPod()
+		if !ok {
+			continue
+		}
+		if _, ok := seen[pod.GetID()]; !ok {
+			seen[pod.GetID()] = struct{}{}
+			pods = append(pods, pod)
+		}
+	}
 	state := &introspect.State{Pods: make(map[string]*introspect.Pod, len(pods))}
 
 	for _, p := range pods {
@@ -390,6 +420,9 @@ func (p *policy) Introspect() *introspect.State {
 	p.inspsys.Policy = opt.Policy
 
 	state.System = p.inspsys
+	for _, err := range p.cache.SelfCheck() {
+		state.CacheErrors = append(state.CacheErrors, err.Error())
+	}
 	p.active.Introspect(state)
 
 	return state
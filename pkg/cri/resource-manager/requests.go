@@ -211,6 +211,9 @@ func (m *resmgr) syncWithCRI(ctx context.Context) ([]cache.Container, []cache.Co
 		del = append(del, c)
 	}
 
+	// let the policy see discovered containers in creation order
+	cache.SortContainers(add, cache.CompareByCreation)
+
 	return add, del, nil
 }
 
@@ -19,6 +19,7 @@ import (
 	"os"
 	"os/signal"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 
@@ -299,15 +300,48 @@ func (m *resmgr) resetCachedConfig() int {
 func (m *resmgr) setupCache() error {
 	var err error
 
-	options := cache.Options{CacheDir: opt.RelayDir}
+	options := cache.Options{
+		CacheDir:          opt.RelayDir,
+		EncryptionKeyFile: opt.CacheKeyFile,
+	}
 	if m.cache, err = cache.NewCache(options); err != nil {
 		return resmgrError("failed to create cache: %v", err)
 	}
+	if err = m.cache.AddConfigNotify("resource-manager", m.logConfigChanges); err != nil {
+		return resmgrError("failed to subscribe to configuration changes: %v", err)
+	}
 
 	return nil
 
 }
 
+// logConfigChanges logs which sections of the cached configuration changed.
+func (m *resmgr) logConfigChanges(oldCfg, newCfg *config.RawConfig) {
+	oldData, newData := map[string]string{}, map[string]string{}
+	if oldCfg != nil {
+		oldData = oldCfg.Data
+	}
+	if newCfg != nil {
+		newData = newCfg.Data
+	}
+	changed := []string{}
+	for key, value := range newData {
+		if oldValue, ok := oldData[key]; !ok || oldValue != value {
+			changed = append(changed, key)
+		}
+	}
+	for key := range oldData {
+		if _, ok := newData[key]; !ok {
+			changed = append(changed, key)
+		}
+	}
+	if len(changed) == 0 {
+		return
+	}
+	sort.Strings(changed)
+	m.Info("cached configuration changed in %s", strings.Join(changed, ", "))
+}
+
 // setupAgentInterface sets up the connection to the node agent.
 func (m *resmgr) setupAgentInterface() error {
 	var err error
@@ -62,6 +62,11 @@ explicitly enabled, otherwise messages are suppressed.
 If a dump file is specified messages will be dumped additionally
 to the dump file as well.
 
+If the dump file is an existing named pipe (FIFO), messages are
+written to it without ever blocking. Messages are dropped while no
+reader is attached to the pipe, or if the reader can't keep up. A
+reader can attach to and detach from the pipe at any time.
+
 Here is a sample configuration fragment to suppress all .*List.*
 calls, produce short dumps of all .*Stop.* calls, and full dumps
 of everything else, dumps also going to the file '/tmp/cri-dump.log'
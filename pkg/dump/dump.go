@@ -30,6 +30,7 @@ package dump
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"sigs.k8s.io/yaml"
 	"strings"
@@ -54,7 +55,7 @@ type dumper struct {
 	disabled     bool             // dumping globally disabled
 	debug        bool             // dump as debug messages
 	path         string           // extra dump file path
-	file         *os.File         // extra dump file
+	file         io.WriteCloser   // extra dump file or named pipe
 	methods      []string         // training set for config
 	q            chan *dumpreq
 }
@@ -205,11 +206,17 @@ func (d *dumper) configure(o *options) {
 
 		d.path = o.File
 		if d.path != "" {
-			var err error
-			log.Info("opening new message dump file %q...", d.path)
-			d.file, err = os.Create(d.path)
-			if err != nil {
-				log.Error("failed to open file %q: %v", d.path, err)
+			if isFifo(d.path) {
+				log.Info("opening new message dump pipe %q...", d.path)
+				d.file = openFifo(d.path)
+			} else {
+				log.Info("opening new message dump file %q...", d.path)
+				file, err := os.Create(d.path)
+				if err != nil {
+					log.Error("failed to open file %q: %v", d.path, err)
+				} else {
+					d.file = file
+				}
 			}
 		}
 	}
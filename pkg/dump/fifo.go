@@ -0,0 +1,104 @@
+// Copyright 2026 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dump
+
+import (
+	"os"
+	"sync/atomic"
+	"syscall"
+)
+
+// fifo is a non-blocking writer for a named pipe.
+//
+// Writes never block. If no reader is attached or the reader does not keep
+// up, the written data is silently dropped and accounted for in a counter.
+// A reader can attach and detach at any time.
+type fifo struct {
+	path    string // path to the named pipe
+	fd      int    // pipe file descriptor, -1 if not connected to a reader
+	dropped uint64 // number of dropped writes
+}
+
+// isFifo checks if the given path is an existing named pipe.
+func isFifo(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeNamedPipe != 0
+}
+
+// openFifo creates a non-blocking writer for the given named pipe.
+func openFifo(path string) *fifo {
+	f := &fifo{path: path, fd: -1}
+	f.connect()
+	return f
+}
+
+// connect tries to open the named pipe for writing, failing if there is no reader.
+func (f *fifo) connect() bool {
+	if f.fd >= 0 {
+		return true
+	}
+	fd, err := syscall.Open(f.path, syscall.O_WRONLY|syscall.O_NONBLOCK|syscall.O_CLOEXEC, 0)
+	if err != nil {
+		return false
+	}
+	f.fd = fd
+	if dropped := f.Dropped(); dropped > 0 {
+		log.Info("reader attached to message dump pipe %q (%d dumps dropped so far)",
+			f.path, dropped)
+	}
+	return true
+}
+
+// disconnect closes the named pipe once the reader is gone.
+func (f *fifo) disconnect() {
+	if f.fd >= 0 {
+		syscall.Close(f.fd)
+		f.fd = -1
+	}
+}
+
+// Write writes the data to the named pipe, dropping it if it can't be written.
+func (f *fifo) Write(data []byte) (int, error) {
+	if !f.connect() {
+		atomic.AddUint64(&f.dropped, 1)
+		return len(data), nil
+	}
+
+	n, err := syscall.Write(f.fd, data)
+	switch {
+	case err == syscall.EPIPE:
+		log.Info("reader detached from message dump pipe %q", f.path)
+		f.disconnect()
+		atomic.AddUint64(&f.dropped, 1)
+	case err != nil || n < len(data):
+		atomic.AddUint64(&f.dropped, 1)
+	}
+
+	return len(data), nil
+}
+
+// Close closes the named pipe.
+func (f *fifo) Close() error {
+	f.disconnect()
+	return nil
+}
+
+// Dropped returns the number of writes dropped so far.
+func (f *fifo) Dropped() uint64 {
+	return atomic.LoadUint64(&f.dropped)
+}
@@ -0,0 +1,74 @@
+// Copyright 2026 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dump
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+func TestFifo(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dump.fifo")
+	if err := syscall.Mkfifo(path, 0600); err != nil {
+		t.Skipf("failed to create named pipe: %v", err)
+	}
+	if !isFifo(path) {
+		t.Fatalf("%q not detected as a named pipe", path)
+	}
+	if isFifo(filepath.Dir(path)) {
+		t.Fatalf("%q incorrectly detected as a named pipe", filepath.Dir(path))
+	}
+
+	f := openFifo(path)
+	defer f.Close()
+
+	// without a reader writes should be dropped, not block
+	for i := 0; i < 3; i++ {
+		if _, err := f.Write([]byte("dropped\n")); err != nil {
+			t.Fatalf("unexpected write error: %v", err)
+		}
+	}
+	if dropped := f.Dropped(); dropped != 3 {
+		t.Errorf("expected 3 dropped writes, got %d", dropped)
+	}
+
+	// with a reader attached writes should get through
+	r, err := os.OpenFile(path, os.O_RDONLY|syscall.O_NONBLOCK, 0)
+	if err != nil {
+		t.Fatalf("failed to open named pipe for reading: %v", err)
+	}
+	if _, err := f.Write([]byte("delivered\n")); err != nil {
+		t.Fatalf("unexpected write error: %v", err)
+	}
+	line, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil {
+		t.Fatalf("failed to read from named pipe: %v", err)
+	}
+	if line != "delivered\n" {
+		t.Errorf("expected %q, got %q", "delivered\n", line)
+	}
+
+	// once the reader is gone, writes should be dropped again
+	r.Close()
+	if _, err := f.Write([]byte("dropped\n")); err != nil {
+		t.Fatalf("unexpected write error: %v", err)
+	}
+	if dropped := f.Dropped(); dropped != 4 {
+		t.Errorf("expected 4 dropped writes, got %d", dropped)
+	}
+}
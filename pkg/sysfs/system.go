@@ -120,6 +120,8 @@ type Node interface {
 	MemoryInfo() (*MemInfo, error)
 	GetMemoryType() MemoryType
 	HasNormalMemory() bool
+	FreeHugePages(pageSize uint64) (uint64, error)
+	TotalHugePages() (uint64, error)
 }
 
 type node struct {
@@ -898,6 +900,37 @@ func (n *node) HasNormalMemory() bool {
 	return n.normalMem
 }
 
+// FreeHugePages returns the number of free hugepages of the given size (in bytes) in the node.
+func (n *node) FreeHugePages(pageSize uint64) (uint64, error) {
+	var free uint64
+	entry := filepath.Join("hugepages", fmt.Sprintf("hugepages-%dkB", pageSize/1024), "free_hugepages")
+	if _, err := readSysfsEntry(n.path, entry, &free); err != nil {
+		return 0, err
+	}
+	return free, nil
+}
+
+// TotalHugePages returns the amount of memory preallocated as hugepages of any size in the node, in bytes.
+func (n *node) TotalHugePages() (uint64, error) {
+	dirs, err := filepath.Glob(filepath.Join(n.path, "hugepages", "hugepages-*kB"))
+	if err != nil {
+		return 0, err
+	}
+	var total uint64
+	for _, dir := range dirs {
+		var size, pages uint64
+		name := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(dir), "hugepages-"), "kB")
+		if size, err = strconv.ParseUint(name, 10, 64); err != nil {
+			return 0, sysfsError(dir, "invalid hugepage size %q: %v", name, err)
+		}
+		if _, err := readSysfsEntry(dir, "nr_hugepages", &pages); err != nil {
+			return 0, err
+		}
+		total += pages * size * 1024
+	}
+	return total, nil
+}
+
 // Discover physical packages (CPU sockets) present in the system.
 func (sys *system) discoverPackages() error {
 	if sys.packages != nil {